@@ -18,6 +18,7 @@ func main() {
 	port := flag.Int("port", 8080, "Port to run the server on")
 	host := flag.String("host", "localhost", "Host to bind the server to")
 	noBrowser := flag.Bool("no-browser", false, "Don't automatically open browser")
+	forwardAddr := flag.String("forward", "", "Listen address for the Fluent Forward receiver, e.g. localhost:24224 (disabled if empty)")
 	flag.Parse()
 
 	// Load settings to get polling interval
@@ -26,6 +27,7 @@ func main() {
 	// Create configuration
 	cfg := config.New(*host, *port)
 	cfg.PollingInterval = time.Duration(appSettings.PollingIntervalMs) * time.Millisecond
+	cfg.ForwardAddr = *forwardAddr
 
 	// Print startup info
 	url := fmt.Sprintf("http://%s:%d", *host, *port)
@@ -0,0 +1,448 @@
+package alerts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/weblogview/internal/anomaly"
+	"github.com/yourusername/weblogview/internal/config"
+	"github.com/yourusername/weblogview/internal/logline"
+	"github.com/yourusername/weblogview/internal/parser"
+	"github.com/yourusername/weblogview/internal/query"
+	"github.com/yourusername/weblogview/internal/settings"
+	"github.com/yourusername/weblogview/internal/sources"
+	"github.com/yourusername/weblogview/internal/watcher"
+)
+
+const (
+	// defaultWindow is the sliding window of rules that don't set one
+	defaultWindow = time.Minute
+
+	// maxAlertLines is the number of matching lines sent with an alert
+	maxAlertLines = 20
+
+	// maxHistory is the number of recent alerts kept for listing
+	maxHistory = 100
+
+	// feedTimeout is how long a tab keeps feeding a source before another
+	// tab that has it open may take over
+	feedTimeout = 30 * time.Second
+
+	// webhookTimeout limits a webhook request
+	webhookTimeout = 10 * time.Second
+)
+
+// Alert is an event fired by a rule
+type Alert struct {
+	Rule          string   `json:"rule"`
+	Source        string   `json:"source"` // File path or source ID
+	Count         int      `json:"count"`  // Matches within the window
+	Threshold     int      `json:"threshold"`
+	WindowSeconds int      `json:"windowSeconds"`
+	Time          int64    `json:"time"`  // Unix milliseconds
+	Lines         []string `json:"lines"` // The most recent matching lines
+}
+
+// rule is a compiled alert rule
+type rule struct {
+	settings.AlertRule
+	window time.Duration
+	match  func(logline.Line) bool
+}
+
+// state tracks the matches of one rule on one source
+type state struct {
+	matches   []time.Time
+	lines     []string
+	lastFired time.Time
+}
+
+// feed is the tab currently feeding a source
+type feed struct {
+	owner interface{}
+	last  time.Time
+}
+
+// Engine evaluates the alert rules from settings continuously: on every
+// ingested stream, on the files named in rules (watched by the backend
+// whether or not a tab has them open) and on the sources open in tabs.
+// Each source is evaluated once, however many tabs show it. The same
+// lines feed the engine's anomaly detector.
+type Engine struct {
+	config    *config.Config
+	streams   *sources.Registry
+	client    *http.Client
+	anomalies *anomaly.Detector
+
+	mu       sync.Mutex
+	rules    []*rule
+	states   map[string]*state // By rule name and source
+	watchers map[string]*watcher.FileWatcher
+	feeds    map[string]feed
+	history  []Alert
+	notify   func(Alert)
+}
+
+// NewEngine creates an engine for the rules in settings and starts
+// observing the ingested streams. Call Reload to start watching the
+// files named in rules.
+func NewEngine(cfg *config.Config, streams *sources.Registry) *Engine {
+	e := &Engine{
+		config:    cfg,
+		streams:   streams,
+		client:    &http.Client{Timeout: webhookTimeout},
+		anomalies: anomaly.NewDetector(),
+		states:    map[string]*state{},
+		watchers:  map[string]*watcher.FileWatcher{},
+		feeds:     map[string]feed{},
+		history:   []Alert{},
+	}
+	streams.Observe(func(stream *sources.Stream, lines []logline.Line) {
+		e.evaluate(stream.ID, enrich(parser.ForSource(stream.ID, "", ""), lines))
+	})
+	return e
+}
+
+// Anomalies returns the anomaly detector fed with the engine's sources
+func (e *Engine) Anomalies() *anomaly.Detector {
+	return e.anomalies
+}
+
+// OnAlert registers fn to be called for every alert fired
+func (e *Engine) OnAlert(fn func(Alert)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.notify = fn
+}
+
+// Validate checks that a rule is complete and its pattern compiles
+func Validate(cfg settings.AlertRule) error {
+	if cfg.Name == "" {
+		return fmt.Errorf("name required")
+	}
+	if cfg.Threshold < 0 || cfg.WindowSeconds < 0 {
+		return fmt.Errorf("threshold and window must not be negative")
+	}
+	if cfg.Webhook != "" {
+		u, err := url.Parse(cfg.Webhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid webhook URL: %s", cfg.Webhook)
+		}
+	}
+	for _, glob := range cfg.Sources {
+		if _, err := filepath.Match(glob, ""); err != nil {
+			return fmt.Errorf("invalid source pattern %q: %v", glob, err)
+		}
+	}
+	_, err := compile(cfg)
+	return err
+}
+
+// compile builds the matcher of a rule
+func compile(cfg settings.AlertRule) (*rule, error) {
+	if cfg.Pattern == "" {
+		return nil, fmt.Errorf("pattern required")
+	}
+
+	r := &rule{AlertRule: cfg, window: defaultWindow}
+	if cfg.WindowSeconds > 0 {
+		r.window = time.Duration(cfg.WindowSeconds) * time.Second
+	}
+	if r.Threshold <= 0 {
+		r.Threshold = 1
+	}
+
+	if cfg.Regex {
+		re, err := regexp.Compile(cfg.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex: %v", err)
+		}
+		r.match = func(line logline.Line) bool { return re.MatchString(line.Text) }
+		return r, nil
+	}
+
+	q, err := query.Parse(cfg.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %v", err)
+	}
+	if q.Aggregates() {
+		return nil, fmt.Errorf("alert rules can't aggregate")
+	}
+	r.match = q.Match
+	return r, nil
+}
+
+// Reload recompiles the rules from settings, starting their windows over,
+// and watches the files they name
+func (e *Engine) Reload() {
+	var rules []*rule
+	files := map[string]bool{}
+	for _, cfg := range settings.GetInstance().GetAlertRules() {
+		if cfg.Disabled {
+			continue
+		}
+		r, err := compile(cfg)
+		if err != nil {
+			log.Printf("Skipping alert rule %q: %v", cfg.Name, err)
+			continue
+		}
+		rules = append(rules, r)
+		for _, source := range cfg.Sources {
+			if filepath.IsAbs(source) && !strings.ContainsAny(source, "*?[") {
+				files[source] = true
+			}
+		}
+	}
+
+	e.mu.Lock()
+	e.rules = rules
+	e.states = map[string]*state{}
+	var stopped []*watcher.FileWatcher
+	for path, fw := range e.watchers {
+		if !files[path] {
+			stopped = append(stopped, fw)
+			delete(e.watchers, path)
+		}
+	}
+	var added []string
+	for path := range files {
+		if e.watchers[path] == nil {
+			added = append(added, path)
+		}
+	}
+	e.mu.Unlock()
+
+	for _, fw := range stopped {
+		fw.Stop()
+		e.anomalies.Forget(fw.Path())
+	}
+	for _, path := range added {
+		if err := e.watch(path); err != nil {
+			log.Printf("Alert rules can't watch %s: %v", path, err)
+		}
+	}
+}
+
+// watch follows a file named in a rule, evaluating its new lines
+func (e *Engine) watch(path string) error {
+	// Follow-only: rules see lines written from now on, and the existing
+	// content isn't read
+	fw, err := watcher.NewFileWatcher(path, 0, e.config)
+	if err != nil {
+		return err
+	}
+	if err := fw.Start(); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	if e.watchers[path] != nil {
+		// A concurrent reload got there first
+		e.mu.Unlock()
+		fw.Stop()
+		return nil
+	}
+	e.watchers[path] = fw
+	e.mu.Unlock()
+
+	p := parser.ForSource(path, path, "")
+	go func() {
+		for text := range fw.Lines {
+			e.evaluate(path, enrich(p, []logline.Line{logline.New(text)}))
+		}
+	}()
+	return nil
+}
+
+// Feed evaluates the lines of a source open in a tab. owner identifies
+// the tab; sources the engine follows itself (ingested streams and files
+// named in rules) are skipped, and only one tab feeds each source.
+func (e *Engine) Feed(owner interface{}, source string, lines []logline.Line) {
+	if source == "" || e.streams.Get(source) != nil {
+		return
+	}
+
+	now := time.Now()
+	e.mu.Lock()
+	if e.watchers[source] != nil {
+		e.mu.Unlock()
+		return
+	}
+	if current, ok := e.feeds[source]; ok && current.owner != owner && now.Sub(current.last) < feedTimeout {
+		e.mu.Unlock()
+		return
+	}
+	e.feeds[source] = feed{owner: owner, last: now}
+	e.mu.Unlock()
+
+	e.evaluate(source, lines)
+}
+
+// Release stops a tab from feeding its sources, so another tab showing
+// the same source can take over. The baselines of sources no tab has
+// open any more (open reports whether one does) are dropped so they
+// aren't flagged as silent.
+func (e *Engine) Release(owner interface{}, open func(source string) bool) {
+	e.mu.Lock()
+	var released []string
+	for source, current := range e.feeds {
+		if current.owner == owner {
+			delete(e.feeds, source)
+			released = append(released, source)
+		}
+	}
+	e.mu.Unlock()
+
+	for _, source := range released {
+		if !open(source) {
+			e.anomalies.Forget(source)
+		}
+	}
+}
+
+// History returns the recent alerts, most recent first
+func (e *Engine) History() []Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	list := make([]Alert, len(e.history))
+	for i, alert := range e.history {
+		list[len(list)-1-i] = alert
+	}
+	return list
+}
+
+// evaluate counts the matches of lines of a source and fires the rules
+// that reach their threshold, and feeds the lines to the anomaly
+// detector. A rule fires at most once per window for each source.
+func (e *Engine) evaluate(source string, lines []logline.Line) {
+	e.anomalies.Add(source, lines)
+
+	now := time.Now()
+	var fired []Alert
+
+	e.mu.Lock()
+	for _, r := range e.rules {
+		if !r.appliesTo(source) {
+			continue
+		}
+
+		key := r.Name + "\x00" + source
+		st := e.states[key]
+		for _, line := range lines {
+			if !r.match(line) {
+				continue
+			}
+			if st == nil {
+				st = &state{}
+				e.states[key] = st
+			}
+			st.matches = append(st.matches, now)
+			st.lines = append(st.lines, line.Text)
+			if len(st.lines) > maxAlertLines {
+				st.lines = st.lines[len(st.lines)-maxAlertLines:]
+			}
+		}
+		if st == nil {
+			continue
+		}
+
+		// Drop matches that left the window
+		cutoff := now.Add(-r.window)
+		i := 0
+		for i < len(st.matches) && st.matches[i].Before(cutoff) {
+			i++
+		}
+		st.matches = st.matches[i:]
+		if len(st.matches) < len(st.lines) {
+			st.lines = st.lines[len(st.lines)-len(st.matches):]
+		}
+
+		if len(st.matches) < r.Threshold || (!st.lastFired.IsZero() && now.Sub(st.lastFired) < r.window) {
+			continue
+		}
+		alert := Alert{
+			Rule:          r.Name,
+			Source:        source,
+			Count:         len(st.matches),
+			Threshold:     r.Threshold,
+			WindowSeconds: int(r.window / time.Second),
+			Time:          now.UnixMilli(),
+			Lines:         append([]string(nil), st.lines...),
+		}
+		st.lastFired = now
+		st.matches = nil
+		st.lines = nil
+
+		e.history = append(e.history, alert)
+		if len(e.history) > maxHistory {
+			e.history = e.history[len(e.history)-maxHistory:]
+		}
+		fired = append(fired, alert)
+		if r.Webhook != "" {
+			go e.post(r.Webhook, alert)
+		}
+	}
+	notify := e.notify
+	e.mu.Unlock()
+
+	if notify != nil {
+		for _, alert := range fired {
+			notify(alert)
+		}
+	}
+}
+
+// appliesTo reports whether a rule evaluates a source: every source if it
+// names none, otherwise sources matching one of its globs by full path or
+// base name
+func (r *rule) appliesTo(source string) bool {
+	if len(r.Sources) == 0 {
+		return true
+	}
+	for _, glob := range r.Sources {
+		if glob == source {
+			return true
+		}
+		if matched, _ := filepath.Match(glob, source); matched {
+			return true
+		}
+		if matched, _ := filepath.Match(glob, filepath.Base(source)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// post sends an alert to a webhook as JSON
+func (e *Engine) post(webhook string, alert Alert) {
+	body, _ := json.Marshal(alert)
+	resp, err := e.client.Post(webhook, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Printf("Alert webhook %s failed: %v", webhook, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Printf("Alert webhook %s returned %s", webhook, resp.Status)
+	}
+}
+
+// enrich fills in the detected metadata of lines
+func enrich(p *parser.Parser, lines []logline.Line) []logline.Line {
+	enriched := make([]logline.Line, len(lines))
+	for i, line := range lines {
+		p.Enrich(&line)
+		enriched[i] = line
+	}
+	return enriched
+}
@@ -0,0 +1,326 @@
+package anomaly
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/weblogview/internal/logline"
+	"github.com/yourusername/weblogview/internal/templates"
+)
+
+const (
+	// Interval is the time slot lines are counted in
+	Interval = 10 * time.Second
+
+	// warmupIntervals is the number of intervals a series is observed
+	// before it can be flagged
+	warmupIntervals = 30
+
+	// alpha is the weight of the latest interval in the baseline (an
+	// exponentially weighted moving average over roughly 5 minutes)
+	alpha = 0.03
+
+	// spikeScore is the number of standard deviations above the baseline
+	// that makes a spike
+	spikeScore = 4.0
+
+	// minSpikeCount is the smallest count per interval flagged as a spike
+	minSpikeCount = 20
+
+	// minSilenceRate is the baseline count per interval a series needs
+	// for its silence to be flagged
+	minSilenceRate = 1.0
+
+	// silenceIntervals is the number of empty intervals that make a
+	// silence
+	silenceIntervals = 6
+
+	// maxSeries limits the series tracked per source; further templates
+	// aren't tracked
+	maxSeries = 200
+
+	// maxHistory is the number of anomalies kept
+	maxHistory = 500
+)
+
+// Anomaly kinds
+const (
+	KindSpike   = "spike"
+	KindSilence = "silence"
+)
+
+// Anomaly is an unusual burst or silence of a series of a source
+type Anomaly struct {
+	ID       int64   `json:"id"`
+	Source   string  `json:"source"`
+	Series   string  `json:"series"` // "total", "level:error" or "template:12"
+	Template string  `json:"template,omitempty"`
+	Kind     string  `json:"kind"`
+	Start    int64   `json:"start"` // Unix milliseconds
+	End      int64   `json:"end"`
+	Count    int64   `json:"count"`    // Lines in the flagged window
+	Expected float64 `json:"expected"` // Baseline lines in the same window
+	Score    float64 `json:"score"`    // Standard deviations above the baseline (spikes)
+	Ongoing  bool    `json:"ongoing"`  // The silence hasn't ended yet
+}
+
+// series is the baseline of one line rate
+type series struct {
+	mean      float64
+	variance  float64
+	intervals int
+	count     int64 // Lines in the current interval
+	empty     int   // Consecutive empty intervals
+	silence   *Anomaly
+}
+
+// sourceState holds the series of one source
+type sourceState struct {
+	series map[string]*series
+	miner  *templates.Miner
+}
+
+// Detector learns a baseline line rate per source, level and message
+// template and flags statistically unusual bursts and silences. Lines are
+// counted by arrival time in intervals of Interval. It is safe for
+// concurrent use.
+type Detector struct {
+	mu      sync.Mutex
+	sources map[string]*sourceState
+	history []Anomaly
+	nextID  int64
+	notify  func(Anomaly)
+	stop    chan struct{}
+}
+
+// NewDetector creates a detector and starts closing its intervals
+func NewDetector() *Detector {
+	d := &Detector{
+		sources: map[string]*sourceState{},
+		stop:    make(chan struct{}),
+	}
+	go d.run()
+	return d
+}
+
+// OnAnomaly registers fn to be called for every anomaly flagged and for
+// every silence that ends
+func (d *Detector) OnAnomaly(fn func(Anomaly)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.notify = fn
+}
+
+// Stop stops closing intervals
+func (d *Detector) Stop() {
+	close(d.stop)
+}
+
+// Add counts the lines of a source in the current interval
+func (d *Detector) Add(source string, lines []logline.Line) {
+	if source == "" || len(lines) == 0 {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	st := d.sources[source]
+	if st == nil {
+		st = &sourceState{series: map[string]*series{}, miner: templates.NewMiner()}
+		d.sources[source] = st
+	}
+	for _, line := range lines {
+		st.add("total")
+		if line.Level != "" {
+			st.add("level:" + line.Level)
+		}
+		if id := st.miner.Add(line.Text, line.Time()); id != 0 {
+			st.add("template:" + strconv.Itoa(id))
+		}
+	}
+}
+
+// add counts a line in a series, creating it if the source has room
+func (st *sourceState) add(key string) {
+	s := st.series[key]
+	if s == nil {
+		if len(st.series) >= maxSeries {
+			return
+		}
+		s = &series{}
+		st.series[key] = s
+	}
+	s.count++
+}
+
+// Forget drops the baselines of a source that stopped being followed, so
+// it isn't flagged as silent
+func (d *Detector) Forget(source string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.sources, source)
+}
+
+// Anomalies returns the anomalies of a source (all sources if empty) that
+// ended at or after since, most recent first
+func (d *Detector) Anomalies(source string, since time.Time) []Anomaly {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	list := []Anomaly{}
+	for i := len(d.history) - 1; i >= 0; i-- {
+		a := d.history[i]
+		if source != "" && a.Source != source {
+			continue
+		}
+		if !since.IsZero() && a.End < since.UnixMilli() {
+			continue
+		}
+		list = append(list, a)
+	}
+	return list
+}
+
+// run closes an interval every Interval
+func (d *Detector) run() {
+	ticker := time.NewTicker(Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			d.closeInterval(now)
+		case <-d.stop:
+			return
+		}
+	}
+}
+
+// closeInterval checks the counts of the interval ending at now against
+// the baselines, then folds them in
+func (d *Detector) closeInterval(now time.Time) {
+	var flagged []Anomaly
+
+	d.mu.Lock()
+	start := now.Add(-Interval).UnixMilli()
+	sources := make([]string, 0, len(d.sources))
+	for source := range d.sources {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	for _, source := range sources {
+		st := d.sources[source]
+		for key, s := range st.series {
+			count := s.count
+			s.count = 0
+			std := math.Max(math.Sqrt(s.variance), math.Max(math.Sqrt(s.mean), 1))
+			warm := s.intervals >= warmupIntervals
+
+			if count == 0 {
+				s.empty++
+			} else {
+				s.empty = 0
+			}
+
+			// A silence ends with the first line
+			if s.silence != nil && count > 0 {
+				s.silence.End = start
+				s.silence.Ongoing = false
+				d.update(*s.silence)
+				flagged = append(flagged, *s.silence)
+				s.silence = nil
+			}
+
+			switch {
+			case warm && count >= minSpikeCount && float64(count)-s.mean >= spikeScore*std:
+				a := d.record(Anomaly{
+					Source:   source,
+					Series:   key,
+					Kind:     KindSpike,
+					Start:    start,
+					End:      now.UnixMilli(),
+					Count:    count,
+					Expected: s.mean,
+					Score:    (float64(count) - s.mean) / std,
+				}, st)
+				flagged = append(flagged, a)
+				// Spikes don't move the baseline
+				continue
+
+			case warm && s.empty == silenceIntervals && s.mean >= minSilenceRate:
+				a := d.record(Anomaly{
+					Source:   source,
+					Series:   key,
+					Kind:     KindSilence,
+					Start:    now.Add(-silenceIntervals * Interval).UnixMilli(),
+					End:      now.UnixMilli(),
+					Expected: s.mean * silenceIntervals,
+					Ongoing:  true,
+				}, st)
+				s.silence = &a
+				flagged = append(flagged, a)
+			}
+
+			if s.silence != nil {
+				// Silences don't move the baseline either
+				s.silence.End = now.UnixMilli()
+				d.update(*s.silence)
+				continue
+			}
+			// A plain average until the moving average has enough history
+			weight := math.Max(alpha, 1/float64(s.intervals+1))
+			delta := float64(count) - s.mean
+			s.mean += weight * delta
+			s.variance = (1 - weight) * (s.variance + weight*delta*delta)
+			s.intervals++
+		}
+	}
+	notify := d.notify
+	d.mu.Unlock()
+
+	if notify != nil {
+		for _, a := range flagged {
+			notify(a)
+		}
+	}
+}
+
+// record assigns an ID to an anomaly and adds it to the history
+func (d *Detector) record(a Anomaly, st *sourceState) Anomaly {
+	d.nextID++
+	a.ID = d.nextID
+	if id, ok := strings.CutPrefix(a.Series, "template:"); ok {
+		a.Template = templatePattern(st.miner, id)
+	}
+	d.history = append(d.history, a)
+	if len(d.history) > maxHistory {
+		d.history = d.history[len(d.history)-maxHistory:]
+	}
+	return a
+}
+
+// update replaces an anomaly of the history by ID
+func (d *Detector) update(a Anomaly) {
+	for i := len(d.history) - 1; i >= 0; i-- {
+		if d.history[i].ID == a.ID {
+			d.history[i] = a
+			return
+		}
+	}
+}
+
+// templatePattern returns the pattern of a template by ID
+func templatePattern(miner *templates.Miner, id string) string {
+	for _, t := range miner.Templates() {
+		if strconv.Itoa(t.ID) == id {
+			return t.Pattern
+		}
+	}
+	return ""
+}
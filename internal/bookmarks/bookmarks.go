@@ -0,0 +1,201 @@
+package bookmarks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// maxBookmarks limits the bookmarks kept across all sources
+	maxBookmarks = 10000
+
+	// maxNoteLength limits the note of a bookmark
+	maxNoteLength = 4096
+
+	// maxTextLength truncates the line text kept to find a line again
+	maxTextLength = 1000
+)
+
+// Colors are the named bookmark colors; "#rrggbb" works too
+var Colors = []string{"yellow", "red", "orange", "green", "blue", "purple", "gray"}
+
+var hexColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// Bookmark marks a line of a source with a note and a color. Lines of
+// files are found by byte offset, lines of other sources (pods,
+// containers, streams) by timestamp; the text finds them again when the
+// position no longer matches.
+type Bookmark struct {
+	ID        int64     `json:"id"`
+	Source    string    `json:"source"`              // The path for files, otherwise kind:name (like settings keys)
+	Offset    int64     `json:"offset"`              // Byte offset of the line in a file
+	Timestamp int64     `json:"timestamp,omitempty"` // Unix milliseconds of the line, for other sources
+	Text      string    `json:"text,omitempty"`      // The line (the first of an event, truncated)
+	Note      string    `json:"note,omitempty"`
+	Color     string    `json:"color"`
+	Created   time.Time `json:"created"`
+}
+
+// Store holds the bookmarks, persisted as JSON under ~/.weblogview
+type Store struct {
+	mu        sync.Mutex
+	path      string
+	NextID    int64      `json:"nextId"`
+	Bookmarks []Bookmark `json:"bookmarks"`
+}
+
+var (
+	instance *Store
+	once     sync.Once
+)
+
+// GetInstance returns the bookmark store, loading it on first use
+func GetInstance() *Store {
+	once.Do(func() {
+		instance = &Store{path: getBookmarksPath(), NextID: 1, Bookmarks: []Bookmark{}}
+		instance.load()
+	})
+	return instance
+}
+
+// load reads the bookmarks from disk; a missing file means none
+func (s *Store) load() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return err
+	}
+	if s.Bookmarks == nil {
+		s.Bookmarks = []Bookmark{}
+	}
+	for _, b := range s.Bookmarks {
+		if b.ID >= s.NextID {
+			s.NextID = b.ID + 1
+		}
+	}
+	return nil
+}
+
+// List returns the bookmarks of a source (all sources if empty), oldest
+// first
+func (s *Store) List(source string) []Bookmark {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := []Bookmark{}
+	for _, b := range s.Bookmarks {
+		if source == "" || b.Source == source {
+			list = append(list, b)
+		}
+	}
+	return list
+}
+
+// Add validates a bookmark, assigns its ID and creation time, and saves it
+func (s *Store) Add(b Bookmark) (Bookmark, error) {
+	if b.Source == "" {
+		return b, fmt.Errorf("source required")
+	}
+	if b.Offset < 0 {
+		return b, fmt.Errorf("offset must not be negative")
+	}
+	if len(b.Note) > maxNoteLength {
+		return b, fmt.Errorf("note is longer than %d bytes", maxNoteLength)
+	}
+	if b.Color == "" {
+		b.Color = Colors[0]
+	} else if !validColor(b.Color) {
+		return b, fmt.Errorf("unknown color %q", b.Color)
+	}
+	b.Text = firstLine(b.Text)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.Bookmarks) >= maxBookmarks {
+		return b, fmt.Errorf("too many bookmarks (at most %d)", maxBookmarks)
+	}
+	b.ID = s.NextID
+	b.Created = time.Now().UTC()
+	s.NextID++
+	s.Bookmarks = append(s.Bookmarks, b)
+	return b, s.saveUnlocked()
+}
+
+// Delete removes a bookmark by ID
+func (s *Store) Delete(id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, b := range s.Bookmarks {
+		if b.ID == id {
+			s.Bookmarks = append(s.Bookmarks[:i], s.Bookmarks[i+1:]...)
+			return s.saveUnlocked()
+		}
+	}
+	return fmt.Errorf("bookmark %d not found", id)
+}
+
+// saveUnlocked saves the bookmarks without locking (internal use only)
+func (s *Store) saveUnlocked() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.path, data, 0644)
+}
+
+// getBookmarksPath returns the path to the bookmarks file
+func getBookmarksPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		homeDir = "."
+	}
+	return filepath.Join(homeDir, ".weblogview", "bookmarks.json")
+}
+
+// validColor reports whether color is a named color or #rrggbb
+func validColor(color string) bool {
+	for _, name := range Colors {
+		if color == name {
+			return true
+		}
+	}
+	return hexColor.MatchString(color)
+}
+
+// firstLine returns the first line of a multi-line event, truncated
+func firstLine(text string) string {
+	text, _, _ = strings.Cut(text, "\n")
+	return truncate(strings.TrimRight(text, "\r"))
+}
+
+// truncate shortens a line to maxTextLength bytes without splitting a
+// character
+func truncate(text string) string {
+	if len(text) <= maxTextLength {
+		return text
+	}
+	cut := maxTextLength
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut]
+}
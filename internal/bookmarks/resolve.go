@@ -0,0 +1,136 @@
+package bookmarks
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/yourusername/weblogview/internal/logline"
+)
+
+// Position is a bookmark resolved against the current content of its
+// source
+type Position struct {
+	Bookmark
+	Found bool  `json:"found"`           // The line is still there
+	Moved bool  `json:"moved,omitempty"` // It was found by its text elsewhere; Offset is the new one
+	Line  int64 `json:"line,omitempty"`  // 1-based line number in the file, or in the given lines
+}
+
+// ResolveFile finds the lines of bookmarks in a file. A bookmark is found
+// at its offset if a line starts there with the bookmarked text; otherwise
+// the line with that text closest to the offset is taken (the file was
+// rewritten or rotated). Bookmarks without text are found at any line
+// start.
+func ResolveFile(path string, list []Bookmark) ([]Position, error) {
+	positions := make([]Position, len(list))
+	byOffset := map[int64][]int{}
+	last := int64(-1)
+	for i, b := range list {
+		positions[i] = Position{Bookmark: b}
+		byOffset[b.Offset] = append(byOffset[b.Offset], i)
+		if b.Offset > last {
+			last = b.Offset
+		}
+	}
+	if len(list) == 0 {
+		return positions, nil
+	}
+
+	// Lines still at their offset
+	err := scanLines(path, func(offset, lineNumber int64, text string) bool {
+		for _, i := range byOffset[offset] {
+			if list[i].Text == "" || list[i].Text == text {
+				positions[i].Found = true
+				positions[i].Line = lineNumber
+			}
+		}
+		return offset < last
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Lines that moved, by text
+	missing := map[string][]int{}
+	for i, p := range positions {
+		if !p.Found && p.Text != "" {
+			missing[p.Text] = append(missing[p.Text], i)
+		}
+	}
+	if len(missing) == 0 {
+		return positions, nil
+	}
+	distance := func(i int, offset int64) int64 {
+		d := offset - list[i].Offset
+		if d < 0 {
+			return -d
+		}
+		return d
+	}
+	err = scanLines(path, func(offset, lineNumber int64, text string) bool {
+		for _, i := range missing[text] {
+			p := &positions[i]
+			if !p.Found || distance(i, offset) < distance(i, p.Offset) {
+				p.Found = true
+				p.Moved = true
+				p.Offset = offset
+				p.Line = lineNumber
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return positions, nil
+}
+
+// scanLines calls fn with the offset, 1-based number and truncated text of
+// each line of a file until it returns false
+func scanLines(path string, fn func(offset, lineNumber int64, text string) bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	offset := int64(0)
+	for lineNumber := int64(1); ; lineNumber++ {
+		raw, err := reader.ReadString('\n')
+		if raw == "" {
+			if err != nil && err != io.EOF {
+				return err
+			}
+			return nil
+		}
+		if !fn(offset, lineNumber, truncate(strings.TrimRight(raw, "\r\n"))) {
+			return nil
+		}
+		offset += int64(len(raw))
+	}
+}
+
+// ResolveLines finds the lines of bookmarks among the lines loaded from a
+// source that can't be read by offset: the last line with the bookmarked
+// timestamp and text
+func ResolveLines(list []Bookmark, lines []logline.Line) []Position {
+	positions := make([]Position, len(list))
+	for i, b := range list {
+		positions[i] = Position{Bookmark: b}
+		if b.Timestamp == 0 && b.Text == "" {
+			continue
+		}
+		for j := len(lines) - 1; j >= 0; j-- {
+			line := lines[j]
+			if (b.Timestamp == 0 || line.Timestamp == b.Timestamp) && (b.Text == "" || firstLine(line.Text) == b.Text) {
+				positions[i].Found = true
+				positions[i].Line = int64(j + 1)
+				break
+			}
+		}
+	}
+	return positions
+}
@@ -0,0 +1,301 @@
+package compare
+
+import (
+	"sort"
+
+	"github.com/yourusername/weblogview/internal/logline"
+	"github.com/yourusername/weblogview/internal/templates"
+)
+
+const (
+	// maxEdits limits the size of an aligned diff; sources that differ in
+	// more lines are only compared by their unique lines
+	maxEdits = 1000
+
+	// maxHunkLines is the number of lines kept per side of a hunk
+	maxHunkLines = 20
+
+	// defaultTop is the number of unique lines and hunks returned when
+	// Options.Top isn't set
+	defaultTop = 100
+)
+
+// Options controls a comparison
+type Options struct {
+	Top   int  // Unique lines and hunks returned per side (default 100)
+	Align bool // Also produce an aligned diff of the two sources
+}
+
+// Difference is a normalized line found on one side only
+type Difference struct {
+	Template  string `json:"template"`  // The line with timestamps, IDs and numbers masked
+	Count     int    `json:"count"`     // Lines of the side with this template
+	Example   string `json:"example"`   // The first such line
+	FirstLine int    `json:"firstLine"` // 1-based position of the example in its side
+}
+
+// Hunk is a run of lines that differ between the sources, between lines
+// they have in common
+type Hunk struct {
+	LineA  int      `json:"lineA"` // 1-based position in A where the hunk starts
+	LineB  int      `json:"lineB"`
+	CountA int      `json:"countA"` // Lines of A only
+	CountB int      `json:"countB"`
+	OnlyA  []string `json:"onlyA"` // The first lines of A only
+	OnlyB  []string `json:"onlyB"`
+}
+
+// Result is the comparison of two sources
+type Result struct {
+	LinesA    int          `json:"linesA"`
+	LinesB    int          `json:"linesB"`
+	Common    int          `json:"common"` // Templates found on both sides
+	OnlyA     []Difference `json:"onlyA"`  // Templates of A only, most frequent first
+	OnlyB     []Difference `json:"onlyB"`
+	Aligned   bool         `json:"aligned"` // Hunks is the complete aligned diff
+	Hunks     []Hunk       `json:"hunks,omitempty"`
+	Truncated bool         `json:"truncated"` // Some unique templates or hunks weren't returned
+}
+
+// Compare compares two sources by their normalized lines (see
+// templates.Normalize): the templates unique to each side with counts and,
+// with opts.Align, an aligned diff of the lines as hunks of lines unique to
+// each side. Sources that differ in too many lines aren't aligned.
+func Compare(a, b []logline.Line, opts Options) Result {
+	top := opts.Top
+	if top <= 0 {
+		top = defaultTop
+	}
+
+	// Normalized lines become small integers so they compare fast
+	ids := map[string]int{}
+	var names []string
+	normalize := func(lines []logline.Line) []int {
+		seq := make([]int, len(lines))
+		for i, line := range lines {
+			name := templates.Normalize(line.Text)
+			id, ok := ids[name]
+			if !ok {
+				id = len(names)
+				ids[name] = id
+				names = append(names, name)
+			}
+			seq[i] = id
+		}
+		return seq
+	}
+	seqA, seqB := normalize(a), normalize(b)
+
+	result := Result{LinesA: len(a), LinesB: len(b), OnlyA: []Difference{}, OnlyB: []Difference{}}
+
+	countsA, countsB := count(seqA), count(seqB)
+	for id := range countsA {
+		if countsB[id] > 0 {
+			result.Common++
+		}
+	}
+	result.OnlyA = unique(seqA, a, countsA, countsB, names)
+	result.OnlyB = unique(seqB, b, countsB, countsA, names)
+	if len(result.OnlyA) > top {
+		result.OnlyA = result.OnlyA[:top]
+		result.Truncated = true
+	}
+	if len(result.OnlyB) > top {
+		result.OnlyB = result.OnlyB[:top]
+		result.Truncated = true
+	}
+
+	if opts.Align {
+		ops, ok := diff(seqA, seqB, maxEdits)
+		if ok {
+			result.Aligned = true
+			result.Hunks = hunks(ops, a, b)
+			if len(result.Hunks) > top {
+				result.Hunks = result.Hunks[:top]
+				result.Truncated = true
+			}
+		}
+	}
+	return result
+}
+
+// count returns the number of lines per normalized line
+func count(seq []int) map[int]int {
+	counts := map[int]int{}
+	for _, id := range seq {
+		counts[id]++
+	}
+	return counts
+}
+
+// unique returns the normalized lines of one side missing from the other,
+// most frequent first
+func unique(seq []int, lines []logline.Line, counts, other map[int]int, names []string) []Difference {
+	var list []Difference
+	seen := map[int]bool{}
+	for i, id := range seq {
+		if other[id] > 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		list = append(list, Difference{
+			Template:  names[id],
+			Count:     counts[id],
+			Example:   lines[i].Text,
+			FirstLine: i + 1,
+		})
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Count > list[j].Count
+	})
+	if list == nil {
+		list = []Difference{}
+	}
+	return list
+}
+
+// Edit operations of an aligned diff
+const (
+	opEqual = iota
+	opDelete
+	opInsert
+)
+
+// op is one step of an aligned diff: a line of both sides, of A only or
+// of B only
+type op struct {
+	kind int
+	a, b int // Positions in A and B before the step
+}
+
+// diff aligns two sequences with Myers' algorithm, giving up when they
+// differ in more than maxD lines. Common leading and trailing lines are
+// skipped first, which keeps the usual case of mostly equal sources fast.
+func diff(a, b []int, maxD int) ([]op, bool) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	var ops []op
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, op{opEqual, i, i})
+	}
+	mid, ok := myers(midA, midB, maxD)
+	if !ok {
+		return nil, false
+	}
+	for _, o := range mid {
+		ops = append(ops, op{o.kind, o.a + prefix, o.b + prefix})
+	}
+	for i := 0; i < suffix; i++ {
+		ops = append(ops, op{opEqual, len(a) - suffix + i, len(b) - suffix + i})
+	}
+	return ops, true
+}
+
+// myers returns the shortest edit script between a and b, or false if it
+// is longer than maxD
+func myers(a, b []int, maxD int) ([]op, bool) {
+	n, m := len(a), len(b)
+	if maxD > n+m {
+		maxD = n + m
+	}
+	off := maxD + 1
+	v := make([]int, 2*maxD+3)
+	var trace [][]int
+
+	for d := 0; d <= maxD; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, off, n, m), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// backtrack walks the saved states of myers back from the end to build
+// the edit script
+func backtrack(trace [][]int, off, n, m int) []op {
+	var ops []op
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+			prevK = k + 1
+		}
+		prevX := v[off+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, op{opEqual, x, y})
+		}
+		if x == prevX {
+			ops = append(ops, op{opInsert, x, prevY})
+		} else {
+			ops = append(ops, op{opDelete, prevX, y})
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		ops = append(ops, op{opEqual, x, y})
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// hunks groups the deletions and insertions between equal lines
+func hunks(ops []op, a, b []logline.Line) []Hunk {
+	var list []Hunk
+	var current *Hunk
+	for _, o := range ops {
+		if o.kind == opEqual {
+			current = nil
+			continue
+		}
+		if current == nil {
+			list = append(list, Hunk{LineA: o.a + 1, LineB: o.b + 1, OnlyA: []string{}, OnlyB: []string{}})
+			current = &list[len(list)-1]
+		}
+		if o.kind == opDelete {
+			current.CountA++
+			if len(current.OnlyA) < maxHunkLines {
+				current.OnlyA = append(current.OnlyA, a[o.a].Text)
+			}
+		} else {
+			current.CountB++
+			if len(current.OnlyB) < maxHunkLines {
+				current.OnlyB = append(current.OnlyB, b[o.b].Text)
+			}
+		}
+	}
+	return list
+}
@@ -0,0 +1,132 @@
+package compare
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/yourusername/weblogview/internal/logline"
+)
+
+// lcs returns the length of the longest common subsequence of a and b
+func lcs(a, b []int) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			switch {
+			case a[i] == b[j]:
+				cur[j+1] = prev[j] + 1
+			case prev[j+1] >= cur[j]:
+				cur[j+1] = prev[j+1]
+			default:
+				cur[j+1] = cur[j]
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// checkScript checks that ops turns a into b, stepping through both in
+// order, and returns its number of equal lines
+func checkScript(t *testing.T, a, b []int, ops []op) int {
+	t.Helper()
+	x, y, equal := 0, 0, 0
+	for _, o := range ops {
+		if o.a != x || o.b != y {
+			t.Fatalf("op %+v at a=%d b=%d of %v -> %v", o, x, y, a, b)
+		}
+		switch o.kind {
+		case opEqual:
+			if a[x] != b[y] {
+				t.Fatalf("equal op on %d != %d in %v -> %v", a[x], b[y], a, b)
+			}
+			x, y, equal = x+1, y+1, equal+1
+		case opDelete:
+			x++
+		case opInsert:
+			y++
+		}
+	}
+	if x != len(a) || y != len(b) {
+		t.Fatalf("script ends at a=%d b=%d, want %d %d for %v -> %v", x, y, len(a), len(b), a, b)
+	}
+	return equal
+}
+
+// randomSeq returns n values from a small alphabet so sequences share lines
+func randomSeq(r *rand.Rand, n, alphabet int) []int {
+	seq := make([]int, n)
+	for i := range seq {
+		seq[i] = r.Intn(alphabet)
+	}
+	return seq
+}
+
+func TestDiffMatchesLCS(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		a := randomSeq(r, r.Intn(30), 1+r.Intn(5))
+		b := randomSeq(r, r.Intn(30), 1+r.Intn(5))
+		want := lcs(a, b)
+		distance := len(a) + len(b) - 2*want
+
+		ops, ok := diff(a, b, maxEdits)
+		if !ok {
+			t.Fatalf("diff(%v, %v) gave up", a, b)
+		}
+		if got := checkScript(t, a, b, ops); got != want {
+			t.Fatalf("diff(%v, %v) keeps %d equal lines, want %d", a, b, got, want)
+		}
+
+		mid, ok := myers(a, b, maxEdits)
+		if !ok {
+			t.Fatalf("myers(%v, %v) gave up", a, b)
+		}
+		if got := checkScript(t, a, b, mid); got != want {
+			t.Fatalf("myers(%v, %v) keeps %d equal lines, want %d", a, b, got, want)
+		}
+
+		// The edit limit is exact
+		if _, ok := myers(a, b, distance); !ok {
+			t.Fatalf("myers(%v, %v) gave up at its distance %d", a, b, distance)
+		}
+		if distance > 0 {
+			if _, ok := myers(a, b, distance-1); ok {
+				t.Fatalf("myers(%v, %v) succeeded below its distance %d", a, b, distance)
+			}
+		}
+	}
+}
+
+// texts builds lines from text
+func texts(lines ...string) []logline.Line {
+	records := make([]logline.Line, len(lines))
+	for i, text := range lines {
+		records[i] = logline.New(text)
+	}
+	return records
+}
+
+func TestCompareHunks(t *testing.T) {
+	a := texts("start", "connect db", "ready", "request /a", "stop")
+	b := texts("start", "connect db", "cache miss", "cache miss", "ready", "stop")
+
+	result := Compare(a, b, Options{Align: true})
+	if !result.Aligned {
+		t.Fatal("sources weren't aligned")
+	}
+	if len(result.Hunks) != 2 {
+		t.Fatalf("got %d hunks, want 2: %+v", len(result.Hunks), result.Hunks)
+	}
+	first, second := result.Hunks[0], result.Hunks[1]
+	if first.LineA != 3 || first.LineB != 3 || first.CountA != 0 || first.CountB != 2 {
+		t.Errorf("first hunk = %+v, want 2 lines of B at line 3", first)
+	}
+	if second.LineA != 4 || second.CountA != 1 || second.OnlyA[0] != "request /a" || second.CountB != 0 {
+		t.Errorf("second hunk = %+v, want request /a of A at line 4", second)
+	}
+	if len(result.OnlyA) != 1 || len(result.OnlyB) != 1 || result.OnlyB[0].Count != 2 {
+		t.Errorf("unique lines = %+v and %+v", result.OnlyA, result.OnlyB)
+	}
+}
@@ -13,6 +13,7 @@ type Config struct {
 	BufferSize         int
 	MaxConcurrentFiles int
 	PollingInterval    time.Duration // Fallback polling interval for file watching
+	ForwardAddr        string        // Fluent Forward listen address (empty = disabled)
 }
 
 // New creates a new configuration with defaults
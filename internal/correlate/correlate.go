@@ -0,0 +1,110 @@
+package correlate
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/yourusername/weblogview/internal/logline"
+	"github.com/yourusername/weblogview/internal/settings"
+)
+
+var (
+	// DefaultFields are the field names searched for a key when none are
+	// configured
+	DefaultFields = []string{
+		"trace_id", "traceId", "traceID", "trace.id", "trace",
+		"dd.trace_id", "otel.trace_id", "x-trace-id", "request_id", "requestId",
+	}
+
+	// DefaultPatterns find a key in lines without fields when none are
+	// configured: the trace ID of a W3C traceparent header
+	DefaultPatterns = []string{
+		`\b[0-9a-f]{2}-([0-9a-f]{32})-[0-9a-f]{16}-[0-9a-f]{2}\b`,
+	}
+)
+
+// Extractor finds the correlation key of lines
+type Extractor struct {
+	fields   []string
+	patterns []*regexp.Regexp
+}
+
+// NewExtractor creates an extractor from settings, using the defaults for
+// empty lists
+func NewExtractor(cfg settings.CorrelationConfig) (*Extractor, error) {
+	e := &Extractor{fields: cfg.Fields}
+	if len(e.fields) == 0 {
+		e.fields = DefaultFields
+	}
+
+	patterns := cfg.Patterns
+	if len(patterns) == 0 {
+		patterns = DefaultPatterns
+	}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %v", pattern, err)
+		}
+		if re.NumSubexp() < 1 {
+			return nil, fmt.Errorf("pattern %q needs a group for the key", pattern)
+		}
+		e.patterns = append(e.patterns, re)
+	}
+	return e, nil
+}
+
+// Has reports whether a line carries the key id in any of the configured
+// fields or pattern matches
+func (e *Extractor) Has(line logline.Line, id string) bool {
+	for _, name := range e.fields {
+		if line.Fields[name] == id {
+			return true
+		}
+	}
+	for _, re := range e.patterns {
+		for _, m := range re.FindAllStringSubmatch(line.Text, -1) {
+			if m[1] == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Entry is a line carrying a correlation key
+type Entry struct {
+	Source     string `json:"source"`               // File path or source ID
+	LineNumber int64  `json:"lineNumber,omitempty"` // 1-based, files only
+	Offset     int64  `json:"offset,omitempty"`     // Byte offset of the line, files only
+	logline.Line
+}
+
+// Merge orders the entries of several sources by time. Entries must be in
+// source order; those without a timestamp keep the time of the entry
+// before them in their source, so they stay next to it.
+func Merge(entries []Entry) []Entry {
+	times := make([]int64, len(entries))
+	last := map[string]int64{}
+	for i, entry := range entries {
+		if entry.Timestamp != 0 {
+			last[entry.Source] = entry.Timestamp
+		}
+		times[i] = last[entry.Source]
+	}
+
+	order := make([]int, len(entries))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return times[order[a]] < times[order[b]]
+	})
+
+	merged := make([]Entry, len(entries))
+	for i, j := range order {
+		merged[i] = entries[j]
+	}
+	return merged
+}
@@ -0,0 +1,43 @@
+package correlate
+
+import (
+	"testing"
+
+	"github.com/yourusername/weblogview/internal/logline"
+	"github.com/yourusername/weblogview/internal/settings"
+)
+
+func TestExtractorHas(t *testing.T) {
+	e, err := NewExtractor(settings.CorrelationConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fields := logline.Line{
+		Text:   "request handled",
+		Fields: map[string]string{"trace_id": "trace-1", "request_id": "req-1"},
+	}
+	first := "0af7651916cd43dd8448eb211c80319c"
+	second := "4bf92f3577b34da6a3ce929d0e0e4736"
+	headers := logline.Line{
+		Text: "forwarded 00-" + first + "-b7ad6b7169203331-01 as 00-" + second + "-00f067aa0ba902b7-01",
+	}
+
+	cases := []struct {
+		line logline.Line
+		id   string
+		want bool
+	}{
+		{fields, "trace-1", true},
+		{fields, "req-1", true},
+		{fields, "trace", false},
+		{headers, first, true},
+		{headers, second, true},
+		{headers, "b7ad6b7169203331", false},
+	}
+	for _, c := range cases {
+		if got := e.Has(c.line, c.id); got != c.want {
+			t.Errorf("Has(%q, %q) = %v, want %v", c.line.Text, c.id, got, c.want)
+		}
+	}
+}
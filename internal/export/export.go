@@ -0,0 +1,177 @@
+package export
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/yourusername/weblogview/internal/logline"
+)
+
+// Formats
+const (
+	FormatText   = "text"
+	FormatNDJSON = "ndjson"
+	FormatCSV    = "csv"
+)
+
+// DefaultColumns are the CSV columns when none are chosen
+var DefaultColumns = []string{"time", "level", "text"}
+
+// Record is an exported line with where it came from
+type Record struct {
+	Source string `json:"source"`           // File path or source ID
+	Offset int64  `json:"offset,omitempty"` // Byte offset of the line, files only
+	logline.Line
+}
+
+// Writer writes records in one format
+type Writer struct {
+	format  string
+	columns []string
+	buf     *bufio.Writer
+	gz      *gzip.Writer
+	csv     *csv.Writer
+	json    *json.Encoder
+}
+
+// NewWriter creates a writer of records in a format, optionally gzipped.
+// CSV has a header row and the given columns: "time" (RFC 3339),
+// "timestamp" (Unix milliseconds), "source", "offset" or any field a line
+// can be queried by (see logline.Line.Field).
+func NewWriter(w io.Writer, format string, columns []string, compress bool) (*Writer, error) {
+	switch format {
+	case "":
+		format = FormatText
+	case FormatText, FormatNDJSON, FormatCSV:
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+	if len(columns) == 0 {
+		columns = DefaultColumns
+	}
+
+	ew := &Writer{format: format, columns: columns}
+	if compress {
+		ew.gz = gzip.NewWriter(w)
+		w = ew.gz
+	}
+	ew.buf = bufio.NewWriter(w)
+
+	switch format {
+	case FormatNDJSON:
+		ew.json = json.NewEncoder(ew.buf)
+	case FormatCSV:
+		ew.csv = csv.NewWriter(ew.buf)
+		if err := ew.csv.Write(columns); err != nil {
+			return nil, err
+		}
+	}
+	return ew, nil
+}
+
+// Extension returns the file name extension of a format
+func Extension(format string, compress bool) string {
+	ext := ".log"
+	switch format {
+	case FormatNDJSON:
+		ext = ".ndjson"
+	case FormatCSV:
+		ext = ".csv"
+	case FormatHTML:
+		ext = ".html"
+	}
+	if compress {
+		ext += ".gz"
+	}
+	return ext
+}
+
+// ContentType returns the MIME type of a format
+func ContentType(format string, compress bool) string {
+	switch {
+	case compress:
+		return "application/gzip"
+	case format == FormatNDJSON:
+		return "application/x-ndjson"
+	case format == FormatCSV:
+		return "text/csv; charset=utf-8"
+	case format == FormatHTML:
+		return "text/html; charset=utf-8"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+// Write writes one record
+func (ew *Writer) Write(rec Record) error {
+	switch ew.format {
+	case FormatNDJSON:
+		return ew.json.Encode(rec)
+	case FormatCSV:
+		row := make([]string, len(ew.columns))
+		for i, column := range ew.columns {
+			row[i] = value(rec, column)
+		}
+		return ew.csv.Write(row)
+	}
+	if _, err := ew.buf.WriteString(rec.Text); err != nil {
+		return err
+	}
+	return ew.buf.WriteByte('\n')
+}
+
+// flush writes the buffered records through
+func (ew *Writer) flush() error {
+	if ew.csv != nil {
+		ew.csv.Flush()
+		if err := ew.csv.Error(); err != nil {
+			return err
+		}
+	}
+	if err := ew.buf.Flush(); err != nil {
+		return err
+	}
+	if ew.gz != nil {
+		return ew.gz.Flush()
+	}
+	return nil
+}
+
+// Close flushes the records and ends the gzip stream; the underlying
+// writer isn't closed
+func (ew *Writer) Close() error {
+	if err := ew.flush(); err != nil {
+		return err
+	}
+	if ew.gz != nil {
+		return ew.gz.Close()
+	}
+	return nil
+}
+
+// value returns the CSV column of a record
+func value(rec Record, column string) string {
+	switch column {
+	case "time":
+		if rec.Timestamp == 0 {
+			return ""
+		}
+		return rec.Time().UTC().Format(time.RFC3339Nano)
+	case "timestamp":
+		if rec.Timestamp == 0 {
+			return ""
+		}
+		return strconv.FormatInt(rec.Timestamp, 10)
+	case "source":
+		return rec.Source
+	case "offset":
+		return strconv.FormatInt(rec.Offset, 10)
+	}
+	v, _ := rec.Field(column)
+	return v
+}
@@ -0,0 +1,106 @@
+package export
+
+import (
+	"bufio"
+	"compress/gzip"
+	"html/template"
+	"io"
+	"regexp"
+	"time"
+
+	"github.com/yourusername/weblogview/internal/bookmarks"
+	"github.com/yourusername/weblogview/internal/logline"
+)
+
+// FormatHTML is a self-contained HTML snapshot (see WriteSnapshot)
+const FormatHTML = "html"
+
+// snapshotTemplate is the page around the snapshot viewer; the viewer
+// itself, its styles and its ANSI colors come from the frontend build
+var snapshotTemplate = template.Must(template.New("snapshot").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="UTF-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1.0">
+  <title>{{.Snapshot.Source}} - WebLogView snapshot</title>
+  <style>
+    * {
+      margin: 0;
+      padding: 0;
+      box-sizing: border-box;
+    }
+
+    body {
+      font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif;
+      background: #1e1e1e;
+      color: #d4d4d4;
+      overflow: hidden;
+    }
+
+    #app {
+      height: 100vh;
+      display: flex;
+      flex-direction: column;
+    }
+  </style>
+</head>
+<body>
+  <div id="app"></div>
+  <script id="snapshot-data" type="application/json">{{.Snapshot}}</script>
+  <script>{{.Viewer}}</script>
+</body>
+</html>
+`))
+
+// scriptEnd matches what would end the inline viewer script early
+var scriptEnd = regexp.MustCompile(`(?i)</(script)`)
+
+// SnapshotLine is a line of a snapshot
+type SnapshotLine struct {
+	logline.Line
+	Bookmark *bookmarks.Bookmark `json:"bookmark,omitempty"` // Set when the line is bookmarked
+}
+
+// SnapshotBookmark is a bookmark of the snapshot's source
+type SnapshotBookmark struct {
+	bookmarks.Position
+	Shown bool `json:"shown"` // Its line is in the snapshot
+}
+
+// Snapshot is what a tab was showing: the filtered lines of a source and
+// its bookmarks
+type Snapshot struct {
+	Source    string             `json:"source"`
+	Filter    []string           `json:"filter"` // Human readable filter and time range, one per condition
+	Created   time.Time          `json:"created"`
+	Lines     []SnapshotLine     `json:"lines"`
+	Bookmarks []SnapshotBookmark `json:"bookmarks"`
+	Truncated bool               `json:"truncated"` // More lines passed the filter than were kept
+}
+
+// WriteSnapshot writes a snapshot as one static HTML file, readable without
+// WebLogView: the snapshot as JSON and the frontend's snapshot viewer
+// bundle (static/snapshot/snapshot.js of the server), which renders it
+// with the ANSI colors and search of the log viewer
+func WriteSnapshot(w io.Writer, snap Snapshot, viewer []byte, compress bool) error {
+	var gz *gzip.Writer
+	if compress {
+		gz = gzip.NewWriter(w)
+		w = gz
+	}
+	buf := bufio.NewWriter(w)
+	page := struct {
+		Snapshot Snapshot
+		Viewer   template.JS
+	}{snap, template.JS(scriptEnd.ReplaceAll(viewer, []byte(`<\/$1`)))}
+	if err := snapshotTemplate.Execute(buf, page); err != nil {
+		return err
+	}
+	if err := buf.Flush(); err != nil {
+		return err
+	}
+	if gz != nil {
+		return gz.Close()
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/weblogview/internal/bookmarks"
+	"github.com/yourusername/weblogview/internal/logline"
+)
+
+// between returns the text of page between start and the next end
+func between(t *testing.T, page, start, end string) string {
+	t.Helper()
+	i := strings.Index(page, start)
+	if i < 0 {
+		t.Fatalf("page has no %q", start)
+	}
+	rest := page[i+len(start):]
+	j := strings.Index(rest, end)
+	if j < 0 {
+		t.Fatalf("page has no %q after %q", end, start)
+	}
+	return rest[:j]
+}
+
+func TestWriteSnapshot(t *testing.T) {
+	mark := &bookmarks.Bookmark{ID: 1, Color: "#f14c4c", Note: "the crash"}
+	snap := Snapshot{
+		Source:  "/var/log/app.log",
+		Filter:  []string{"include: panic"},
+		Created: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		Lines: []SnapshotLine{
+			{Line: logline.Line{Text: "\x1b[31mpanic\x1b[0m </script><script>alert(1)</script>", Timestamp: 1700000000000}, Bookmark: mark},
+			{Line: logline.Line{Text: "<!-- & 'quotes' \"too\""}},
+		},
+		Bookmarks: []SnapshotBookmark{{Position: bookmarks.Position{Bookmark: *mark, Found: true, Line: 1}, Shown: true}},
+	}
+	viewer := []byte(`render("</script>", "</SCRIPT>")`)
+
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf, snap, viewer, false); err != nil {
+		t.Fatal(err)
+	}
+	page := buf.String()
+
+	data := between(t, page, `<script id="snapshot-data" type="application/json">`, "</script>")
+	var got Snapshot
+	if err := json.Unmarshal([]byte(data), &got); err != nil {
+		t.Fatalf("snapshot data isn't JSON: %v\n%s", err, data)
+	}
+	if got.Source != snap.Source || len(got.Lines) != 2 || got.Lines[0].Text != snap.Lines[0].Text ||
+		got.Lines[1].Text != snap.Lines[1].Text || got.Lines[0].Bookmark == nil || got.Lines[0].Bookmark.Color != mark.Color {
+		t.Errorf("snapshot data = %+v, want %+v", got, snap)
+	}
+	if len(got.Bookmarks) != 1 || !got.Bookmarks[0].Shown || got.Bookmarks[0].Note != "the crash" {
+		t.Errorf("bookmarks = %+v", got.Bookmarks)
+	}
+
+	script := between(t, page, "<script>", "</script>\n</body>")
+	if want := `render("<\/script>", "<\/SCRIPT>")`; script != want {
+		t.Errorf("viewer script = %s, want %s", script, want)
+	}
+	if n := strings.Count(strings.ToLower(page), "</script"); n != 2 {
+		t.Errorf("page closes %d scripts, want 2", n)
+	}
+}
@@ -0,0 +1,210 @@
+package fieldstats
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/yourusername/weblogview/internal/logline"
+)
+
+const (
+	// maxValues limits the distinct values counted per field; lines with
+	// further values are counted as Other
+	maxValues = 100000
+
+	// maxSamples is the reservoir size for numeric percentiles
+	maxSamples = 100000
+
+	// maxValueLength truncates long values before counting them
+	maxValueLength = 200
+
+	// defaultTop is the number of top values returned when none is given
+	defaultTop = 10
+)
+
+// Value is a field value and the number of lines having it
+type Value struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// Numeric summarizes the values of a field that are numbers
+type Numeric struct {
+	Count int64   `json:"count"` // Values that are numbers
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Mean  float64 `json:"mean"`
+	P50   float64 `json:"p50"`
+	P90   float64 `json:"p90"`
+	P99   float64 `json:"p99"`
+}
+
+// Stats summarizes the values of one field
+type Stats struct {
+	Field       string   `json:"field"`
+	Count       int64    `json:"count"`       // Lines with the field
+	Missing     int64    `json:"missing"`     // Lines without it
+	Cardinality int      `json:"cardinality"` // Distinct values
+	Capped      bool     `json:"capped"`      // Too many distinct values; Cardinality is a lower bound
+	Top         []Value  `json:"top"`         // Most frequent values first
+	Other       int64    `json:"other"`       // Lines with values beyond the distinct values counted
+	Numeric     *Numeric `json:"numeric,omitempty"`
+}
+
+// Result is the summary of the fields of a set of lines
+type Result struct {
+	Lines  int64   `json:"lines"`
+	Fields []Stats `json:"fields"`
+}
+
+// field counts the values of one field
+type field struct {
+	name     string
+	count    int64
+	values   map[string]int64
+	other    int64
+	capped   bool
+	numbers  int64
+	min, max float64
+	sum      float64
+	samples  []float64
+}
+
+// Aggregator counts the values of some fields over lines: top values,
+// cardinality and, for numbers, min, max, mean and percentiles. Fields
+// are looked up like the query language does (see logline.Line.Field), so
+// level and template work too. It isn't safe for concurrent use.
+type Aggregator struct {
+	lines  int64
+	fields []*field
+}
+
+// New creates an aggregator for the given fields
+func New(fields ...string) *Aggregator {
+	a := &Aggregator{}
+	for _, name := range fields {
+		a.fields = append(a.fields, &field{name: name, values: map[string]int64{}})
+	}
+	return a
+}
+
+// Add counts the fields of a line
+func (a *Aggregator) Add(line logline.Line) {
+	a.lines++
+	for _, f := range a.fields {
+		value, ok := line.Field(f.name)
+		if !ok {
+			continue
+		}
+		f.add(value)
+	}
+}
+
+// Lines returns the number of lines counted
+func (a *Aggregator) Lines() int64 {
+	return a.lines
+}
+
+// add counts one value
+func (f *field) add(value string) {
+	f.count++
+
+	// NaN and infinities (which ParseFloat accepts as words) aren't
+	// counted as numbers
+	if n, err := strconv.ParseFloat(value, 64); err == nil && !math.IsNaN(n) && !math.IsInf(n, 0) {
+		if f.numbers == 0 || n < f.min {
+			f.min = n
+		}
+		if f.numbers == 0 || n > f.max {
+			f.max = n
+		}
+		f.numbers++
+		f.sum += n
+
+		// Reservoir sampling keeps the percentiles' memory bounded
+		if len(f.samples) < maxSamples {
+			f.samples = append(f.samples, n)
+		} else if i := rand.Int63n(f.numbers); i < maxSamples {
+			f.samples[i] = n
+		}
+	}
+
+	value = truncate(value)
+	if _, ok := f.values[value]; !ok && len(f.values) >= maxValues {
+		f.capped = true
+		f.other++
+		return
+	}
+	f.values[value]++
+}
+
+// Result returns the summary of the fields, with up to top values each
+// (10 if top isn't positive)
+func (a *Aggregator) Result(top int) Result {
+	if top <= 0 {
+		top = defaultTop
+	}
+
+	result := Result{Lines: a.lines, Fields: []Stats{}}
+	for _, f := range a.fields {
+		stats := Stats{
+			Field:       f.name,
+			Count:       f.count,
+			Missing:     a.lines - f.count,
+			Cardinality: len(f.values),
+			Capped:      f.capped,
+			Top:         []Value{},
+			Other:       f.other,
+		}
+		for value, count := range f.values {
+			stats.Top = append(stats.Top, Value{Value: value, Count: count})
+		}
+		sort.Slice(stats.Top, func(i, j int) bool {
+			if stats.Top[i].Count != stats.Top[j].Count {
+				return stats.Top[i].Count > stats.Top[j].Count
+			}
+			return stats.Top[i].Value < stats.Top[j].Value
+		})
+		if len(stats.Top) > top {
+			stats.Top = stats.Top[:top]
+		}
+
+		if f.numbers > 0 {
+			sorted := append([]float64(nil), f.samples...)
+			sort.Float64s(sorted)
+			stats.Numeric = &Numeric{
+				Count: f.numbers,
+				Min:   f.min,
+				Max:   f.max,
+				Mean:  f.sum / float64(f.numbers),
+				P50:   percentile(sorted, 0.50),
+				P90:   percentile(sorted, 0.90),
+				P99:   percentile(sorted, 0.99),
+			}
+		}
+		result.Fields = append(result.Fields, stats)
+	}
+	return result
+}
+
+// percentile returns the q-th quantile of sorted values
+func percentile(sorted []float64, q float64) float64 {
+	i := int(q * float64(len(sorted)-1))
+	return sorted[i]
+}
+
+// truncate shortens a value to maxValueLength bytes without splitting a
+// character
+func truncate(value string) string {
+	if len(value) <= maxValueLength {
+		return value
+	}
+	cut := maxValueLength
+	for cut > 0 && !utf8.RuneStart(value[cut]) {
+		cut--
+	}
+	return value[:cut] + "…"
+}
@@ -0,0 +1,27 @@
+package fieldstats
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/yourusername/weblogview/internal/logline"
+)
+
+func TestNonFiniteValuesAreNotNumbers(t *testing.T) {
+	a := New("latency")
+	for _, value := range []string{"10", "NaN", "inf", "-Infinity", "30"} {
+		a.Add(logline.Line{Fields: map[string]string{"latency": value}})
+	}
+
+	result := a.Result(10)
+	numeric := result.Fields[0].Numeric
+	if numeric == nil || numeric.Count != 2 || numeric.Min != 10 || numeric.Max != 30 || numeric.Mean != 20 {
+		t.Errorf("numeric = %+v, want 2 numbers from 10 to 30", numeric)
+	}
+	if result.Fields[0].Count != 5 {
+		t.Errorf("count = %d, want 5", result.Fields[0].Count)
+	}
+	if _, err := json.Marshal(result); err != nil {
+		t.Errorf("encoding the result: %v", err)
+	}
+}
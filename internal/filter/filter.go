@@ -0,0 +1,236 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"github.com/yourusername/weblogview/internal/logline"
+	"github.com/yourusername/weblogview/internal/query"
+)
+
+const (
+	// maxPatterns limits the number of include or exclude patterns
+	maxPatterns = 32
+
+	// maxPatternLength limits the length of a single pattern
+	maxPatternLength = 1024
+)
+
+// Filter applies include/exclude patterns and an optional query to log
+// lines. Patterns are compiled with Go's RE2 engine, which matches in time
+// linear in the input, so user supplied patterns can't cause catastrophic
+// backtracking.
+type Filter struct {
+	include  []*regexp.Regexp
+	exclude  []*regexp.Regexp
+	query    *query.Query
+	counter  *query.Counter // Counts of the query's count stage, if any
+	minLevel int            // Rank of the minimum level, -1 for none
+	only     map[int]bool   // Template IDs to keep, nil for all
+	hide     map[int]bool   // Template IDs to drop
+	opts     Options
+
+	matched atomic.Int64
+	dropped atomic.Int64
+}
+
+// Options describes the patterns of a filter
+type Options struct {
+	Include       []string `json:"include,omitempty"`       // A line must match at least one (if any are given)
+	Exclude       []string `json:"exclude,omitempty"`       // A line must match none
+	CaseSensitive bool     `json:"caseSensitive,omitempty"` // Patterns are case-insensitive by default
+	Query         string   `json:"query,omitempty"`         // Query language expression (see internal/query)
+	MinLevel      string   `json:"minLevel,omitempty"`      // Drop lines below this level; lines without a level are kept
+	OnlyTemplates []int    `json:"onlyTemplates,omitempty"` // Keep only lines of these templates (if any are given)
+	HideTemplates []int    `json:"hideTemplates,omitempty"` // Drop lines of these templates
+}
+
+// Stats counts the lines a filter has let through and dropped
+type Stats struct {
+	Matched int64 `json:"matched"`
+	Dropped int64 `json:"dropped"`
+
+	// Counts holds the result of the query's count stage
+	Counts []query.Bucket `json:"counts,omitempty"`
+}
+
+// New compiles a filter from the given options
+func New(opts Options) (*Filter, error) {
+	include, err := compilePatterns(opts.Include, opts.CaseSensitive)
+	if err != nil {
+		return nil, fmt.Errorf("invalid include pattern: %w", err)
+	}
+
+	exclude, err := compilePatterns(opts.Exclude, opts.CaseSensitive)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exclude pattern: %w", err)
+	}
+
+	f := &Filter{include: include, exclude: exclude, minLevel: -1, opts: opts}
+	if opts.MinLevel != "" {
+		f.minLevel = logline.LevelRank(opts.MinLevel)
+		if f.minLevel < 0 {
+			return nil, fmt.Errorf("unknown level %q", opts.MinLevel)
+		}
+	}
+	if len(opts.OnlyTemplates) > 0 {
+		f.only = templateSet(opts.OnlyTemplates)
+	}
+	if len(opts.HideTemplates) > 0 {
+		f.hide = templateSet(opts.HideTemplates)
+	}
+	if strings.TrimSpace(opts.Query) != "" {
+		q, err := query.Parse(opts.Query)
+		if err != nil {
+			return nil, fmt.Errorf("invalid query: %w", err)
+		}
+		f.query = q
+		if q.Aggregates() {
+			f.counter = q.NewCounter()
+		}
+	}
+
+	return f, nil
+}
+
+// templateSet converts template IDs to a set
+func templateSet(ids []int) map[int]bool {
+	set := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+// compilePatterns compiles non-empty patterns, enforcing size limits
+func compilePatterns(patterns []string, caseSensitive bool) ([]*regexp.Regexp, error) {
+	if len(patterns) > maxPatterns {
+		return nil, fmt.Errorf("too many patterns (max %d)", maxPatterns)
+	}
+
+	compiled := []*regexp.Regexp{}
+	for _, pattern := range patterns {
+		if pattern == "" {
+			continue
+		}
+		if len(pattern) > maxPatternLength {
+			return nil, fmt.Errorf("pattern too long (max %d characters)", maxPatternLength)
+		}
+		if !caseSensitive {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, re)
+	}
+
+	return compiled, nil
+}
+
+// Empty reports whether the filter lets every line through
+func (f *Filter) Empty() bool {
+	return f == nil || (len(f.include) == 0 && len(f.exclude) == 0 && f.query == nil && f.minLevel < 0 &&
+		f.only == nil && f.hide == nil)
+}
+
+// Options returns the options the filter was created with
+func (f *Filter) Options() Options {
+	if f == nil {
+		return Options{}
+	}
+	return f.opts
+}
+
+// Match reports whether text passes the filter, without updating the counts
+func (f *Filter) Match(text string) bool {
+	return f.MatchLine(logline.New(text))
+}
+
+// MatchLine reports whether a line passes the filter, without updating the
+// counts. Patterns apply to the text, the query also sees the metadata.
+func (f *Filter) MatchLine(line logline.Line) bool {
+	if f == nil {
+		return true
+	}
+	if f.minLevel >= 0 && line.Level != "" && logline.LevelRank(line.Level) < f.minLevel {
+		return false
+	}
+	if f.only != nil && !f.only[line.Template] {
+		return false
+	}
+	if f.hide[line.Template] {
+		return false
+	}
+	text := line.Text
+
+	if len(f.include) > 0 {
+		included := false
+		for _, re := range f.include {
+			if re.MatchString(text) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+
+	for _, re := range f.exclude {
+		if re.MatchString(text) {
+			return false
+		}
+	}
+
+	return f.query.Match(line)
+}
+
+// Accept reports whether text passes the filter and updates the counts
+func (f *Filter) Accept(text string) bool {
+	return f.AcceptLine(logline.New(text))
+}
+
+// AcceptLine reports whether a line passes the filter and updates the counts
+func (f *Filter) AcceptLine(line logline.Line) bool {
+	if f == nil {
+		return true
+	}
+
+	if f.MatchLine(line) {
+		f.matched.Add(1)
+		if f.counter != nil {
+			f.counter.Add(line)
+		}
+		return true
+	}
+	f.dropped.Add(1)
+	return false
+}
+
+// Apply returns the lines that pass the filter and updates the counts
+func (f *Filter) Apply(lines []logline.Line) []logline.Line {
+	if f.Empty() {
+		return lines
+	}
+
+	kept := make([]logline.Line, 0, len(lines))
+	for _, line := range lines {
+		if f.AcceptLine(line) {
+			kept = append(kept, line)
+		}
+	}
+
+	return kept
+}
+
+// Stats returns the number of lines matched and dropped so far
+func (f *Filter) Stats() Stats {
+	if f == nil {
+		return Stats{}
+	}
+	return Stats{Matched: f.matched.Load(), Dropped: f.dropped.Load(), Counts: f.counter.Buckets()}
+}
@@ -0,0 +1,242 @@
+package histogram
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yourusername/weblogview/internal/logline"
+)
+
+const (
+	// MaxBuckets limits the buckets of a histogram; the oldest are dropped
+	MaxBuckets = 2000
+
+	// maxGroups limits the distinct groups; the rest are counted as "(other)"
+	maxGroups = 20
+
+	// minBucket is the smallest bucket size
+	minBucket = time.Second
+
+	// ByLevel groups lines by detected level
+	ByLevel = "level"
+
+	// ByNone doesn't group lines
+	ByNone = "none"
+
+	// unknownGroup counts lines without the grouping field
+	unknownGroup = "unknown"
+
+	// otherGroup counts lines of groups beyond maxGroups
+	otherGroup = "(other)"
+)
+
+// Bucket is the line count of one time slot
+type Bucket struct {
+	Start  int64            `json:"start"` // Unix milliseconds
+	Count  int64            `json:"count"`
+	Groups map[string]int64 `json:"groups,omitempty"` // Counts per level or field value
+}
+
+// Histogram is the line volume over time
+type Histogram struct {
+	BucketMs  int64    `json:"bucketMs"`
+	By        string   `json:"by"`
+	Buckets   []Bucket `json:"buckets"` // Oldest first, including empty buckets between
+	Untimed   int64    `json:"untimed"` // Lines before the first timestamped line
+	Truncated bool     `json:"truncated"`
+}
+
+// Builder counts lines into time buckets. It is safe for concurrent use,
+// so a file scan and live lines can feed the same histogram.
+type Builder struct {
+	mu        sync.Mutex
+	bucket    int64 // Milliseconds
+	by        string
+	buckets   map[int64]*Bucket
+	groups    map[string]bool
+	oldest    int64
+	last      int64 // Time of the last timestamped line, for lines without one
+	untimed   int64
+	truncated bool
+	changed   bool
+}
+
+// ParseBucket parses a bucket size such as "10s" or "5m"
+func ParseBucket(value string) (time.Duration, error) {
+	if value == "" {
+		return 10 * time.Second, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid bucket size %q", value)
+	}
+	if d < minBucket {
+		return 0, fmt.Errorf("bucket size must be at least %s", minBucket)
+	}
+	return d, nil
+}
+
+// New creates a builder with the given bucket size, grouping by level,
+// by the value of a field, or not at all (ByNone)
+func New(bucket time.Duration, by string) *Builder {
+	if by == "" {
+		by = ByLevel
+	}
+	return &Builder{
+		bucket:  bucket.Milliseconds(),
+		by:      by,
+		buckets: map[int64]*Bucket{},
+		groups:  map[string]bool{},
+	}
+}
+
+// Bucket returns the bucket size
+func (b *Builder) Bucket() time.Duration {
+	return time.Duration(b.bucket) * time.Millisecond
+}
+
+// By returns the grouping of the builder
+func (b *Builder) By() string {
+	return b.by
+}
+
+// Add counts a line at its timestamp. Lines without one (such as stack
+// trace lines) count at the time of the last timestamped line.
+func (b *Builder) Add(line logline.Line) {
+	b.AddAt(line, line.Time())
+}
+
+// AddAt counts a line at t; a zero t behaves like a line without timestamp
+func (b *Builder) AddAt(line logline.Line, t time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ms := b.last
+	if !t.IsZero() {
+		ms = t.UnixMilli()
+		b.last = ms
+	}
+	if ms == 0 {
+		b.untimed++
+		b.changed = true
+		return
+	}
+
+	start := ms - ms%b.bucket
+	if ms < 0 && ms%b.bucket != 0 {
+		start -= b.bucket
+	}
+	bucket := b.buckets[start]
+	if bucket == nil {
+		if len(b.buckets) >= MaxBuckets {
+			if start < b.oldest {
+				// Older than everything kept
+				b.truncated = true
+				return
+			}
+			b.dropOldest()
+		}
+		bucket = &Bucket{Start: start}
+		b.buckets[start] = bucket
+		if len(b.buckets) == 1 || start < b.oldest {
+			b.oldest = start
+		}
+	}
+
+	bucket.Count++
+	if group, ok := b.group(line); ok {
+		if bucket.Groups == nil {
+			bucket.Groups = map[string]int64{}
+		}
+		bucket.Groups[group]++
+	}
+	b.changed = true
+}
+
+// group returns the group of a line
+func (b *Builder) group(line logline.Line) (string, bool) {
+	if b.by == ByNone {
+		return "", false
+	}
+	value, ok := line.Field(b.by)
+	if !ok || value == "" {
+		value = unknownGroup
+	}
+	if !b.groups[value] {
+		if len(b.groups) >= maxGroups {
+			return otherGroup, true
+		}
+		b.groups[value] = true
+	}
+	return value, true
+}
+
+// dropOldest removes the oldest bucket
+func (b *Builder) dropOldest() {
+	delete(b.buckets, b.oldest)
+	b.truncated = true
+
+	first := true
+	for start := range b.buckets {
+		if first || start < b.oldest {
+			b.oldest = start
+			first = false
+		}
+	}
+}
+
+// Changed reports whether lines were added since the last call
+func (b *Builder) Changed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	changed := b.changed
+	b.changed = false
+	return changed
+}
+
+// Histogram returns the buckets, filling the gaps between them with empty
+// buckets (the most recent MaxBuckets are kept)
+func (b *Builder) Histogram() Histogram {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	h := Histogram{
+		BucketMs:  b.bucket,
+		By:        b.by,
+		Buckets:   []Bucket{},
+		Untimed:   b.untimed,
+		Truncated: b.truncated,
+	}
+	if len(b.buckets) == 0 {
+		return h
+	}
+
+	starts := make([]int64, 0, len(b.buckets))
+	for start := range b.buckets {
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+
+	first, last := starts[0], starts[len(starts)-1]
+	if (last-first)/b.bucket >= MaxBuckets {
+		first = last - (MaxBuckets-1)*b.bucket
+		h.Truncated = true
+	}
+	for start := first; start <= last; start += b.bucket {
+		bucket := Bucket{Start: start}
+		if counted := b.buckets[start]; counted != nil {
+			bucket.Count = counted.Count
+			if len(counted.Groups) > 0 {
+				bucket.Groups = make(map[string]int64, len(counted.Groups))
+				for group, n := range counted.Groups {
+					bucket.Groups[group] = n
+				}
+			}
+		}
+		h.Buckets = append(h.Buckets, bucket)
+	}
+	return h
+}
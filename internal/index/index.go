@@ -0,0 +1,323 @@
+package index
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"hash/fnv"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+const (
+	// blockSize is the amount of text (in whole lines) covered by one block.
+	// Posting lists point at blocks rather than lines, which keeps the index
+	// a few percent of the file size while still skipping most of the file.
+	blockSize = 256 * 1024
+
+	// fingerprintSize is the amount of indexed text hashed to detect files
+	// that were rewritten rather than appended to
+	fingerprintSize = 4096
+)
+
+// Block is a line-aligned region of the indexed file
+type Block struct {
+	Offset int64 // Byte offset of the first line
+	Line   int64 // 1-based number of the first line
+}
+
+// Posting lists the blocks containing a trigram as delta-encoded uvarints
+type Posting struct {
+	Last  uint32 // Last block added
+	Count uint32
+	Data  []byte
+}
+
+// Index is a trigram index over the complete lines of a file. Trigrams are
+// taken from ASCII-lowercased text so the same index serves case-sensitive
+// and case-insensitive searches; matches are always verified by scanning.
+type Index struct {
+	Path        string
+	Size        int64 // Bytes indexed, always ending at a line boundary
+	ModTime     time.Time
+	Lines       int64
+	Fingerprint uint64
+	Blocks      []Block
+	Postings    map[uint32]*Posting
+
+	open     []uint32 // Trigrams already added for the last block
+	segments int      // Segments in the index file, see save
+}
+
+// newIndex creates an empty index for path
+func newIndex(path string) *Index {
+	return &Index{Path: path, Postings: map[uint32]*Posting{}}
+}
+
+// add records that block contains trigram t and reports whether it was
+// not recorded yet
+func (p *Posting) add(block uint32) bool {
+	if p.Count > 0 && p.Last == block {
+		return false
+	}
+	delta := block
+	if p.Count > 0 {
+		delta = block - p.Last
+	}
+	p.Data = binary.AppendUvarint(p.Data, uint64(delta))
+	p.Last = block
+	p.Count++
+	return true
+}
+
+// blocks decodes the posting list
+func (p *Posting) blocks() []uint32 {
+	out := make([]uint32, 0, p.Count)
+	var block uint32
+	data := p.Data
+	for len(data) > 0 {
+		delta, n := binary.Uvarint(data)
+		if n <= 0 {
+			break
+		}
+		data = data[n:]
+		block += uint32(delta)
+		out = append(out, block)
+	}
+	return out
+}
+
+// update indexes the complete lines appended to file since the last update
+// and returns what it added as a segment
+func (ix *Index) update(file *os.File, info os.FileInfo) (*segment, error) {
+	if _, err := file.Seek(ix.Size, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	// Continue filling the last block when it has room
+	firstNew := len(ix.Blocks)
+	touched, prevOpen := ix.open, ix.open
+	if len(ix.Blocks) == 0 || ix.Size-ix.Blocks[len(ix.Blocks)-1].Offset >= blockSize {
+		ix.Blocks = append(ix.Blocks, Block{Offset: ix.Size, Line: ix.Lines + 1})
+		touched = []uint32{}
+	}
+	blockID := uint32(len(ix.Blocks) - 1)
+
+	// seen marks the trigrams already added for the current block
+	seen := make([]uint64, 1<<24/64)
+	for _, t := range touched {
+		seen[t/64] |= 1 << (t % 64)
+	}
+	added := map[uint32]*Posting{}
+
+	reader := bufio.NewReaderSize(file, 64*1024)
+	for {
+		raw, err := reader.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			// Very long line: gather it completely
+			long := append([]byte{}, raw...)
+			for err == bufio.ErrBufferFull {
+				raw, err = reader.ReadSlice('\n')
+				long = append(long, raw...)
+			}
+			raw = long
+		}
+		if err != nil && err != io.EOF {
+			ix.open = touched
+			return nil, err
+		}
+		if len(raw) == 0 || raw[len(raw)-1] != '\n' {
+			// A partial last line is indexed once it is complete
+			break
+		}
+
+		if ix.Size-ix.Blocks[blockID].Offset >= blockSize {
+			ix.Blocks = append(ix.Blocks, Block{Offset: ix.Size, Line: ix.Lines + 1})
+			blockID++
+			for _, t := range touched {
+				seen[t/64] = 0
+			}
+			touched = touched[:0]
+		}
+
+		var t uint32
+		for i, b := range raw[:len(raw)-1] {
+			t = (t<<8 | uint32(lower(b))) & 0xffffff
+			if i < 2 || seen[t/64]&(1<<(t%64)) != 0 {
+				continue
+			}
+			seen[t/64] |= 1 << (t % 64)
+			touched = append(touched, t)
+
+			p := ix.Postings[t]
+			if p == nil {
+				p = &Posting{}
+				ix.Postings[t] = p
+			}
+			if p.add(blockID) {
+				a := added[t]
+				if a == nil {
+					a = &Posting{}
+					added[t] = a
+				}
+				a.add(blockID)
+			}
+		}
+
+		ix.Size += int64(len(raw))
+		ix.Lines++
+	}
+
+	ix.open = touched
+
+	// Drop an empty trailing block so it never becomes a candidate
+	if last := ix.Blocks[len(ix.Blocks)-1]; last.Offset == ix.Size && len(ix.Blocks) > 1 {
+		ix.Blocks = ix.Blocks[:len(ix.Blocks)-1]
+		ix.open = prevOpen
+	}
+	firstNew = min(firstNew, len(ix.Blocks))
+
+	ix.ModTime = info.ModTime()
+	fingerprint, err := fingerprintFile(file, ix.Size)
+	if err != nil {
+		return nil, err
+	}
+	ix.Fingerprint = fingerprint
+
+	seg := ix.header()
+	seg.Blocks = ix.Blocks[firstNew:]
+	seg.Postings = added
+	return seg, nil
+}
+
+// lastBlockTrigrams returns the trigrams recorded for the last block. It
+// walks every posting list, so it is only used when loading an index.
+func (ix *Index) lastBlockTrigrams() []uint32 {
+	out := []uint32{}
+	if len(ix.Blocks) == 0 {
+		return out
+	}
+	last := uint32(len(ix.Blocks) - 1)
+	for t, p := range ix.Postings {
+		if p.Count > 0 && p.Last == last {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// appended reports whether the file still starts with the indexed text,
+// i.e. it has only grown since it was indexed
+func (ix *Index) appended(file *os.File, info os.FileInfo) bool {
+	if info.Size() < ix.Size {
+		return false
+	}
+	fingerprint, err := fingerprintFile(file, ix.Size)
+	return err == nil && fingerprint == ix.Fingerprint
+}
+
+// upToDate reports whether the file is unchanged since it was indexed
+func (ix *Index) upToDate(info os.FileInfo) bool {
+	return info.Size() == ix.Size && info.ModTime().Equal(ix.ModTime)
+}
+
+// candidates returns the blocks that contain every trigram, or ok=false
+// when there are no trigrams to narrow the search
+func (ix *Index) candidates(trigrams []uint32) (blocks []uint32, ok bool) {
+	if len(trigrams) == 0 {
+		return nil, false
+	}
+
+	lists := make([][]uint32, 0, len(trigrams))
+	for _, t := range trigrams {
+		p := ix.Postings[t]
+		if p == nil {
+			return nil, true
+		}
+		lists = append(lists, p.blocks())
+	}
+
+	// Intersect starting with the shortest list
+	sort.Slice(lists, func(i, j int) bool { return len(lists[i]) < len(lists[j]) })
+	blocks = lists[0]
+	for _, list := range lists[1:] {
+		blocks = intersect(blocks, list)
+		if len(blocks) == 0 {
+			break
+		}
+	}
+	return blocks, true
+}
+
+// blockEnd returns the end offset of block i
+func (ix *Index) blockEnd(i int) int64 {
+	if i+1 < len(ix.Blocks) {
+		return ix.Blocks[i+1].Offset
+	}
+	return ix.Size
+}
+
+// intersect returns the values present in both sorted lists
+func intersect(a, b []uint32) []uint32 {
+	out := a[:0:0]
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			out = append(out, a[i])
+			i++
+			j++
+		}
+	}
+	return out
+}
+
+// fingerprintFile hashes the last indexed bytes of the file
+func fingerprintFile(file *os.File, size int64) (uint64, error) {
+	start := size - fingerprintSize
+	if start < 0 {
+		start = 0
+	}
+	buf := make([]byte, size-start)
+	if _, err := file.ReadAt(buf, start); err != nil && err != io.EOF {
+		return 0, err
+	}
+	h := fnv.New64a()
+	h.Write(buf)
+	return h.Sum64(), nil
+}
+
+// lower lowercases an ASCII letter
+func lower(b byte) byte {
+	if 'A' <= b && b <= 'Z' {
+		return b + 'a' - 'A'
+	}
+	return b
+}
+
+// trigramsOf returns the distinct trigrams of the lowercased literals
+func trigramsOf(literals []string) []uint32 {
+	set := map[uint32]bool{}
+	out := []uint32{}
+	for _, lit := range literals {
+		lit := []byte(lit)
+		for i := 0; i+3 <= len(lit); i++ {
+			t := uint32(lower(lit[i]))<<16 | uint32(lower(lit[i+1]))<<8 | uint32(lower(lit[i+2]))
+			if !set[t] {
+				set[t] = true
+				out = append(out, t)
+			}
+		}
+	}
+	return out
+}
+
+// containsNewline reports whether a literal spans lines (never indexed)
+func containsNewline(s string) bool {
+	return bytes.IndexByte([]byte(s), '\n') >= 0
+}
@@ -0,0 +1,122 @@
+package index
+
+import (
+	"regexp/syntax"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yourusername/weblogview/internal/query"
+	"github.com/yourusername/weblogview/internal/search"
+)
+
+// requiredLiterals returns strings that every matching line must contain
+// (ignoring ASCII case). An empty result means the index can't help.
+func requiredLiterals(opts search.Options) []string {
+	if opts.Language {
+		q, err := query.Parse(opts.Query)
+		if err != nil {
+			return nil
+		}
+		// Text terms are matched ignoring case
+		return usable(q.RequiredTerms(), true)
+	}
+	if !opts.Regex {
+		return usable([]string{opts.Query}, !opts.CaseSensitive)
+	}
+
+	flags := syntax.Perl
+	if !opts.CaseSensitive {
+		flags |= syntax.FoldCase
+	}
+	re, err := syntax.Parse(opts.Query, flags)
+	if err != nil {
+		return nil
+	}
+	return usable(literalsOf(re.Simplify()), !opts.CaseSensitive)
+}
+
+// literalsOf collects the literal runs that a regexp requires
+func literalsOf(re *syntax.Regexp) []string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return []string{string(re.Rune)}
+
+	case syntax.OpCapture, syntax.OpPlus:
+		return literalsOf(re.Sub[0])
+
+	case syntax.OpRepeat:
+		if re.Min >= 1 {
+			return literalsOf(re.Sub[0])
+		}
+
+	case syntax.OpConcat:
+		out := []string{}
+		var run strings.Builder
+		for _, sub := range re.Sub {
+			if sub.Op == syntax.OpLiteral {
+				run.WriteString(string(sub.Rune))
+				continue
+			}
+			if run.Len() > 0 {
+				out = append(out, run.String())
+				run.Reset()
+			}
+			out = append(out, literalsOf(sub)...)
+		}
+		if run.Len() > 0 {
+			out = append(out, run.String())
+		}
+		return out
+	}
+
+	// Alternations, optional parts and character classes require nothing
+	return nil
+}
+
+// usable drops literals the index can't answer for. With case folding,
+// non-ASCII text may match other byte sequences, so only ASCII literals
+// are used, and they are split around k and s, which also fold to the
+// Kelvin and long s signs.
+func usable(literals []string, foldCase bool) []string {
+	out := []string{}
+	for _, lit := range literals {
+		if containsNewline(lit) {
+			continue
+		}
+		if !foldCase {
+			if len(lit) >= 3 {
+				out = append(out, lit)
+			}
+			continue
+		}
+		if !isASCII(lit) {
+			continue
+		}
+		for _, part := range strings.FieldsFunc(lit, foldsBeyondASCII) {
+			if len(part) >= 3 {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
+
+// foldsBeyondASCII reports whether an ASCII letter case-folds to a
+// non-ASCII rune as well
+func foldsBeyondASCII(r rune) bool {
+	switch r {
+	case 'k', 'K', 's', 'S':
+		return true
+	}
+	return false
+}
+
+// isASCII reports whether s has only ASCII characters
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,27 @@
+package index
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUsable(t *testing.T) {
+	cases := []struct {
+		literals []string
+		foldCase bool
+		want     []string
+	}{
+		{[]string{"timeout", "ab"}, false, []string{"timeout"}},
+		{[]string{"success"}, false, []string{"success"}},
+		{[]string{"success"}, true, []string{"ucce"}},
+		{[]string{"task queue"}, true, []string{" queue"}},
+		{[]string{"KSK"}, true, []string{}},
+		{[]string{"café"}, true, []string{}},
+		{[]string{"line\nbreak"}, false, []string{}},
+	}
+	for _, c := range cases {
+		if got := usable(c.literals, c.foldCase); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("usable(%q, %v) = %q, want %q", c.literals, c.foldCase, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,615 @@
+package index
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/yourusername/weblogview/internal/query"
+	"github.com/yourusername/weblogview/internal/search"
+)
+
+const (
+	// touchDelay batches index updates while a watched file is growing
+	touchDelay = 2 * time.Second
+
+	// indexMagic starts every index file
+	indexMagic = "weblogview index 2\n"
+
+	// maxSegments bounds the segments appended to an index file before it
+	// is rewritten as a single one, which keeps loading fast
+	maxSegments = 512
+)
+
+// Status describes the index of a file
+type Status struct {
+	Path      string    `json:"path"`
+	Indexed   bool      `json:"indexed"`
+	Building  bool      `json:"building"`
+	Size      int64     `json:"size"`  // Bytes indexed
+	Lines     int64     `json:"lines"` // Lines indexed
+	Blocks    int       `json:"blocks"`
+	Trigrams  int       `json:"trigrams"`
+	IndexSize int64     `json:"indexSize"` // Size of the index file on disk
+	ModTime   time.Time `json:"modTime,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// entry holds the loaded index of one file
+type entry struct {
+	mu       sync.Mutex
+	index    *Index
+	building bool
+	err      error
+	timer    *time.Timer
+}
+
+var (
+	entriesMu sync.Mutex
+	entries   = map[string]*entry{}
+)
+
+// getEntry returns the entry for path, loading an existing index from disk
+// if there is one. It returns nil when the file has no index.
+func getEntry(path string, create bool) *entry {
+	entriesMu.Lock()
+	defer entriesMu.Unlock()
+
+	if e, ok := entries[path]; ok {
+		return e
+	}
+
+	ix, err := load(path)
+	if err != nil && !create {
+		return nil
+	}
+
+	e := &entry{index: ix}
+	entries[path] = e
+	return e
+}
+
+// Build creates or updates the index of a file in the background. Use
+// Get to follow its progress.
+func Build(path string) (Status, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return Status{}, err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return Status{}, fmt.Errorf("file not found: %s", path)
+	}
+
+	e := getEntry(path, true)
+	e.mu.Lock()
+	if e.building {
+		e.mu.Unlock()
+		return Get(path), nil
+	}
+	e.building = true
+	e.err = nil
+	e.mu.Unlock()
+
+	go func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+
+		start := time.Now()
+		e.err = e.refresh(path)
+		e.building = false
+		if e.err != nil {
+			log.Printf("Failed to index %s: %v", path, e.err)
+			return
+		}
+		log.Printf("Indexed %s (%d lines) in %v", path, e.index.Lines, time.Since(start).Round(time.Millisecond))
+	}()
+
+	return Get(path), nil
+}
+
+// Get returns the index status of a file
+func Get(path string) Status {
+	path, _ = filepath.Abs(path)
+	status := Status{Path: path}
+
+	e := getEntry(path, false)
+	if e == nil {
+		return status
+	}
+
+	// Don't block on a running build; report it instead
+	if !e.mu.TryLock() {
+		status.Building = true
+		return status
+	}
+	defer e.mu.Unlock()
+
+	status.Building = e.building
+	if e.err != nil {
+		status.Error = e.err.Error()
+	}
+	if ix := e.index; ix != nil {
+		status.Indexed = true
+		status.Size = ix.Size
+		status.Lines = ix.Lines
+		status.Blocks = len(ix.Blocks)
+		status.Trigrams = len(ix.Postings)
+		status.ModTime = ix.ModTime
+		if info, err := os.Stat(indexPath(path)); err == nil {
+			status.IndexSize = info.Size()
+		}
+	}
+	return status
+}
+
+// Remove deletes the index of a file
+func Remove(path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	entriesMu.Lock()
+	e := entries[path]
+	delete(entries, path)
+	entriesMu.Unlock()
+
+	if e != nil {
+		e.mu.Lock()
+		if e.timer != nil {
+			e.timer.Stop()
+		}
+		e.mu.Unlock()
+	}
+
+	if err := os.Remove(indexPath(path)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Touch schedules an incremental update of the index of a file that is
+// being appended to. Files without an index are ignored.
+func Touch(path string) {
+	path, _ = filepath.Abs(path)
+
+	entriesMu.Lock()
+	e, ok := entries[path]
+	entriesMu.Unlock()
+	if !ok {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.index == nil || e.timer != nil {
+		return
+	}
+	e.timer = time.AfterFunc(touchDelay, func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+
+		e.timer = nil
+		if e.index == nil {
+			return
+		}
+		if err := e.refresh(path); err != nil {
+			log.Printf("Failed to update index of %s: %v", path, err)
+		}
+	})
+}
+
+// Search searches a file like search.File, scanning only the blocks that
+// may contain a match when the file has an index. The index is brought up
+// to date first, which only reads the text appended since the last update.
+func Search(ctx context.Context, path string, opts search.Options, fn func(search.Match) error) (total search.Summary, err error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return search.Summary{}, err
+	}
+
+	e := getEntry(abs, false)
+	trigrams := trigramsOf(requiredLiterals(opts))
+	if e == nil || len(trigrams) == 0 {
+		return search.File(ctx, path, opts, fn)
+	}
+
+	// Searching during a build would wait for it; scan the file instead
+	if !e.mu.TryLock() {
+		return search.File(ctx, path, opts, fn)
+	}
+	if e.index == nil || e.building {
+		e.mu.Unlock()
+		return search.File(ctx, path, opts, fn)
+	}
+	if err := e.refresh(abs); err != nil {
+		e.mu.Unlock()
+		return search.File(ctx, path, opts, fn)
+	}
+	blocks, _ := e.index.candidates(trigrams)
+	ranges := e.index.ranges(blocks, opts.Context > 0)
+
+	// Always scan the partial line after the indexed text
+	if n := len(ranges); n > 0 && ranges[n-1].end == e.index.Size {
+		ranges[n-1].end = -1
+	} else {
+		ranges = append(ranges, scanRange{start: e.index.Size, end: -1, line: e.index.Lines + 1})
+	}
+	e.mu.Unlock()
+
+	file, err := os.Open(abs)
+	if err != nil {
+		return search.Summary{}, err
+	}
+	defer file.Close()
+
+	// A count stage needs every range scanned, so the limit on matches
+	// passed on is applied here rather than per range
+	var counter *query.Counter
+	if opts.Language {
+		if q, err := query.Parse(opts.Query); err == nil && q.Aggregates() {
+			counter = q.NewCounter()
+		}
+	}
+
+	total.Indexed = true
+	defer func() {
+		if counter != nil {
+			total.Counts = counter.Buckets()
+		}
+	}()
+
+	for i, rng := range ranges {
+		end := rng.end
+		if end < 0 {
+			end = math.MaxInt64
+		}
+		r := io.NewSectionReader(file, rng.start, end-rng.start)
+
+		rangeOpts := opts
+		rangeFn := fn
+		if counter != nil {
+			rangeOpts.MaxResults = 0
+			rangeFn = func(m search.Match) error {
+				if opts.MaxResults > 0 && total.Matches >= int64(opts.MaxResults) {
+					total.Truncated = true
+					return nil
+				}
+				total.Matches++
+				return fn(m)
+			}
+		} else if opts.MaxResults > 0 {
+			rangeOpts.MaxResults = opts.MaxResults - int(total.Matches)
+		}
+
+		summary, err := search.Section(ctx, r, rng.line, rng.start, rangeOpts, rangeFn)
+		if counter != nil {
+			counter.Merge(summary.Counts)
+		} else {
+			total.Matches += summary.Matches
+		}
+		total.LinesScanned += summary.LinesScanned
+		total.BytesScanned += summary.BytesScanned
+		if err != nil {
+			return total, err
+		}
+		if summary.Canceled {
+			total.Canceled = true
+			return total, nil
+		}
+		if counter == nil && opts.MaxResults > 0 && total.Matches >= int64(opts.MaxResults) {
+			total.Truncated = summary.Truncated
+			if !total.Truncated {
+				// Candidate blocks may not hold a real match
+				total.Truncated, err = anyMatch(ctx, file, ranges[i+1:], opts)
+			}
+			return total, err
+		}
+	}
+
+	return total, nil
+}
+
+// errFound stops a scan at the first match (see anyMatch)
+var errFound = errors.New("found")
+
+// anyMatch reports whether any of the ranges holds a match
+func anyMatch(ctx context.Context, file *os.File, ranges []scanRange, opts search.Options) (bool, error) {
+	opts.MaxResults = 0
+	opts.Context = 0
+	for _, rng := range ranges {
+		end := rng.end
+		if end < 0 {
+			end = math.MaxInt64
+		}
+		r := io.NewSectionReader(file, rng.start, end-rng.start)
+		_, err := search.Section(ctx, r, rng.line, rng.start, opts, func(search.Match) error {
+			return errFound
+		})
+		if err == errFound {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// scanRange is a region of the file to scan; end < 0 means to EOF
+type scanRange struct {
+	start, end int64
+	line       int64
+}
+
+// ranges merges candidate blocks into contiguous regions. With context,
+// neighbouring blocks are included so context lines aren't cut off.
+func (ix *Index) ranges(blocks []uint32, withContext bool) []scanRange {
+	out := []scanRange{}
+	for _, b := range blocks {
+		first, last := int(b), int(b)
+		if withContext {
+			first = max(first-1, 0)
+			last = min(last+1, len(ix.Blocks)-1)
+		}
+
+		start, end := ix.Blocks[first].Offset, ix.blockEnd(last)
+		if n := len(out); n > 0 && out[n-1].end >= start {
+			out[n-1].end = max(out[n-1].end, end)
+			continue
+		}
+		out = append(out, scanRange{start: start, end: end, line: ix.Blocks[first].Line})
+	}
+	return out
+}
+
+// refresh brings the index up to date with the file, rebuilding it if the
+// file was rewritten, and saves it. Callers hold e.mu.
+func (e *entry) refresh(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	if e.index != nil && e.index.upToDate(info) {
+		return nil
+	}
+	if e.index == nil || !e.index.appended(file, info) {
+		e.index = newIndex(path)
+	}
+
+	seg, err := e.index.update(file, info)
+	if err != nil {
+		// The file no longer matches the index; write it whole next time
+		e.index.segments = 0
+		return err
+	}
+	return save(e.index, seg)
+}
+
+// Dir returns the directory holding the index files
+func Dir() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".", ".weblogview", "index")
+	}
+	return filepath.Join(homeDir, ".weblogview", "index")
+}
+
+// indexPath returns the index file for a log file
+func indexPath(path string) string {
+	sum := sha256.Sum256([]byte(path))
+	return filepath.Join(Dir(), hex.EncodeToString(sum[:16])+".idx")
+}
+
+// segment is one record of an index file: the state of the indexed file
+// after an update, with the blocks and posting entries the update added.
+// The first segment of an index file holds the complete index; the ones
+// appended after it are replayed on top when loading.
+type segment struct {
+	Path        string
+	Size        int64
+	ModTime     time.Time
+	Lines       int64
+	Fingerprint uint64
+	Blocks      []Block
+	Postings    map[uint32]*Posting // Blocks added per trigram
+}
+
+// header returns a segment describing the indexed file, without blocks
+// or postings
+func (ix *Index) header() *segment {
+	return &segment{
+		Path:        ix.Path,
+		Size:        ix.Size,
+		ModTime:     ix.ModTime,
+		Lines:       ix.Lines,
+		Fingerprint: ix.Fingerprint,
+	}
+}
+
+// apply replays a segment onto the index
+func (ix *Index) apply(seg *segment) {
+	ix.Size = seg.Size
+	ix.ModTime = seg.ModTime
+	ix.Lines = seg.Lines
+	ix.Fingerprint = seg.Fingerprint
+	ix.Blocks = append(ix.Blocks, seg.Blocks...)
+
+	for t, added := range seg.Postings {
+		p := ix.Postings[t]
+		if p == nil {
+			// Both are delta-encoded from block 0
+			ix.Postings[t] = added
+			continue
+		}
+		for _, block := range added.blocks() {
+			p.add(block)
+		}
+	}
+}
+
+// load reads the index of a file from disk
+func load(path string) (*Index, error) {
+	file, err := os.Open(indexPath(path))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+	magic := make([]byte, len(indexMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic) != indexMagic {
+		return nil, errors.New("invalid index: unknown format")
+	}
+
+	ix := newIndex(path)
+	damaged := false
+	for {
+		seg, err := readSegment(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if ix.segments == 0 {
+				return nil, fmt.Errorf("invalid index: %w", err)
+			}
+			// A segment cut short while it was appended: keep the ones
+			// before it and rewrite the file on the next save
+			damaged = true
+			break
+		}
+		if seg.Path != path {
+			return nil, fmt.Errorf("index belongs to %s", seg.Path)
+		}
+		ix.apply(seg)
+		ix.segments++
+	}
+	if damaged {
+		ix.segments = 0
+	}
+	ix.open = ix.lastBlockTrigrams()
+	return ix, nil
+}
+
+// readSegment reads one length-prefixed segment. It returns io.EOF at the
+// end of the file.
+func readSegment(r io.Reader) (*segment, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, err
+	}
+	data := make([]byte, binary.BigEndian.Uint32(size[:]))
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	var seg segment
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&seg); err != nil {
+		return nil, err
+	}
+	if seg.Postings == nil {
+		seg.Postings = map[uint32]*Posting{}
+	}
+	return &seg, nil
+}
+
+// writeSegment writes a length-prefixed segment in a single write
+func writeSegment(w io.Writer, seg *segment) error {
+	var buf bytes.Buffer
+	buf.Write(make([]byte, 4))
+	if err := gob.NewEncoder(&buf).Encode(seg); err != nil {
+		return err
+	}
+	data := buf.Bytes()
+	binary.BigEndian.PutUint32(data, uint32(len(data)-4))
+	_, err := w.Write(data)
+	return err
+}
+
+// save persists an update of the index by appending its segment to the
+// index file. A new index, or one with many segments, is written whole
+// instead (see writeIndex).
+func save(ix *Index, seg *segment) error {
+	if ix.segments == 0 || ix.segments >= maxSegments {
+		if err := writeIndex(ix); err != nil {
+			return err
+		}
+		ix.segments = 1
+		return nil
+	}
+
+	file, err := os.OpenFile(indexPath(ix.Path), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		ix.segments = 0
+		return err
+	}
+	if err := writeSegment(file, seg); err != nil {
+		file.Close()
+		ix.segments = 0
+		return err
+	}
+	if err := file.Close(); err != nil {
+		ix.segments = 0
+		return err
+	}
+	ix.segments++
+	return nil
+}
+
+// writeIndex writes the whole index as a single segment, replacing the
+// previous index file atomically
+func writeIndex(ix *Index) error {
+	if err := os.MkdirAll(Dir(), 0755); err != nil {
+		return err
+	}
+
+	target := indexPath(ix.Path)
+	tmp, err := os.CreateTemp(Dir(), ".tmp-*.idx")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	seg := ix.header()
+	seg.Blocks = ix.Blocks
+	seg.Postings = ix.Postings
+
+	w := bufio.NewWriter(tmp)
+	if _, err := w.WriteString(indexMagic); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := writeSegment(w, seg); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
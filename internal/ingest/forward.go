@@ -0,0 +1,370 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/weblogview/internal/logline"
+	"github.com/yourusername/weblogview/internal/sources"
+)
+
+// ForwardKind is the stream kind used for Fluent Forward sources
+const ForwardKind = "forward"
+
+// forwardIdleTimeout closes connections that stay silent for too long
+const forwardIdleTimeout = 5 * time.Minute
+
+// maxForwardUnpackedSize limits the decompressed size of a
+// CompressedPackedForward message
+const maxForwardUnpackedSize = 64 << 20
+
+// ForwardServer is a Fluent Forward protocol (v1) receiver. It accepts
+// Message, Forward, PackedForward and CompressedPackedForward modes, maps
+// each tag to a stream and acknowledges chunks when requested. Shared key
+// authentication (HELO/PING/PONG) is not supported.
+type ForwardServer struct {
+	registry *sources.Registry
+	listener net.Listener
+	wg       sync.WaitGroup
+}
+
+// forwardEntry is a single event decoded from a Forward message
+type forwardEntry struct {
+	time   time.Time
+	record map[string]interface{}
+}
+
+// NewForwardServer creates a Fluent Forward receiver
+func NewForwardServer(registry *sources.Registry) *ForwardServer {
+	return &ForwardServer{registry: registry}
+}
+
+// ListenAndServe listens on addr and handles connections until Close is called
+func (s *ForwardServer) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	return s.Serve(listener)
+}
+
+// Serve accepts connections on listener
+func (s *ForwardServer) Serve(listener net.Listener) error {
+	s.listener = listener
+	log.Printf("Fluent Forward receiver listening on %s", listener.Addr())
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				continue
+			}
+			s.wg.Wait()
+			return err
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handleConn(conn)
+		}()
+	}
+}
+
+// Close stops accepting connections
+func (s *ForwardServer) Close() error {
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Close()
+}
+
+// handleConn decodes messages from a single connection
+func (s *ForwardServer) handleConn(conn net.Conn) {
+	defer conn.Close()
+
+	decoder := newMsgpackDecoder(conn)
+	for {
+		conn.SetReadDeadline(time.Now().Add(forwardIdleTimeout))
+
+		value, err := decoder.Decode()
+		if err != nil {
+			if err != io.EOF {
+				log.Printf("Forward connection %s: %v", conn.RemoteAddr(), err)
+			}
+			return
+		}
+
+		msg, ok := value.([]interface{})
+		if !ok {
+			log.Printf("Forward connection %s: unexpected message type %T", conn.RemoteAddr(), value)
+			return
+		}
+
+		tag, entries, option, err := decodeForwardMessage(msg)
+		if err != nil {
+			log.Printf("Forward connection %s: %v", conn.RemoteAddr(), err)
+			return
+		}
+
+		s.ingest(tag, entries)
+
+		// Acknowledge the chunk so the sender doesn't retry it
+		if chunk, ok := option["chunk"].(string); ok && chunk != "" {
+			ack := appendMsgpackMap(nil, map[string]string{"ack": chunk})
+			if _, err := conn.Write(ack); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// ingest appends the entries to the stream of their tag
+func (s *ForwardServer) ingest(tag string, entries []forwardEntry) {
+	if len(entries) == 0 {
+		return
+	}
+
+	lines := make([]logline.Line, 0, len(entries))
+	for _, entry := range entries {
+		lines = append(lines, entry.line(tag))
+	}
+	s.registry.GetOrCreate(ForwardKind, tag).Append(lines)
+}
+
+// decodeForwardMessage decodes any of the Forward protocol event modes
+func decodeForwardMessage(msg []interface{}) (string, []forwardEntry, map[string]interface{}, error) {
+	if len(msg) < 2 {
+		return "", nil, nil, fmt.Errorf("invalid forward message: %d elements", len(msg))
+	}
+
+	tag, ok := msg[0].(string)
+	if !ok {
+		return "", nil, nil, fmt.Errorf("invalid forward message: tag is %T", msg[0])
+	}
+
+	switch second := msg[1].(type) {
+	case []interface{}:
+		// Forward mode: [tag, [[time, record], ...], option?]
+		var option map[string]interface{}
+		if len(msg) > 2 {
+			option, _ = msg[2].(map[string]interface{})
+		}
+		entries := make([]forwardEntry, 0, len(second))
+		for _, item := range second {
+			pair, ok := item.([]interface{})
+			if !ok || len(pair) < 2 {
+				return "", nil, nil, fmt.Errorf("invalid forward entry")
+			}
+			entry, err := decodeForwardEntry(pair[0], pair[1])
+			if err != nil {
+				return "", nil, nil, err
+			}
+			entries = append(entries, entry)
+		}
+		return tag, entries, option, nil
+
+	case string, []byte:
+		// PackedForward mode: [tag, bin, option?] where bin holds a
+		// sequence of msgpack encoded [time, record] entries
+		var option map[string]interface{}
+		if len(msg) > 2 {
+			option, _ = msg[2].(map[string]interface{})
+		}
+		packed := []byte(nil)
+		if s, ok := second.(string); ok {
+			packed = []byte(s)
+		} else {
+			packed = second.([]byte)
+		}
+		entries, err := decodePackedEntries(packed, option["compressed"] == "gzip")
+		return tag, entries, option, err
+
+	default:
+		// Message mode: [tag, time, record, option?]
+		if len(msg) < 3 {
+			return "", nil, nil, fmt.Errorf("invalid message mode event")
+		}
+		var option map[string]interface{}
+		if len(msg) > 3 {
+			option, _ = msg[3].(map[string]interface{})
+		}
+		entry, err := decodeForwardEntry(msg[1], msg[2])
+		if err != nil {
+			return "", nil, nil, err
+		}
+		return tag, []forwardEntry{entry}, option, nil
+	}
+}
+
+// decodePackedEntries decodes the entry stream of a PackedForward message
+func decodePackedEntries(packed []byte, compressed bool) ([]forwardEntry, error) {
+	if compressed {
+		// CompressedPackedForward may contain several concatenated gzip members
+		gz, err := gzip.NewReader(bytes.NewReader(packed))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip entries: %w", err)
+		}
+		defer gz.Close()
+		packed, err = io.ReadAll(io.LimitReader(gz, maxForwardUnpackedSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip entries: %w", err)
+		}
+		if len(packed) > maxForwardUnpackedSize {
+			return nil, fmt.Errorf("compressed entries exceed %d bytes", maxForwardUnpackedSize)
+		}
+	}
+
+	decoder := newMsgpackDecoder(bufio.NewReader(bytes.NewReader(packed)))
+	entries := []forwardEntry{}
+	for {
+		value, err := decoder.Decode()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid packed entries: %w", err)
+		}
+		pair, ok := value.([]interface{})
+		if !ok || len(pair) < 2 {
+			return nil, fmt.Errorf("invalid packed entry")
+		}
+		entry, err := decodeForwardEntry(pair[0], pair[1])
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// decodeForwardEntry decodes an event time and record
+func decodeForwardEntry(t, record interface{}) (forwardEntry, error) {
+	rec, ok := record.(map[string]interface{})
+	if !ok {
+		return forwardEntry{}, fmt.Errorf("invalid record type %T", record)
+	}
+
+	entry := forwardEntry{record: rec}
+	switch v := t.(type) {
+	case int64:
+		entry.time = time.Unix(v, 0)
+	case uint64:
+		entry.time = time.Unix(int64(v), 0)
+	case float64:
+		entry.time = time.Unix(0, int64(v*float64(time.Second)))
+	case msgpackExt:
+		// EventTime: ext type 0 with big-endian seconds and nanoseconds
+		if v.Type != 0 || len(v.Data) != 8 {
+			return forwardEntry{}, fmt.Errorf("invalid EventTime")
+		}
+		sec := binary.BigEndian.Uint32(v.Data[:4])
+		nsec := binary.BigEndian.Uint32(v.Data[4:])
+		entry.time = time.Unix(int64(sec), int64(nsec))
+	default:
+		return forwardEntry{}, fmt.Errorf("invalid event time type %T", t)
+	}
+
+	return entry, nil
+}
+
+// line converts an entry into a log line. The message is taken from the
+// "log" or "message" key when present (as Fluent Bit's tail and docker
+// inputs produce), otherwise the whole record is rendered as JSON.
+func (e forwardEntry) line(tag string) logline.Line {
+	fields := map[string]string{"tag": tag}
+	for k, v := range e.record {
+		fields[k] = forwardValueString(v)
+	}
+
+	text := ""
+	for _, key := range []string{"log", "message", "msg"} {
+		if v, ok := e.record[key]; ok {
+			text = strings.TrimRight(forwardValueString(v), "\r\n")
+			break
+		}
+	}
+	if text == "" {
+		text = forwardRecordJSON(e.record)
+	}
+
+	line := logline.New(text)
+	line.SetTime(e.time)
+	line.Fields = fields
+	return line
+}
+
+// forwardValueString renders a decoded msgpack value as text
+func forwardValueString(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case []byte:
+		return string(val)
+	case []interface{}, map[string]interface{}:
+		data, _ := json.Marshal(forwardJSONValue(val))
+		return string(data)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// forwardRecordJSON renders a record as JSON with sorted keys
+func forwardRecordJSON(record map[string]interface{}) string {
+	keys := make([]string, 0, len(record))
+	for k := range record {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(k)
+		value, _ := json.Marshal(forwardJSONValue(record[k]))
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.String()
+}
+
+// forwardJSONValue converts binary values to strings so they render as
+// text rather than base64 when marshaled
+func forwardJSONValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case []byte:
+		return string(val)
+	case msgpackExt:
+		return fmt.Sprintf("ext(%d)", val.Type)
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = forwardJSONValue(item)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = forwardJSONValue(item)
+		}
+		return out
+	}
+	return v
+}
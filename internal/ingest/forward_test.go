@@ -0,0 +1,248 @@
+package ingest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/weblogview/internal/sources"
+)
+
+// msgpackArray appends an array header
+func msgpackArray(buf []byte, n int) []byte {
+	return append(buf, 0xdc, byte(n>>8), byte(n))
+}
+
+// msgpackBin appends a bin value
+func msgpackBin(buf []byte, data []byte) []byte {
+	n := len(data)
+	buf = append(buf, 0xc6, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	return append(buf, data...)
+}
+
+// msgpackEventTime appends an EventTime extension value
+func msgpackEventTime(buf []byte, t time.Time) []byte {
+	sec, nsec := uint32(t.Unix()), uint32(t.Nanosecond())
+	return append(buf, 0xd7, 0x00,
+		byte(sec>>24), byte(sec>>16), byte(sec>>8), byte(sec),
+		byte(nsec>>24), byte(nsec>>16), byte(nsec>>8), byte(nsec))
+}
+
+// msgpackEntry appends a [time, {"log": text}] entry
+func msgpackEntry(buf []byte, t time.Time, text string) []byte {
+	buf = msgpackArray(buf, 2)
+	buf = msgpackEventTime(buf, t)
+	return appendMsgpackMap(buf, map[string]string{"log": text})
+}
+
+var testEventTime = time.Unix(1700000000, 250000000)
+
+// forwardMessages builds one message of each Forward mode for tag
+func forwardMessages(t *testing.T, tag string) map[string][]byte {
+	t.Helper()
+
+	message := msgpackArray(nil, 4)
+	message = appendMsgpackString(message, tag)
+	message = msgpackEventTime(message, testEventTime)
+	message = appendMsgpackMap(message, map[string]string{"log": "message mode"})
+	message = appendMsgpackMap(message, map[string]string{"chunk": "c1"})
+
+	forward := msgpackArray(nil, 3)
+	forward = appendMsgpackString(forward, tag)
+	forward = msgpackArray(forward, 2)
+	forward = msgpackEntry(forward, testEventTime, "forward one")
+	forward = msgpackEntry(forward, testEventTime, "forward two")
+	forward = appendMsgpackMap(forward, map[string]string{"chunk": "c2"})
+
+	entries := msgpackEntry(nil, testEventTime, "packed one")
+	entries = msgpackEntry(entries, testEventTime, "packed two")
+
+	packed := msgpackArray(nil, 3)
+	packed = appendMsgpackString(packed, tag)
+	packed = msgpackBin(packed, entries)
+	packed = appendMsgpackMap(packed, map[string]string{"chunk": "c3"})
+
+	compressed := msgpackArray(nil, 3)
+	compressed = appendMsgpackString(compressed, tag)
+	compressed = msgpackBin(compressed, gzipBytes(t, entries))
+	compressed = appendMsgpackMap(compressed, map[string]string{"chunk": "c4", "compressed": "gzip"})
+
+	return map[string][]byte{
+		"Message":                 message,
+		"Forward":                 forward,
+		"PackedForward":           packed,
+		"CompressedPackedForward": compressed,
+	}
+}
+
+// gzipBytes compresses data
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// decodeForward decodes a single encoded Forward message
+func decodeForward(data []byte) (string, []forwardEntry, map[string]interface{}, error) {
+	value, err := newMsgpackDecoder(bytes.NewReader(data)).Decode()
+	if err != nil {
+		return "", nil, nil, err
+	}
+	msg, ok := value.([]interface{})
+	if !ok {
+		return "", nil, nil, io.ErrUnexpectedEOF
+	}
+	return decodeForwardMessage(msg)
+}
+
+func TestForwardModes(t *testing.T) {
+	want := map[string][]string{
+		"Message":                 {"message mode"},
+		"Forward":                 {"forward one", "forward two"},
+		"PackedForward":           {"packed one", "packed two"},
+		"CompressedPackedForward": {"packed one", "packed two"},
+	}
+
+	for mode, data := range forwardMessages(t, "app.web") {
+		tag, entries, option, err := decodeForward(data)
+		if err != nil {
+			t.Errorf("%s: %v", mode, err)
+			continue
+		}
+		if tag != "app.web" {
+			t.Errorf("%s: tag = %q", mode, tag)
+		}
+		if option["chunk"] == nil {
+			t.Errorf("%s: chunk option missing", mode)
+		}
+		if len(entries) != len(want[mode]) {
+			t.Errorf("%s: got %d entries, want %d", mode, len(entries), len(want[mode]))
+			continue
+		}
+		for i, entry := range entries {
+			line := entry.line(tag)
+			if line.Text != want[mode][i] {
+				t.Errorf("%s: entry %d text = %q, want %q", mode, i, line.Text, want[mode][i])
+			}
+			if !entry.time.Equal(testEventTime) {
+				t.Errorf("%s: entry %d time = %v, want %v", mode, i, entry.time, testEventTime)
+			}
+			if line.Fields["tag"] != "app.web" {
+				t.Errorf("%s: entry %d tag field = %q", mode, i, line.Fields["tag"])
+			}
+		}
+	}
+}
+
+func TestForwardConnAcks(t *testing.T) {
+	registry := sources.NewRegistry(100)
+	server := NewForwardServer(registry)
+
+	client, conn := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		server.handleConn(conn)
+		close(done)
+	}()
+
+	messages := forwardMessages(t, "app.web")
+	for i, mode := range []string{"Message", "Forward", "PackedForward", "CompressedPackedForward"} {
+		client.SetDeadline(time.Now().Add(5 * time.Second))
+		if _, err := client.Write(messages[mode]); err != nil {
+			t.Fatalf("%s: write: %v", mode, err)
+		}
+		ack, err := newMsgpackDecoder(client).Decode()
+		if err != nil {
+			t.Fatalf("%s: reading ack: %v", mode, err)
+		}
+		chunk := "c" + string(rune('1'+i))
+		if got, _ := ack.(map[string]interface{}); got["ack"] != chunk {
+			t.Errorf("%s: ack = %v, want %s", mode, ack, chunk)
+		}
+	}
+	client.Close()
+	<-done
+
+	stream := registry.Get(sources.StreamID(ForwardKind, "app.web"))
+	if stream == nil {
+		t.Fatal("no stream for tag app.web")
+	}
+	if n := len(stream.Recent(100)); n != 7 {
+		t.Errorf("stream has %d lines, want 7", n)
+	}
+}
+
+func TestForwardTruncated(t *testing.T) {
+	for mode, data := range forwardMessages(t, "app.web") {
+		for n := 0; n < len(data); n++ {
+			if _, _, _, err := decodeForward(data[:n]); err == nil {
+				t.Errorf("%s truncated to %d of %d bytes decoded without error", mode, n, len(data))
+			}
+		}
+	}
+
+	// Truncated packed entries inside a complete message
+	entries := msgpackEntry(nil, testEventTime, "packed one")
+	for _, compressed := range []bool{false, true} {
+		data := entries[:len(entries)-3]
+		if compressed {
+			data = gzipBytes(t, data)
+		}
+		if _, err := decodePackedEntries(data, compressed); err == nil {
+			t.Errorf("truncated packed entries (compressed %v) decoded without error", compressed)
+		}
+	}
+}
+
+func TestForwardHostile(t *testing.T) {
+	cases := map[string][]byte{
+		"deep nesting":     append(bytes.Repeat([]byte{0x91}, maxMsgpackDepth+1), 0x01),
+		"huge string":      {0xdb, 0xff, 0xff, 0xff, 0xff},
+		"huge array":       {0xdd, 0xff, 0xff, 0xff, 0xff},
+		"huge map":         {0xdf, 0x7f, 0xff, 0xff, 0xff},
+		"invalid type":     {0xc1},
+		"array of garbage": {0x92, 0xa3, 't', 'a', 'g', 0xc1},
+		"missing record":   {0x92, 0xa3, 't', 'a', 'g', 0x01},
+		"record not a map": {0x93, 0xa3, 't', 'a', 'g', 0x01, 0x02},
+		"tag not a string": {0x93, 0x01, 0x01, 0x80},
+	}
+	for name, data := range cases {
+		if _, _, _, err := decodeForward(data); err == nil {
+			t.Errorf("%s: decoded without error", name)
+		}
+	}
+
+	// Nesting up to the limit is fine
+	nested := append(bytes.Repeat([]byte{0x91}, maxMsgpackDepth), 0x01)
+	if _, err := newMsgpackDecoder(bytes.NewReader(nested)).Decode(); err != nil {
+		t.Errorf("nesting to depth %d: %v", maxMsgpackDepth, err)
+	}
+
+	// A gzip bomb is cut off at the unpacked size limit
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	zeros := make([]byte, 1<<20)
+	for written := 0; written <= maxForwardUnpackedSize; written += len(zeros) {
+		gz.Write(zeros)
+	}
+	gz.Close()
+	_, err := decodePackedEntries(buf.Bytes(), true)
+	if err == nil || !strings.Contains(err.Error(), "exceed") {
+		t.Errorf("gzip bomb: err = %v, want a size limit error", err)
+	}
+
+	if _, err := decodePackedEntries([]byte("not gzip"), true); err == nil {
+		t.Error("invalid gzip decoded without error")
+	}
+}
@@ -0,0 +1,241 @@
+package ingest
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// maxMsgpackSize limits the size of a single string, binary or container
+// so a corrupt length prefix can't exhaust memory
+const maxMsgpackSize = 64 << 20
+
+// maxMsgpackDepth limits how deeply arrays and maps may nest
+const maxMsgpackDepth = 64
+
+// msgpackExt is a MessagePack extension value
+type msgpackExt struct {
+	Type int8
+	Data []byte
+}
+
+// msgpackDecoder decodes MessagePack values from a stream. Values are
+// returned as nil, bool, int64, uint64, float64, string, []byte,
+// []interface{}, map[string]interface{} or msgpackExt.
+type msgpackDecoder struct {
+	r *bufio.Reader
+}
+
+// newMsgpackDecoder creates a decoder reading from r
+func newMsgpackDecoder(r io.Reader) *msgpackDecoder {
+	if br, ok := r.(*bufio.Reader); ok {
+		return &msgpackDecoder{r: br}
+	}
+	return &msgpackDecoder{r: bufio.NewReader(r)}
+}
+
+// Decode reads the next value from the stream
+func (d *msgpackDecoder) Decode() (interface{}, error) {
+	return d.decode(0)
+}
+
+// decode reads a value nested in depth arrays and maps
+func (d *msgpackDecoder) decode(depth int) (interface{}, error) {
+	if depth > maxMsgpackDepth {
+		return nil, fmt.Errorf("msgpack: nesting exceeds depth %d", maxMsgpackDepth)
+	}
+
+	b, err := d.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case b <= 0x7f:
+		return int64(b), nil
+	case b >= 0xe0:
+		return int64(int8(b)), nil
+	case b&0xf0 == 0x80:
+		return d.decodeMap(int(b&0x0f), depth)
+	case b&0xf0 == 0x90:
+		return d.decodeArray(int(b&0x0f), depth)
+	case b&0xe0 == 0xa0:
+		return d.readString(int(b & 0x1f))
+	}
+
+	switch b {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.readLength(b - 0xc4)
+		if err != nil {
+			return nil, err
+		}
+		return d.readBytes(n)
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.readLength(b - 0xc7)
+		if err != nil {
+			return nil, err
+		}
+		return d.readExt(n)
+	case 0xca:
+		v, err := d.readUint(4)
+		return float64(math.Float32frombits(uint32(v))), err
+	case 0xcb:
+		v, err := d.readUint(8)
+		return math.Float64frombits(v), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		v, err := d.readUint(1 << (b - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		if v > math.MaxInt64 {
+			return v, nil
+		}
+		return int64(v), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (b - 0xd0)
+		v, err := d.readUint(size)
+		if err != nil {
+			return nil, err
+		}
+		// Sign-extend from the encoded width
+		shift := uint(64 - 8*size)
+		return int64(v<<shift) >> shift, nil
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.readExt(1 << (b - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.readLength(b - 0xd9)
+		if err != nil {
+			return nil, err
+		}
+		return d.readString(n)
+	case 0xdc, 0xdd:
+		n, err := d.readLength(b - 0xdc + 1)
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeArray(n, depth)
+	case 0xde, 0xdf:
+		n, err := d.readLength(b - 0xde + 1)
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeMap(n, depth)
+	}
+
+	return nil, fmt.Errorf("msgpack: invalid type byte 0x%02x", b)
+}
+
+// readUint reads a big-endian unsigned integer of size bytes
+func (d *msgpackDecoder) readUint(size int) (uint64, error) {
+	buf := make([]byte, 8)
+	if _, err := io.ReadFull(d.r, buf[8-size:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(buf), nil
+}
+
+// readLength reads a length prefix of 1, 2 or 4 bytes (width 0, 1 or 2)
+func (d *msgpackDecoder) readLength(width byte) (int, error) {
+	v, err := d.readUint(1 << width)
+	if err != nil {
+		return 0, err
+	}
+	if v > maxMsgpackSize {
+		return 0, fmt.Errorf("msgpack: length %d exceeds limit", v)
+	}
+	return int(v), nil
+}
+
+// readBytes reads n raw bytes
+func (d *msgpackDecoder) readBytes(n int) ([]byte, error) {
+	buf := make([]byte, n)
+	_, err := io.ReadFull(d.r, buf)
+	return buf, err
+}
+
+// readString reads a string of n bytes
+func (d *msgpackDecoder) readString(n int) (string, error) {
+	buf, err := d.readBytes(n)
+	return string(buf), err
+}
+
+// readExt reads an extension type byte followed by n bytes of data
+func (d *msgpackDecoder) readExt(n int) (msgpackExt, error) {
+	t, err := d.r.ReadByte()
+	if err != nil {
+		return msgpackExt{}, err
+	}
+	data, err := d.readBytes(n)
+	return msgpackExt{Type: int8(t), Data: data}, err
+}
+
+// decodeArray reads n values of an array nested at depth
+func (d *msgpackDecoder) decodeArray(n, depth int) ([]interface{}, error) {
+	values := make([]interface{}, 0, min(n, 1024))
+	for i := 0; i < n; i++ {
+		v, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// decodeMap reads n key/value pairs of a map nested at depth; non-string
+// keys are formatted as text
+func (d *msgpackDecoder) decodeMap(n, depth int) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, min(n, 1024))
+	for i := 0; i < n; i++ {
+		k, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		switch key := k.(type) {
+		case string:
+			values[key] = v
+		case []byte:
+			values[string(key)] = v
+		default:
+			values[fmt.Sprint(key)] = v
+		}
+	}
+	return values, nil
+}
+
+// appendMsgpackMap encodes a map of string values (used for acks)
+func appendMsgpackMap(buf []byte, m map[string]string) []byte {
+	buf = append(buf, 0x80|byte(len(m)))
+	for k, v := range m {
+		buf = appendMsgpackString(buf, k)
+		buf = appendMsgpackString(buf, v)
+	}
+	return buf
+}
+
+// appendMsgpackString encodes a string
+func appendMsgpackString(buf []byte, s string) []byte {
+	switch n := len(s); {
+	case n < 32:
+		buf = append(buf, 0xa0|byte(n))
+	case n < 1<<8:
+		buf = append(buf, 0xd9, byte(n))
+	case n < 1<<16:
+		buf = append(buf, 0xda, byte(n>>8), byte(n))
+	default:
+		buf = append(buf, 0xdb, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+	return append(buf, s...)
+}
@@ -0,0 +1,370 @@
+package ingest
+
+import (
+	"compress/gzip"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/weblogview/internal/logline"
+	"github.com/yourusername/weblogview/internal/sources"
+)
+
+// OTLPKind is the stream kind used for OpenTelemetry log sources
+const OTLPKind = "otlp"
+
+// maxOTLPBodySize limits the size of a single (decompressed) export request
+const maxOTLPBodySize = 32 << 20
+
+// otlpRecord is a decoded OTLP LogRecord together with its resource and scope
+type otlpRecord struct {
+	Resource       map[string]string
+	Scope          string
+	TimeUnixNano   uint64
+	ObservedNano   uint64
+	SeverityNumber int
+	SeverityText   string
+	Body           string
+	Attributes     map[string]string
+	TraceID        []byte
+	SpanID         []byte
+}
+
+// OTLPHandler receives OTLP/HTTP log exports (POST /v1/logs) in protobuf or
+// JSON encoding and appends them to one stream per service.name
+type OTLPHandler struct {
+	registry *sources.Registry
+}
+
+// NewOTLPHandler creates an OTLP/HTTP logs receiver
+func NewOTLPHandler(registry *sources.Registry) *OTLPHandler {
+	return &OTLPHandler{registry: registry}
+}
+
+// ServeHTTP handles an ExportLogsServiceRequest
+func (h *OTLPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, "Invalid gzip body", http.StatusBadRequest)
+			return
+		}
+		defer gz.Close()
+		body = gz
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, maxOTLPBodySize+1))
+	if err != nil {
+		http.Error(w, "Failed to read body", http.StatusBadRequest)
+		return
+	}
+	if len(data) > maxOTLPBodySize {
+		http.Error(w, "Request too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	contentType, _, _ := strings.Cut(r.Header.Get("Content-Type"), ";")
+	isJSON := strings.TrimSpace(contentType) == "application/json"
+
+	var records []otlpRecord
+	if isJSON {
+		records, err = decodeOTLPJSON(data)
+	} else {
+		records, err = decodeOTLPProto(data)
+	}
+	if err != nil {
+		log.Printf("OTLP decode error: %v", err)
+		http.Error(w, "Invalid OTLP payload: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.ingest(records)
+
+	// Respond with an empty ExportLogsServiceResponse in the request encoding
+	if isJSON {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	} else {
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// ingest groups records by service and appends them to their streams
+func (h *OTLPHandler) ingest(records []otlpRecord) {
+	byService := map[string][]logline.Line{}
+	order := []string{}
+	for _, rec := range records {
+		service := rec.Resource["service.name"]
+		if service == "" {
+			service = "unknown_service"
+		}
+		if _, ok := byService[service]; !ok {
+			order = append(order, service)
+		}
+		byService[service] = append(byService[service], rec.line())
+	}
+
+	for _, service := range order {
+		h.registry.GetOrCreate(OTLPKind, service).Append(byService[service])
+	}
+}
+
+// line converts a record into a log line with its metadata as fields
+func (rec otlpRecord) line() logline.Line {
+	ts := rec.TimeUnixNano
+	if ts == 0 {
+		ts = rec.ObservedNano
+	}
+
+	severity := rec.SeverityText
+	if severity == "" {
+		severity = otlpSeverityName(rec.SeverityNumber)
+	}
+
+	var text strings.Builder
+	var t time.Time
+	if ts > 0 {
+		t = time.Unix(0, int64(ts))
+		text.WriteString(t.UTC().Format("2006-01-02T15:04:05.000Z07:00"))
+		text.WriteByte(' ')
+	}
+	if severity != "" {
+		text.WriteString(strings.ToUpper(severity))
+		text.WriteByte(' ')
+	}
+	text.WriteString(rec.Body)
+
+	line := logline.New(text.String())
+	line.SetTime(t)
+	line.Level = otlpLevel(rec.SeverityNumber, rec.SeverityText)
+	line.Fields = map[string]string{"body": rec.Body}
+	if severity != "" {
+		line.Fields["severity"] = severity
+	}
+	if len(rec.TraceID) > 0 {
+		line.Fields["trace_id"] = hex.EncodeToString(rec.TraceID)
+	}
+	if len(rec.SpanID) > 0 {
+		line.Fields["span_id"] = hex.EncodeToString(rec.SpanID)
+	}
+	if rec.Scope != "" {
+		line.Fields["scope"] = rec.Scope
+	}
+	for k, v := range rec.Resource {
+		line.Fields["resource."+k] = v
+	}
+	for k, v := range rec.Attributes {
+		line.Fields[k] = v
+	}
+
+	return line
+}
+
+// otlpLevel maps an OTLP severity to a log level name
+func otlpLevel(number int, text string) string {
+	switch {
+	case number >= 21:
+		return "fatal"
+	case number >= 17:
+		return "error"
+	case number >= 13:
+		return "warn"
+	case number >= 9:
+		return "info"
+	case number >= 5:
+		return "debug"
+	case number >= 1:
+		return "trace"
+	}
+	return strings.ToLower(text)
+}
+
+// otlpSeverityName returns the short name of a severity number
+func otlpSeverityName(number int) string {
+	if level := otlpLevel(number, ""); level != "" {
+		return strings.ToUpper(level)
+	}
+	return ""
+}
+
+// decodeOTLPJSON decodes the JSON encoding of ExportLogsServiceRequest
+func decodeOTLPJSON(data []byte) ([]otlpRecord, error) {
+	var req struct {
+		ResourceLogs []struct {
+			Resource struct {
+				Attributes []otlpJSONKeyValue `json:"attributes"`
+			} `json:"resource"`
+			ScopeLogs []struct {
+				Scope struct {
+					Name string `json:"name"`
+				} `json:"scope"`
+				LogRecords []struct {
+					TimeUnixNano         json.Number        `json:"timeUnixNano"`
+					ObservedTimeUnixNano json.Number        `json:"observedTimeUnixNano"`
+					SeverityNumber       int                `json:"severityNumber"`
+					SeverityText         string             `json:"severityText"`
+					Body                 otlpJSONAnyValue   `json:"body"`
+					Attributes           []otlpJSONKeyValue `json:"attributes"`
+					TraceID              string             `json:"traceId"`
+					SpanID               string             `json:"spanId"`
+				} `json:"logRecords"`
+			} `json:"scopeLogs"`
+		} `json:"resourceLogs"`
+	}
+
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, err
+	}
+
+	records := []otlpRecord{}
+	for _, rl := range req.ResourceLogs {
+		resource, err := otlpJSONAttributes(rl.Resource.Attributes)
+		if err != nil {
+			return nil, err
+		}
+		for _, sl := range rl.ScopeLogs {
+			for _, lr := range sl.LogRecords {
+				body, err := lr.Body.text()
+				if err != nil {
+					return nil, err
+				}
+				attrs, err := otlpJSONAttributes(lr.Attributes)
+				if err != nil {
+					return nil, err
+				}
+				rec := otlpRecord{
+					Resource:       resource,
+					Scope:          sl.Scope.Name,
+					SeverityNumber: lr.SeverityNumber,
+					SeverityText:   lr.SeverityText,
+					Body:           body,
+					Attributes:     attrs,
+				}
+				rec.TimeUnixNano, _ = strconv.ParseUint(lr.TimeUnixNano.String(), 10, 64)
+				rec.ObservedNano, _ = strconv.ParseUint(lr.ObservedTimeUnixNano.String(), 10, 64)
+				// The JSON encoding uses hex for trace and span IDs
+				rec.TraceID, _ = hex.DecodeString(lr.TraceID)
+				rec.SpanID, _ = hex.DecodeString(lr.SpanID)
+				records = append(records, rec)
+			}
+		}
+	}
+
+	return records, nil
+}
+
+// otlpJSONKeyValue is a KeyValue in the OTLP JSON encoding
+type otlpJSONKeyValue struct {
+	Key   string           `json:"key"`
+	Value otlpJSONAnyValue `json:"value"`
+}
+
+// otlpJSONAnyValue is an AnyValue in the OTLP JSON encoding
+type otlpJSONAnyValue struct {
+	StringValue *string      `json:"stringValue"`
+	BoolValue   *bool        `json:"boolValue"`
+	IntValue    *json.Number `json:"intValue"`
+	DoubleValue *json.Number `json:"doubleValue"`
+	BytesValue  *string      `json:"bytesValue"`
+	ArrayValue  *struct {
+		Values []otlpJSONAnyValue `json:"values"`
+	} `json:"arrayValue"`
+	KvlistValue *struct {
+		Values []otlpJSONKeyValue `json:"values"`
+	} `json:"kvlistValue"`
+}
+
+// value converts the AnyValue into a plain Go value; depth counts the
+// arrays and maps it is nested in
+func (v otlpJSONAnyValue) value(depth int) (interface{}, error) {
+	if depth > maxAnyValueDepth {
+		return nil, errValueTooDeep
+	}
+
+	switch {
+	case v.StringValue != nil:
+		return *v.StringValue, nil
+	case v.BoolValue != nil:
+		return *v.BoolValue, nil
+	case v.IntValue != nil:
+		return *v.IntValue, nil
+	case v.DoubleValue != nil:
+		return *v.DoubleValue, nil
+	case v.BytesValue != nil:
+		return *v.BytesValue, nil
+	case v.ArrayValue != nil:
+		values := make([]interface{}, 0, len(v.ArrayValue.Values))
+		for _, item := range v.ArrayValue.Values {
+			value, err := item.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	case v.KvlistValue != nil:
+		values := make(map[string]interface{}, len(v.KvlistValue.Values))
+		for _, kv := range v.KvlistValue.Values {
+			value, err := kv.Value.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			values[kv.Key] = value
+		}
+		return values, nil
+	}
+	return nil, nil
+}
+
+// text renders the AnyValue as text
+func (v otlpJSONAnyValue) text() (string, error) {
+	value, err := v.value(0)
+	if err != nil {
+		return "", err
+	}
+	return otlpValueString(value), nil
+}
+
+// otlpJSONAttributes flattens attributes into a string map
+func otlpJSONAttributes(kvs []otlpJSONKeyValue) (map[string]string, error) {
+	attrs := make(map[string]string, len(kvs))
+	for _, kv := range kvs {
+		value, err := kv.Value.text()
+		if err != nil {
+			return nil, err
+		}
+		attrs[kv.Key] = value
+	}
+	return attrs, nil
+}
+
+// otlpValueString renders a decoded AnyValue as text; arrays and maps
+// are rendered as JSON
+func otlpValueString(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case json.Number:
+		return val.String()
+	case []interface{}, map[string]interface{}:
+		data, _ := json.Marshal(val)
+		return string(data)
+	default:
+		return fmt.Sprint(val)
+	}
+}
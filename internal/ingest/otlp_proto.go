@@ -0,0 +1,299 @@
+package ingest
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// OTLP protobuf field numbers (opentelemetry/proto/logs/v1, common/v1)
+const (
+	fieldExportResourceLogs = 1
+
+	fieldResourceLogsResource  = 1
+	fieldResourceLogsScopeLogs = 2
+
+	fieldResourceAttributes = 1
+
+	fieldScopeLogsScope      = 1
+	fieldScopeLogsLogRecords = 2
+
+	fieldScopeName = 1
+
+	fieldLogTimeUnixNano     = 1
+	fieldLogSeverityNumber   = 2
+	fieldLogSeverityText     = 3
+	fieldLogBody             = 5
+	fieldLogAttributes       = 6
+	fieldLogTraceID          = 9
+	fieldLogSpanID           = 10
+	fieldLogObservedUnixNano = 11
+
+	fieldKeyValueKey   = 1
+	fieldKeyValueValue = 2
+
+	fieldAnyString = 1
+	fieldAnyBool   = 2
+	fieldAnyInt    = 3
+	fieldAnyDouble = 4
+	fieldAnyArray  = 5
+	fieldAnyKvlist = 6
+	fieldAnyBytes  = 7
+
+	fieldListValues = 1
+)
+
+// maxAnyValueDepth limits how deeply AnyValue arrays and maps may nest
+const maxAnyValueDepth = 64
+
+var errValueTooDeep = errors.New("value nested too deeply")
+
+// protoField is a single decoded protobuf field
+type protoField struct {
+	num   protowire.Number
+	typ   protowire.Type
+	value uint64 // Varint, fixed32 and fixed64 values
+	bytes []byte // Length-delimited values
+}
+
+// walkProto calls fn for each top-level field of a protobuf message
+func walkProto(data []byte, fn func(protoField) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		field := protoField{num: num, typ: typ}
+		switch typ {
+		case protowire.VarintType:
+			field.value, n = protowire.ConsumeVarint(data)
+		case protowire.Fixed32Type:
+			var v uint32
+			v, n = protowire.ConsumeFixed32(data)
+			field.value = uint64(v)
+		case protowire.Fixed64Type:
+			field.value, n = protowire.ConsumeFixed64(data)
+		case protowire.BytesType:
+			field.bytes, n = protowire.ConsumeBytes(data)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		if err := fn(field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeOTLPProto decodes the protobuf encoding of ExportLogsServiceRequest
+func decodeOTLPProto(data []byte) ([]otlpRecord, error) {
+	records := []otlpRecord{}
+
+	err := walkProto(data, func(f protoField) error {
+		if f.num != fieldExportResourceLogs || f.typ != protowire.BytesType {
+			return nil
+		}
+		return decodeResourceLogs(f.bytes, &records)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid protobuf: %w", err)
+	}
+
+	return records, nil
+}
+
+// decodeResourceLogs decodes a ResourceLogs message
+func decodeResourceLogs(data []byte, records *[]otlpRecord) error {
+	resource := map[string]string{}
+	scopeLogs := [][]byte{}
+
+	// The resource may appear after the scope logs, so collect those first
+	err := walkProto(data, func(f protoField) error {
+		if f.typ != protowire.BytesType {
+			return nil
+		}
+		switch f.num {
+		case fieldResourceLogsResource:
+			return walkProto(f.bytes, func(rf protoField) error {
+				if rf.num == fieldResourceAttributes && rf.typ == protowire.BytesType {
+					return decodeKeyValue(rf.bytes, resource)
+				}
+				return nil
+			})
+		case fieldResourceLogsScopeLogs:
+			scopeLogs = append(scopeLogs, f.bytes)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, sl := range scopeLogs {
+		if err := decodeScopeLogs(sl, resource, records); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeScopeLogs decodes a ScopeLogs message
+func decodeScopeLogs(data []byte, resource map[string]string, records *[]otlpRecord) error {
+	scope := ""
+	logRecords := [][]byte{}
+
+	err := walkProto(data, func(f protoField) error {
+		if f.typ != protowire.BytesType {
+			return nil
+		}
+		switch f.num {
+		case fieldScopeLogsScope:
+			return walkProto(f.bytes, func(sf protoField) error {
+				if sf.num == fieldScopeName && sf.typ == protowire.BytesType {
+					scope = string(sf.bytes)
+				}
+				return nil
+			})
+		case fieldScopeLogsLogRecords:
+			logRecords = append(logRecords, f.bytes)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, lr := range logRecords {
+		rec := otlpRecord{Resource: resource, Scope: scope, Attributes: map[string]string{}}
+		if err := decodeLogRecord(lr, &rec); err != nil {
+			return err
+		}
+		*records = append(*records, rec)
+	}
+	return nil
+}
+
+// decodeLogRecord decodes a LogRecord message
+func decodeLogRecord(data []byte, rec *otlpRecord) error {
+	return walkProto(data, func(f protoField) error {
+		switch f.num {
+		case fieldLogTimeUnixNano:
+			rec.TimeUnixNano = f.value
+		case fieldLogObservedUnixNano:
+			rec.ObservedNano = f.value
+		case fieldLogSeverityNumber:
+			rec.SeverityNumber = int(f.value)
+		case fieldLogSeverityText:
+			rec.SeverityText = string(f.bytes)
+		case fieldLogBody:
+			v, err := decodeAnyValue(f.bytes, 0)
+			if err != nil {
+				return err
+			}
+			rec.Body = otlpValueString(v)
+		case fieldLogAttributes:
+			return decodeKeyValue(f.bytes, rec.Attributes)
+		case fieldLogTraceID:
+			rec.TraceID = f.bytes
+		case fieldLogSpanID:
+			rec.SpanID = f.bytes
+		}
+		return nil
+	})
+}
+
+// decodeKeyValue decodes a KeyValue message into attrs
+func decodeKeyValue(data []byte, attrs map[string]string) error {
+	key, value, err := decodeKeyValuePair(data, 0)
+	if err != nil {
+		return err
+	}
+	attrs[key] = otlpValueString(value)
+	return nil
+}
+
+// decodeKeyValuePair decodes a KeyValue message whose value sits at the
+// given nesting depth
+func decodeKeyValuePair(data []byte, depth int) (string, interface{}, error) {
+	var key string
+	var value interface{}
+
+	err := walkProto(data, func(f protoField) error {
+		switch f.num {
+		case fieldKeyValueKey:
+			key = string(f.bytes)
+		case fieldKeyValueValue:
+			v, err := decodeAnyValue(f.bytes, depth)
+			if err != nil {
+				return err
+			}
+			value = v
+		}
+		return nil
+	})
+
+	return key, value, err
+}
+
+// decodeAnyValue decodes an AnyValue message into a plain Go value; depth
+// counts the arrays and maps it is nested in
+func decodeAnyValue(data []byte, depth int) (interface{}, error) {
+	if depth > maxAnyValueDepth {
+		return nil, errValueTooDeep
+	}
+
+	var value interface{}
+
+	err := walkProto(data, func(f protoField) error {
+		switch f.num {
+		case fieldAnyString:
+			value = string(f.bytes)
+		case fieldAnyBool:
+			value = f.value != 0
+		case fieldAnyInt:
+			value = strconv.FormatInt(int64(f.value), 10)
+		case fieldAnyDouble:
+			value = strconv.FormatFloat(math.Float64frombits(f.value), 'g', -1, 64)
+		case fieldAnyBytes:
+			value = base64.StdEncoding.EncodeToString(f.bytes)
+		case fieldAnyArray:
+			values := []interface{}{}
+			err := walkProto(f.bytes, func(af protoField) error {
+				if af.num != fieldListValues {
+					return nil
+				}
+				v, err := decodeAnyValue(af.bytes, depth+1)
+				values = append(values, v)
+				return err
+			})
+			value = values
+			return err
+		case fieldAnyKvlist:
+			values := map[string]interface{}{}
+			err := walkProto(f.bytes, func(kf protoField) error {
+				if kf.num != fieldListValues {
+					return nil
+				}
+				k, v, err := decodeKeyValuePair(kf.bytes, depth+1)
+				values[k] = v
+				return err
+			})
+			value = values
+			return err
+		}
+		return nil
+	})
+
+	return value, err
+}
@@ -0,0 +1,188 @@
+package ingest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/yourusername/weblogview/internal/logline"
+	"github.com/yourusername/weblogview/internal/sources"
+)
+
+// protoBytes appends a length-delimited field
+func protoBytes(b []byte, num protowire.Number, value []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, value)
+}
+
+// protoVarint appends a varint field
+func protoVarint(b []byte, num protowire.Number, value uint64) []byte {
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, value)
+}
+
+// protoString builds a string AnyValue
+func protoString(s string) []byte {
+	return protoBytes(nil, fieldAnyString, []byte(s))
+}
+
+// protoKeyValue builds a KeyValue message
+func protoKeyValue(key string, value []byte) []byte {
+	b := protoBytes(nil, fieldKeyValueKey, []byte(key))
+	return protoBytes(b, fieldKeyValueValue, value)
+}
+
+// protoResourceLogs builds a ResourceLogs message holding one log record
+func protoResourceLogs(service, body string, traceID, spanID []byte) []byte {
+	resource := protoBytes(nil, fieldResourceAttributes, protoKeyValue("service.name", protoString(service)))
+
+	record := protoVarint(nil, fieldLogTimeUnixNano, 1700000000000000000)
+	record = protoVarint(record, fieldLogSeverityNumber, 17)
+	record = protoBytes(record, fieldLogBody, protoString(body))
+	record = protoBytes(record, fieldLogAttributes, protoKeyValue("http.method", protoString("GET")))
+	record = protoBytes(record, fieldLogTraceID, traceID)
+	record = protoBytes(record, fieldLogSpanID, spanID)
+
+	scopeLogs := protoBytes(nil, fieldScopeLogsScope, protoBytes(nil, fieldScopeName, []byte("app")))
+	scopeLogs = protoBytes(scopeLogs, fieldScopeLogsLogRecords, record)
+
+	rl := protoBytes(nil, fieldResourceLogsResource, resource)
+	return protoBytes(rl, fieldResourceLogsScopeLogs, scopeLogs)
+}
+
+// post sends an export request to a handler and returns the registry
+func post(t *testing.T, contentType string, body []byte) (*sources.Registry, int) {
+	t.Helper()
+	registry := sources.NewRegistry(100)
+	req := httptest.NewRequest("POST", "/v1/logs", bytes.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	rec := httptest.NewRecorder()
+	NewOTLPHandler(registry).ServeHTTP(rec, req)
+	return registry, rec.Code
+}
+
+// streamLines returns the lines buffered for an OTLP service
+func streamLines(t *testing.T, registry *sources.Registry, service string) []logline.Line {
+	t.Helper()
+	stream := registry.Get(sources.StreamID(OTLPKind, service))
+	if stream == nil {
+		t.Fatalf("no stream for service %q", service)
+	}
+	return stream.Recent(100)
+}
+
+// checkExport checks the streams created by the two-service export
+func checkExport(t *testing.T, registry *sources.Registry) {
+	t.Helper()
+	if n := len(registry.List()); n != 2 {
+		t.Fatalf("got %d streams, want 2", n)
+	}
+
+	checkout := streamLines(t, registry, "checkout")
+	if len(checkout) != 2 {
+		t.Fatalf("checkout has %d lines, want 2", len(checkout))
+	}
+	line := checkout[0]
+	if line.Level != "error" || !strings.Contains(line.Text, "payment failed") {
+		t.Errorf("line = %q level %q, want an error about the payment", line.Text, line.Level)
+	}
+	want := map[string]string{
+		"body":                  "payment failed",
+		"trace_id":              "0102030405060708090a0b0c0d0e0f10",
+		"span_id":               "a1a2a3a4a5a6a7a8",
+		"scope":                 "app",
+		"resource.service.name": "checkout",
+		"http.method":           "GET",
+	}
+	for k, v := range want {
+		if line.Fields[k] != v {
+			t.Errorf("field %s = %q, want %q", k, line.Fields[k], v)
+		}
+	}
+
+	cart := streamLines(t, registry, "cart")
+	if len(cart) != 1 || cart[0].Fields["body"] != "cart emptied" {
+		t.Errorf("cart lines = %+v, want one line about the cart", cart)
+	}
+}
+
+var (
+	testTraceID = []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	testSpanID  = []byte{0xa1, 0xa2, 0xa3, 0xa4, 0xa5, 0xa6, 0xa7, 0xa8}
+)
+
+func TestOTLPProtoExport(t *testing.T) {
+	var req []byte
+	req = protoBytes(req, fieldExportResourceLogs, protoResourceLogs("checkout", "payment failed", testTraceID, testSpanID))
+	req = protoBytes(req, fieldExportResourceLogs, protoResourceLogs("cart", "cart emptied", testTraceID, testSpanID))
+	req = protoBytes(req, fieldExportResourceLogs, protoResourceLogs("checkout", "payment retried", testTraceID, testSpanID))
+
+	registry, code := post(t, "application/x-protobuf", req)
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", code)
+	}
+	checkExport(t, registry)
+}
+
+func TestOTLPJSONExport(t *testing.T) {
+	resourceLogs := func(service, body string) string {
+		return `{
+			"resource": {"attributes": [{"key": "service.name", "value": {"stringValue": "` + service + `"}}]},
+			"scopeLogs": [{
+				"scope": {"name": "app"},
+				"logRecords": [{
+					"timeUnixNano": "1700000000000000000",
+					"severityNumber": 17,
+					"body": {"stringValue": "` + body + `"},
+					"attributes": [{"key": "http.method", "value": {"stringValue": "GET"}}],
+					"traceId": "0102030405060708090a0b0c0d0e0f10",
+					"spanId": "a1a2a3a4a5a6a7a8"
+				}]
+			}]
+		}`
+	}
+	req := `{"resourceLogs": [` +
+		resourceLogs("checkout", "payment failed") + `,` +
+		resourceLogs("cart", "cart emptied") + `,` +
+		resourceLogs("checkout", "payment retried") + `]}`
+
+	registry, code := post(t, "application/json; charset=utf-8", []byte(req))
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", code)
+	}
+	checkExport(t, registry)
+}
+
+func TestOTLPNestedValues(t *testing.T) {
+	// A body of nested arrays, depth levels deep
+	nested := func(depth int) []byte {
+		value := protoString("leaf")
+		for i := 0; i < depth; i++ {
+			value = protoBytes(nil, fieldAnyArray, protoBytes(nil, fieldListValues, value))
+		}
+		return value
+	}
+
+	v, err := decodeAnyValue(nested(3), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := otlpValueString(v); got != `[[["leaf"]]]` {
+		t.Errorf("nested value = %s", got)
+	}
+
+	if _, err := decodeAnyValue(nested(maxAnyValueDepth+1), 0); err == nil {
+		t.Error("decoding a value nested too deeply succeeded")
+	}
+
+	jsonNested := strings.Repeat(`{"arrayValue": {"values": [`, maxAnyValueDepth+1) +
+		`{"stringValue": "leaf"}` + strings.Repeat(`]}}`, maxAnyValueDepth+1)
+	req := `{"resourceLogs": [{"scopeLogs": [{"logRecords": [{"body": ` + jsonNested + `}]}]}]}`
+	if _, code := post(t, "application/json", []byte(req)); code != http.StatusBadRequest {
+		t.Errorf("deeply nested JSON status = %d, want 400", code)
+	}
+}
@@ -0,0 +1,101 @@
+package logline
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Levels lists the normalized level names from least to most severe
+var Levels = []string{"trace", "debug", "info", "warn", "error", "fatal"}
+
+// Line is a single log line together with any metadata known about it
+type Line struct {
+	Text      string            `json:"text"`
+	Timestamp int64             `json:"timestamp,omitempty"` // Unix milliseconds, 0 if unknown
+	Level     string            `json:"level,omitempty"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	Template  int               `json:"template,omitempty"` // ID of the line's message template, 0 if unknown
+}
+
+// New creates a line without metadata
+func New(text string) Line {
+	return Line{Text: text}
+}
+
+// Time returns the timestamp of the line, or the zero time if unknown
+func (l Line) Time() time.Time {
+	if l.Timestamp == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(l.Timestamp)
+}
+
+// SetTime sets the timestamp of the line
+func (l *Line) SetTime(t time.Time) {
+	if t.IsZero() {
+		l.Timestamp = 0
+		return
+	}
+	l.Timestamp = t.UnixMilli()
+}
+
+// Field returns a metadata field by name. "level" is the normalized level
+// and "template" the template ID when known, and "text" falls back to the
+// line's text.
+func (l Line) Field(name string) (string, bool) {
+	if name == "level" && l.Level != "" {
+		return l.Level, true
+	}
+	if name == "template" && l.Template != 0 {
+		return strconv.Itoa(l.Template), true
+	}
+	if v, ok := l.Fields[name]; ok {
+		return v, true
+	}
+	if name == "text" {
+		return l.Text, true
+	}
+	return "", false
+}
+
+// Texts returns the raw text of each line
+func Texts(lines []Line) []string {
+	texts := make([]string, len(lines))
+	for i, line := range lines {
+		texts[i] = line.Text
+	}
+	return texts
+}
+
+// NormalizeLevel maps common level names and abbreviations to one of
+// Levels, or returns "" for unknown names
+func NormalizeLevel(name string) string {
+	switch strings.ToLower(name) {
+	case "trace", "trc", "finest", "verbose":
+		return "trace"
+	case "debug", "dbg", "fine":
+		return "debug"
+	case "info", "inf", "information", "informational", "notice":
+		return "info"
+	case "warn", "warning", "wrn":
+		return "warn"
+	case "error", "err", "eror", "severe":
+		return "error"
+	case "fatal", "crit", "critical", "panic", "alert", "emerg", "emergency":
+		return "fatal"
+	}
+	return ""
+}
+
+// LevelRank returns the position of a level in Levels (0 for trace), or
+// -1 if the name isn't a known level
+func LevelRank(name string) int {
+	level := NormalizeLevel(name)
+	for i, l := range Levels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
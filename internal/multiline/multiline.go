@@ -0,0 +1,197 @@
+package multiline
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/weblogview/internal/parser"
+)
+
+const (
+	// DefaultMaxLines caps the lines of one event; longer events are split
+	DefaultMaxLines = 500
+
+	// DefaultFlushTimeout is how long a live event waits for more lines
+	DefaultFlushTimeout = time.Second
+)
+
+// DefaultPatterns match continuation lines of Java and Python stack
+// traces and of indented wrapped messages
+var DefaultPatterns = []string{
+	`^\s+\S`,                                // Indented: "\tat com.example...", "  File ..."
+	`^Caused by: `,                          // Java chained exceptions
+	`^Traceback \(most recent call last\):`, // Python traceback header
+	`^(During handling of the above exception|The above exception was the direct cause)`,
+	`^[\w$.]+(Exception|Error)(: |$)`, // Exception header after the log message
+}
+
+// Options configures how lines are joined into events
+type Options struct {
+	// Patterns are regular expressions of lines that continue the previous
+	// event; empty means DefaultPatterns
+	Patterns []string
+
+	// Timestamp makes every line that doesn't start with a timestamp
+	// continue the previous event
+	Timestamp bool
+
+	MaxLines     int           // 0 means DefaultMaxLines
+	FlushTimeout time.Duration // 0 means DefaultFlushTimeout
+}
+
+// Grouper decides which lines continue an event. It is safe for
+// concurrent use.
+type Grouper struct {
+	patterns  []*regexp.Regexp
+	timestamp bool
+	parser    *parser.Parser
+	maxLines  int
+	timeout   time.Duration
+}
+
+// NewGrouper compiles the options. The parser detects timestamps for the
+// Timestamp option, so a source's custom layout is honored.
+func NewGrouper(opts Options, p *parser.Parser) (*Grouper, error) {
+	patterns := opts.Patterns
+	if len(patterns) == 0 {
+		patterns = DefaultPatterns
+	}
+
+	g := &Grouper{
+		timestamp: opts.Timestamp,
+		parser:    p,
+		maxLines:  opts.MaxLines,
+		timeout:   opts.FlushTimeout,
+	}
+	if g.maxLines <= 0 {
+		g.maxLines = DefaultMaxLines
+	}
+	if g.timeout <= 0 {
+		g.timeout = DefaultFlushTimeout
+	}
+
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid continuation pattern %q: %w", pattern, err)
+		}
+		g.patterns = append(g.patterns, re)
+	}
+	return g, nil
+}
+
+// Continues reports whether a line belongs to the event before it, which
+// has eventLines lines so far
+func (g *Grouper) Continues(line string, eventLines int) bool {
+	if eventLines == 0 || eventLines >= g.maxLines {
+		return false
+	}
+	for _, re := range g.patterns {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	if g.timestamp && line != "" {
+		return g.parser.Time(line).IsZero()
+	}
+	return false
+}
+
+// Group joins lines into events, each event's lines separated by "\n"
+func (g *Grouper) Group(lines []string) []string {
+	var events []string
+	var event []string
+	for _, line := range lines {
+		if !g.Continues(line, len(event)) && len(event) > 0 {
+			events = append(events, strings.Join(event, "\n"))
+			event = event[:0]
+		}
+		event = append(event, line)
+	}
+	if len(event) > 0 {
+		events = append(events, strings.Join(event, "\n"))
+	}
+	return events
+}
+
+// Aggregator joins the lines of a live source into events. An event is
+// emitted when the next one starts or, for the last event, after the
+// flush timeout passes without a continuation line.
+type Aggregator struct {
+	grouper *Grouper
+	emit    func(events []string)
+
+	mu      sync.Mutex
+	pending []string
+	timer   *time.Timer
+	stopped bool
+}
+
+// NewAggregator creates an aggregator that passes finished events to emit.
+// emit is called with the aggregator's lock held so events stay in order.
+func NewAggregator(g *Grouper, emit func(events []string)) *Aggregator {
+	return &Aggregator{grouper: g, emit: emit}
+}
+
+// Add feeds new lines to the aggregator
+func (a *Aggregator) Add(lines []string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.stopped {
+		return
+	}
+
+	var events []string
+	for _, line := range lines {
+		if !a.grouper.Continues(line, len(a.pending)) && len(a.pending) > 0 {
+			events = append(events, strings.Join(a.pending, "\n"))
+			a.pending = nil
+		}
+		a.pending = append(a.pending, line)
+	}
+	if len(events) > 0 {
+		a.emit(events)
+	}
+
+	if a.timer == nil {
+		a.timer = time.AfterFunc(a.grouper.timeout, a.Flush)
+	} else {
+		a.timer.Reset(a.grouper.timeout)
+	}
+}
+
+// Flush emits the pending event, if any
+func (a *Aggregator) Flush() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.stopped || len(a.pending) == 0 {
+		return
+	}
+	event := strings.Join(a.pending, "\n")
+	a.pending = nil
+	a.emit([]string{event})
+}
+
+// Reset drops the pending event, e.g. when a new initial window is sent
+func (a *Aggregator) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.pending = nil
+}
+
+// Stop drops the pending event and stops emitting
+func (a *Aggregator) Stop() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.stopped = true
+	a.pending = nil
+	if a.timer != nil {
+		a.timer.Stop()
+	}
+}
@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// clfRe matches the Common and Combined Log Formats, optionally
+	// followed by a request time in seconds (nginx $request_time)
+	clfRe = regexp.MustCompile(`^(?P<client>\S+) \S+ (?P<user>\S+) \[[^\]]+\] "(?P<request>(?:[^"\\]|\\.)*)" (?P<status>\d{3}) (?P<bytes>\d+|-)(?: "(?P<referrer>(?:[^"\\]|\\.)*)" "(?P<user_agent>(?:[^"\\]|\\.)*)")?(?: (?P<request_time>\d+\.\d+))?`)
+
+	// envoyRe matches Envoy's default access log format
+	envoyRe = regexp.MustCompile(`^\[[^\]]+\] "(?P<request>[^"]*)" (?P<status>\d{3}) (?P<response_flags>\S+) (?P<bytes_received>\d+) (?P<bytes>\d+) (?P<duration>\d+) (?P<upstream_time>\S+) "(?P<client>[^"]*)" "(?P<user_agent>[^"]*)" "(?P<request_id>[^"]*)" "(?P<authority>[^"]*)" "(?P<upstream_host>[^"]*)"`)
+)
+
+// AccessFields parses a Common/Combined Log Format (Apache, nginx) or
+// Envoy default format access log line into normalized fields: method,
+// path, protocol, status, bytes, latency_ms (when logged), user_agent,
+// referrer and client, plus Envoy's request_id, authority, upstream_host
+// and response_flags. Returns nil for other lines.
+func AccessFields(text string) map[string]string {
+	if len(text) < 20 || !strings.Contains(text, `"`) {
+		return nil
+	}
+
+	var fields map[string]string
+	if text[0] == '[' {
+		fields = namedCaptures(envoyRe, text)
+		if fields == nil {
+			return nil
+		}
+		fields["latency_ms"] = fields["duration"]
+		delete(fields, "duration")
+		if fields["upstream_time"] == "-" {
+			delete(fields, "upstream_time")
+		}
+	} else {
+		fields = namedCaptures(clfRe, text)
+		if fields == nil {
+			return nil
+		}
+		if seconds, err := strconv.ParseFloat(fields["request_time"], 64); err == nil {
+			fields["latency_ms"] = strconv.FormatFloat(seconds*1000, 'f', -1, 64)
+		}
+		delete(fields, "request_time")
+		if fields["bytes"] == "-" {
+			fields["bytes"] = "0"
+		}
+	}
+
+	// "GET /path HTTP/1.1"
+	request := fields["request"]
+	delete(fields, "request")
+	parts := strings.Fields(request)
+	if len(parts) >= 2 {
+		fields["method"] = parts[0]
+		fields["path"] = parts[1]
+		if len(parts) >= 3 {
+			fields["protocol"] = parts[2]
+		}
+	} else {
+		fields["request"] = request
+	}
+
+	for name, value := range fields {
+		if value == "" || (value == "-" && name != "bytes") {
+			delete(fields, name)
+		}
+	}
+	return fields
+}
+
+// namedCaptures returns the named groups of a match, or nil
+func namedCaptures(re *regexp.Regexp, text string) map[string]string {
+	m := re.FindStringSubmatchIndex(text)
+	if m == nil {
+		return nil
+	}
+	fields := map[string]string{}
+	for i, name := range re.SubexpNames() {
+		if name == "" || m[2*i] < 0 {
+			continue
+		}
+		fields[name] = text[m[2*i]:m[2*i+1]]
+	}
+	return fields
+}
@@ -0,0 +1,216 @@
+package parser
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/weblogview/internal/logline"
+)
+
+const (
+	// maxFieldDepth limits how deep nested JSON objects are flattened;
+	// deeper values are kept as JSON
+	maxFieldDepth = 4
+
+	// maxFieldSetSize limits the number of distinct fields a FieldSet tracks
+	maxFieldSetSize = 500
+
+	// maxFieldSamples is the number of distinct example values kept per field
+	maxFieldSamples = 5
+
+	// maxSampleLength truncates long example values
+	maxSampleLength = 100
+)
+
+// ExtractFields parses the fields of a JSON object line, an access log
+// line (see AccessFields) or the key=value pairs of a logfmt line. Nested
+// JSON objects are flattened with dotted keys (http.status), arrays are
+// kept as JSON and numbers, booleans and null keep their JSON spelling.
+// Returns nil when the line has no fields.
+func ExtractFields(text string) map[string]string {
+	trimmed := strings.TrimSpace(text)
+	if strings.HasPrefix(trimmed, "{") {
+		return jsonFields(trimmed)
+	}
+	if fields := AccessFields(text); fields != nil {
+		return fields
+	}
+	if strings.Contains(text, "=") {
+		return logfmtFields(text)
+	}
+	return nil
+}
+
+// jsonFields flattens the fields of a JSON object
+func jsonFields(text string) map[string]string {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(text), &object); err != nil || len(object) == 0 {
+		return nil
+	}
+	fields := make(map[string]string, len(object))
+	flattenJSON(fields, "", object, 1)
+	return fields
+}
+
+// flattenJSON adds the values of a JSON object to fields under prefix
+func flattenJSON(fields map[string]string, prefix string, object map[string]json.RawMessage, depth int) {
+	for key, raw := range object {
+		name := prefix + key
+		switch {
+		case len(raw) > 0 && raw[0] == '"':
+			var s string
+			if json.Unmarshal(raw, &s) == nil {
+				fields[name] = s
+			}
+		case len(raw) > 0 && raw[0] == '{' && depth < maxFieldDepth:
+			var nested map[string]json.RawMessage
+			if json.Unmarshal(raw, &nested) == nil {
+				flattenJSON(fields, name+".", nested, depth+1)
+			}
+		default:
+			fields[name] = string(raw)
+		}
+	}
+}
+
+// logfmtFields parses the key=value pairs of a line. Values may be
+// double-quoted with backslash escapes. Words that aren't pairs (such as a
+// leading timestamp or level) are skipped.
+func logfmtFields(text string) map[string]string {
+	var fields map[string]string
+	for i := 0; i < len(text); {
+		// Skip to the start of a word
+		if text[i] == ' ' || text[i] == '\t' {
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(text) && isKeyChar(text[i]) {
+			i++
+		}
+		if i == start || i >= len(text) || text[i] != '=' || (start > 0 && text[start-1] != ' ' && text[start-1] != '\t') {
+			// Not a key: skip the rest of the word
+			for i < len(text) && text[i] != ' ' && text[i] != '\t' {
+				i++
+			}
+			continue
+		}
+		key := text[start:i]
+		i++ // =
+
+		var value string
+		if i < len(text) && text[i] == '"' {
+			end := i + 1
+			for end < len(text) && text[end] != '"' {
+				if text[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(text) {
+				end = len(text) - 1
+			}
+			quoted := text[i : end+1]
+			if unquoted, err := strconv.Unquote(quoted); err == nil {
+				value = unquoted
+			} else {
+				value = strings.Trim(quoted, `"`)
+			}
+			i = end + 1
+		} else {
+			end := i
+			for end < len(text) && text[end] != ' ' && text[end] != '\t' {
+				end++
+			}
+			value = text[i:end]
+			i = end
+		}
+
+		if fields == nil {
+			fields = map[string]string{}
+		}
+		fields[key] = value
+	}
+	return fields
+}
+
+// isKeyChar reports whether c can be part of a logfmt key
+func isKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '_' || c == '.' || c == '-' || c == '@' || c == '/'
+}
+
+// FieldInfo describes a field seen in a source's lines
+type FieldInfo struct {
+	Name   string   `json:"name"`
+	Count  int64    `json:"count"`            // Lines that have the field
+	Values []string `json:"values,omitempty"` // A few distinct example values
+}
+
+// FieldSet collects the fields seen in lines. It is not safe for
+// concurrent use.
+type FieldSet struct {
+	fields map[string]*FieldInfo
+	lines  int64
+}
+
+// NewFieldSet creates an empty field set
+func NewFieldSet() *FieldSet {
+	return &FieldSet{fields: map[string]*FieldInfo{}}
+}
+
+// Add records the fields of a line
+func (s *FieldSet) Add(line logline.Line) {
+	s.lines++
+	for name, value := range line.Fields {
+		info := s.fields[name]
+		if info == nil {
+			if len(s.fields) >= maxFieldSetSize {
+				continue
+			}
+			info = &FieldInfo{Name: name}
+			s.fields[name] = info
+		}
+		info.Count++
+
+		if len(info.Values) < maxFieldSamples {
+			if len(value) > maxSampleLength {
+				value = value[:maxSampleLength]
+			}
+			known := false
+			for _, v := range info.Values {
+				known = known || v == value
+			}
+			if !known {
+				info.Values = append(info.Values, value)
+			}
+		}
+	}
+}
+
+// Lines returns the number of lines added
+func (s *FieldSet) Lines() int64 {
+	return s.lines
+}
+
+// List returns the fields, most common first
+func (s *FieldSet) List() []FieldInfo {
+	list := make([]FieldInfo, 0, len(s.fields))
+	for _, info := range s.fields {
+		list = append(list, FieldInfo{
+			Name:   info.Name,
+			Count:  info.Count,
+			Values: append([]string(nil), info.Values...),
+		})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
@@ -0,0 +1,141 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maxGrokDepth limits how deeply grok patterns may reference each other
+const maxGrokDepth = 10
+
+// grokRe matches a grok reference: %{NAME} or %{NAME:field}
+var grokRe = regexp.MustCompile(`%\{(\w+)(?::([\w.@\-]+))?\}`)
+
+// GrokPatterns is the built-in grok pattern library, a subset of the
+// Logstash patterns rewritten for RE2 (no lookarounds or atomic groups)
+var GrokPatterns = map[string]string{
+	// Basic types
+	"USERNAME":     `[a-zA-Z0-9._-]+`,
+	"USER":         `%{USERNAME}`,
+	"EMAILADDRESS": `[a-zA-Z0-9!#$%&'*+/=?^_{|}~.-]+@[a-zA-Z0-9.-]+`,
+	"INT":          `[+-]?[0-9]+`,
+	"BASE10NUM":    `[+-]?(?:[0-9]+(?:\.[0-9]+)?|\.[0-9]+)`,
+	"NUMBER":       `%{BASE10NUM}`,
+	"BASE16NUM":    `[+-]?(?:0x)?[0-9A-Fa-f]+`,
+	"POSINT":       `[1-9][0-9]*`,
+	"NONNEGINT":    `[0-9]+`,
+	"WORD":         `\w+`,
+	"NOTSPACE":     `\S+`,
+	"SPACE":        `\s*`,
+	"DATA":         `.*?`,
+	"GREEDYDATA":   `.*`,
+	"QUOTEDSTRING": `"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'`,
+	"UUID":         `[A-Fa-f0-9]{8}-(?:[A-Fa-f0-9]{4}-){3}[A-Fa-f0-9]{12}`,
+
+	// Networking
+	"MAC":      `(?:[A-Fa-f0-9]{2}[:-]){5}[A-Fa-f0-9]{2}`,
+	"IPV4":     `(?:(?:25[0-5]|2[0-4][0-9]|1?[0-9]?[0-9])\.){3}(?:25[0-5]|2[0-4][0-9]|1?[0-9]?[0-9])`,
+	"IPV6":     `[A-Fa-f0-9:]*:[A-Fa-f0-9:.]+(?:%\w+)?`,
+	"IP":       `%{IPV4}|%{IPV6}`,
+	"HOSTNAME": `[0-9A-Za-z][0-9A-Za-z_-]{0,62}(?:\.[0-9A-Za-z][0-9A-Za-z_-]{0,62})*\.?`,
+	"IPORHOST": `%{IP}|%{HOSTNAME}`,
+	"HOSTPORT": `%{IPORHOST}:%{POSINT}`,
+
+	// Paths and URIs
+	"UNIXPATH":     `(?:/[\w_%!$@:.,+~-]*)+`,
+	"WINPATH":      `(?:[A-Za-z]+:|\\)(?:\\[^\\?*]*)+`,
+	"PATH":         `%{UNIXPATH}|%{WINPATH}`,
+	"URIPROTO":     `[A-Za-z][A-Za-z0-9+.-]+`,
+	"URIHOST":      `%{IPORHOST}(?::%{POSINT})?`,
+	"URIPATH":      `(?:/[A-Za-z0-9$.+!*'(){},~:;=@#%&_\-]*)+`,
+	"URIPARAM":     `\?[A-Za-z0-9$.+!*'|(){},~@#%&/=:;_?\-\[\]<>]*`,
+	"URIPATHPARAM": `%{URIPATH}(?:%{URIPARAM})?`,
+	"URI":          `%{URIPROTO}://(?:%{USER}(?::[^@]*)?@)?%{URIHOST}?(?:%{URIPATHPARAM})?`,
+
+	// Dates and times
+	"MONTH":             `\b(?:[Jj]an(?:uary)?|[Ff]eb(?:ruary)?|[Mm]ar(?:ch)?|[Aa]pr(?:il)?|[Mm]ay|[Jj]un[e]?|[Jj]ul[y]?|[Aa]ug(?:ust)?|[Ss]ep(?:tember)?|[Oo]ct(?:ober)?|[Nn]ov(?:ember)?|[Dd]ec(?:ember)?)\b`,
+	"MONTHNUM":          `0?[1-9]|1[0-2]`,
+	"MONTHDAY":          `(?:0[1-9])|(?:[12][0-9])|(?:3[01])|[1-9]`,
+	"DAY":               `(?:Mon(?:day)?|Tue(?:sday)?|Wed(?:nesday)?|Thu(?:rsday)?|Fri(?:day)?|Sat(?:urday)?|Sun(?:day)?)`,
+	"YEAR":              `\d\d(?:\d\d)?`,
+	"HOUR":              `2[0123]|[01]?[0-9]`,
+	"MINUTE":            `[0-5][0-9]`,
+	"SECOND":            `(?:[0-5]?[0-9]|60)(?:[:.,][0-9]+)?`,
+	"TIME":              `%{HOUR}:%{MINUTE}(?::%{SECOND})?`,
+	"ISO8601_TIMEZONE":  `Z|[+-]%{HOUR}(?::?%{MINUTE})`,
+	"TIMESTAMP_ISO8601": `%{YEAR}-%{MONTHNUM}-%{MONTHDAY}[T ]%{HOUR}:?%{MINUTE}(?::?%{SECOND})?(?:%{ISO8601_TIMEZONE})?`,
+	"DATE_US":           `%{MONTHNUM}[/-]%{MONTHDAY}[/-]%{YEAR}`,
+	"DATE_EU":           `%{MONTHDAY}[./-]%{MONTHNUM}[./-]%{YEAR}`,
+	"DATESTAMP":         `%{DATE_EU}[- ]%{TIME}|%{DATE_US}[- ]%{TIME}`,
+	"HTTPDATE":          `%{MONTHDAY}/%{MONTH}/%{YEAR}:%{TIME} %{INT}`,
+	"SYSLOGTIMESTAMP":   `%{MONTH} +%{MONTHDAY} %{TIME}`,
+
+	// Logs
+	"LOGLEVEL":          `[Aa]lert|ALERT|[Tt]race|TRACE|[Dd]ebug|DEBUG|[Nn]otice|NOTICE|[Ii]nfo|INFO|[Ww]arn?(?:ing)?|WARN?(?:ING)?|[Ee]rr?(?:or)?|ERR?(?:OR)?|[Cc]rit?(?:ical)?|CRIT?(?:ICAL)?|[Ff]atal|FATAL|[Ss]evere|SEVERE|EMERG(?:ENCY)?|[Ee]merg(?:ency)?`,
+	"SYSLOGPROG":        `%{NOTSPACE:program}(?:\[%{POSINT:pid}\])?`,
+	"SYSLOGHOST":        `%{IPORHOST}`,
+	"SYSLOGBASE":        `%{SYSLOGTIMESTAMP:timestamp} %{SYSLOGHOST:logsource} %{SYSLOGPROG}:`,
+	"COMMONAPACHELOG":   `%{IPORHOST:clientip} %{USER:ident} %{USER:auth} \[%{HTTPDATE:timestamp}\] "(?:%{WORD:verb} %{NOTSPACE:request}(?: HTTP/%{NUMBER:httpversion})?|%{DATA:rawrequest})" %{NUMBER:response} (?:%{NUMBER:bytes}|-)`,
+	"COMBINEDAPACHELOG": `%{COMMONAPACHELOG} %{QUOTEDSTRING:referrer} %{QUOTEDSTRING:agent}`,
+}
+
+// GrokPatternNames returns the names of the built-in grok patterns
+func GrokPatternNames() []string {
+	names := make([]string, 0, len(GrokPatterns))
+	for name := range GrokPatterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// expandGrok replaces the grok references of a pattern with regular
+// expressions. Named references become capture groups named _g1, _g2, ...
+// since field names such as http.status aren't valid Go group names; the
+// returned map holds their field names.
+func expandGrok(pattern string) (string, map[string]string, error) {
+	fields := map[string]string{}
+	expanded, err := expandGrokDepth(pattern, fields, 0)
+	if err != nil {
+		return "", nil, err
+	}
+	return expanded, fields, nil
+}
+
+// expandGrokDepth expands one level of references
+func expandGrokDepth(pattern string, fields map[string]string, depth int) (string, error) {
+	if depth > maxGrokDepth {
+		return "", fmt.Errorf("grok patterns nested too deeply")
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range grokRe.FindAllStringSubmatchIndex(pattern, -1) {
+		b.WriteString(pattern[last:m[0]])
+		last = m[1]
+
+		name := pattern[m[2]:m[3]]
+		definition, ok := GrokPatterns[name]
+		if !ok {
+			return "", fmt.Errorf("unknown grok pattern %%{%s}", name)
+		}
+
+		if m[4] >= 0 {
+			group := fmt.Sprintf("_g%d", len(fields)+1)
+			fields[group] = pattern[m[4]:m[5]]
+			b.WriteString("(?P<" + group + ">")
+		} else {
+			b.WriteString("(?:")
+		}
+		inner, err := expandGrokDepth(definition, fields, depth+1)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(inner)
+		b.WriteString(")")
+	}
+	b.WriteString(pattern[last:])
+	return b.String(), nil
+}
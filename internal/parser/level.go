@@ -0,0 +1,152 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/weblogview/internal/logline"
+)
+
+// levelHeadLength is how far into a line bracketed and bare level names
+// are looked for; further in they are usually part of the message
+const levelHeadLength = 128
+
+var (
+	// jsonLevelRe finds the level key of a JSON line ("@l" is Serilog's)
+	jsonLevelRe = regexp.MustCompile(`(?i)"(?:level|severity|lvl|loglevel|log\.level|@l)"\s*:\s*"?([a-z]+|\d+)`)
+
+	// logfmtLevelRe finds a level key of a logfmt line
+	logfmtLevelRe = regexp.MustCompile(`(?i)(?:^|\s)(?:level|lvl|severity|loglevel)=("?)([a-z]+)`)
+
+	// bracketLevelRe finds a level name in brackets, e.g. [ERROR] or <warn>
+	bracketLevelRe = regexp.MustCompile(`(?i)[\[(<](trace|debug|dbg|info|inf|notice|warn|warning|wrn|error|err|fatal|crit|critical|panic|alert|emerg)[\])>]`)
+
+	// bareLevelRe finds an upper-case level word, e.g. "12:00:01 ERROR msg"
+	bareLevelRe = regexp.MustCompile(`\b(TRACE|DEBUG|INFO|NOTICE|WARN|WARNING|ERROR|FATAL|CRITICAL|PANIC)\b`)
+)
+
+// DetectLevel returns the normalized level of a line (see
+// logline.Levels), or "" if it has none. Recognized are JSON level keys
+// (including numeric pino/bunyan levels), logfmt level=, klog headers such
+// as E0612, syslog <PRI> prefixes, bracketed names like [ERROR] and
+// upper-case level words near the start of the line.
+func DetectLevel(text string) string {
+	if text == "" {
+		return ""
+	}
+
+	switch text[0] {
+	case '{':
+		if m := jsonLevelRe.FindStringSubmatch(text); m != nil {
+			if n, err := strconv.Atoi(m[1]); err == nil {
+				return bunyanLevel(n)
+			}
+			if level := logline.NormalizeLevel(m[1]); level != "" {
+				return level
+			}
+		}
+	case '<':
+		if level, ok := syslogPriorityLevel(text); ok {
+			return level
+		}
+	case 'I', 'W', 'E', 'F':
+		if level := klogLevel(text); level != "" {
+			return level
+		}
+	}
+
+	if strings.Contains(text, "=") {
+		if m := logfmtLevelRe.FindStringSubmatch(text); m != nil {
+			if level := logline.NormalizeLevel(m[2]); level != "" {
+				return level
+			}
+		}
+	}
+
+	head := text
+	if len(head) > levelHeadLength {
+		head = head[:levelHeadLength]
+	}
+	if m := bracketLevelRe.FindStringSubmatch(head); m != nil {
+		return logline.NormalizeLevel(m[1])
+	}
+	if m := bareLevelRe.FindStringSubmatch(head); m != nil {
+		return logline.NormalizeLevel(m[1])
+	}
+
+	return ""
+}
+
+// klogLevel parses the severity of a klog/glog header: Lmmdd hh:mm:ss
+func klogLevel(text string) string {
+	if len(text) < 6 || text[5] != ' ' {
+		return ""
+	}
+	for _, c := range text[1:5] {
+		if c < '0' || c > '9' {
+			return ""
+		}
+	}
+	switch text[0] {
+	case 'I':
+		return "info"
+	case 'W':
+		return "warn"
+	case 'E':
+		return "error"
+	case 'F':
+		return "fatal"
+	}
+	return ""
+}
+
+// syslogPriorityLevel parses the <PRI> prefix of a syslog message
+func syslogPriorityLevel(text string) (string, bool) {
+	end := strings.IndexByte(text, '>')
+	if end < 2 || end > 4 {
+		return "", false
+	}
+	pri, err := strconv.Atoi(text[1:end])
+	if err != nil || pri > 191 {
+		return "", false
+	}
+	return SyslogLevel(pri % 8), true
+}
+
+// SyslogLevel maps a syslog severity (0 emerg .. 7 debug) to a level name
+func SyslogLevel(severity int) string {
+	switch {
+	case severity < 0:
+		return ""
+	case severity <= 2:
+		return "fatal"
+	case severity == 3:
+		return "error"
+	case severity == 4:
+		return "warn"
+	case severity <= 6:
+		return "info"
+	default:
+		return "debug"
+	}
+}
+
+// bunyanLevel maps the numeric levels of pino and bunyan
+func bunyanLevel(n int) string {
+	switch {
+	case n >= 60:
+		return "fatal"
+	case n >= 50:
+		return "error"
+	case n >= 40:
+		return "warn"
+	case n >= 30:
+		return "info"
+	case n >= 20:
+		return "debug"
+	case n >= 10:
+		return "trace"
+	}
+	return ""
+}
@@ -0,0 +1,143 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yourusername/weblogview/internal/logline"
+)
+
+// levelFields are metadata fields that hold a level name
+var levelFields = []string{"level", "severity", "lvl", "loglevel"}
+
+// Parser fills in the metadata of lines. The zero value (and a nil
+// *Parser) detects everything automatically.
+type Parser struct {
+	// TimeLayout is a Go time layout for the timestamp at the start of
+	// each line; when it doesn't match, detection falls back to the
+	// built-in formats
+	TimeLayout string
+
+	// Pattern extracts the fields of a custom line format; lines it
+	// doesn't match fall back to JSON and logfmt extraction
+	Pattern *Pattern
+}
+
+// New creates a parser using a source specific timestamp layout, if any
+func New(timeLayout string) *Parser {
+	return &Parser{TimeLayout: timeLayout}
+}
+
+// Parse creates a log line from raw text with the metadata that can be
+// detected from the text
+func Parse(text string) logline.Line {
+	return (*Parser)(nil).Parse(text)
+}
+
+// Enrich fills in the metadata of a line that its source didn't provide:
+// fields of JSON and logfmt text, the level and the timestamp
+func Enrich(line *logline.Line) {
+	(*Parser)(nil).Enrich(line)
+}
+
+// Parse creates a log line from raw text like the package-level Parse
+func (p *Parser) Parse(text string) logline.Line {
+	line := logline.New(text)
+	p.Enrich(&line)
+	return line
+}
+
+// Enrich fills in the metadata of a line like the package-level Enrich
+func (p *Parser) Enrich(line *logline.Line) {
+	extracted := p.fields(line.Text)
+	if extracted != nil {
+		// Source fields win; the map is replaced rather than modified
+		// because lines can be shared between clients
+		for name, value := range line.Fields {
+			extracted[name] = value
+		}
+		line.Fields = extracted
+	}
+
+	if line.Level == "" {
+		for _, name := range levelFields {
+			v, ok := line.Fields[name]
+			if !ok {
+				continue
+			}
+			if n, err := strconv.Atoi(v); err == nil {
+				line.Level = bunyanLevel(n)
+			} else {
+				line.Level = logline.NormalizeLevel(v)
+			}
+			break
+		}
+	}
+	if line.Level == "" {
+		line.Level = DetectLevel(line.Text)
+	}
+
+	if line.Timestamp == 0 {
+		line.SetTime(p.Time(line.Text))
+	}
+}
+
+// fields extracts the fields of a line with the custom pattern, if any,
+// or as JSON or logfmt
+func (p *Parser) fields(text string) map[string]string {
+	if p != nil && p.Pattern != nil {
+		if fields := p.Pattern.Fields(text); fields != nil {
+			return fields
+		}
+	}
+	return ExtractFields(text)
+}
+
+// Time returns the timestamp of a line of text, or the zero time
+func (p *Parser) Time(text string) time.Time {
+	now := referenceTime()
+	if p != nil && p.TimeLayout != "" {
+		if t, ok := parseLayoutPrefix(p.TimeLayout, text, now); ok {
+			return t
+		}
+	}
+	t, _ := DetectTime(text, now)
+	return t
+}
+
+// Timestamps without a year take it from the reference time, which only
+// needs to be accurate to within a day. It is refreshed once a second
+// rather than read from the clock for every line parsed.
+var (
+	referenceOnce  sync.Once
+	referenceNanos atomic.Int64
+)
+
+// referenceTime returns the current time as of the last refresh
+func referenceTime() time.Time {
+	referenceOnce.Do(func() {
+		referenceNanos.Store(time.Now().UnixNano())
+		go func() {
+			for now := range time.Tick(time.Second) {
+				referenceNanos.Store(now.UnixNano())
+			}
+		}()
+	})
+	return time.Unix(0, referenceNanos.Load())
+}
+
+// ValidateLayout checks that a Go time layout can format and parse times
+func ValidateLayout(layout string) error {
+	now := time.Now()
+	formatted := now.Format(layout)
+	if formatted == layout {
+		return fmt.Errorf("layout %q has no time elements (use Go's reference time, e.g. 2006-01-02 15:04:05)", layout)
+	}
+	if _, err := time.ParseInLocation(layout, formatted, time.Local); err != nil {
+		return fmt.Errorf("invalid layout: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+// Pattern kinds
+const (
+	PatternGrok  = "grok"
+	PatternRegex = "regex"
+)
+
+// Pattern extracts fields from lines of a custom format with a grok
+// pattern or a regular expression with named groups
+type Pattern struct {
+	re     *regexp.Regexp
+	fields []string // Field name of each capture group, "" for unnamed groups
+}
+
+var (
+	patternCacheMu sync.Mutex
+	patternCache   = map[string]*Pattern{}
+)
+
+// CompilePattern compiles a grok pattern (kind "grok") or a Go regular
+// expression with named groups (kind "regex"). Compiled patterns are
+// cached since every source open looks them up.
+func CompilePattern(kind, pattern string) (*Pattern, error) {
+	key := kind + "\x00" + pattern
+
+	patternCacheMu.Lock()
+	cached := patternCache[key]
+	patternCacheMu.Unlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	var expr string
+	var grokFields map[string]string
+	switch kind {
+	case PatternGrok:
+		var err error
+		if expr, grokFields, err = expandGrok(pattern); err != nil {
+			return nil, err
+		}
+	case PatternRegex, "":
+		expr = pattern
+	default:
+		return nil, fmt.Errorf("unknown pattern type %q (use %q or %q)", kind, PatternGrok, PatternRegex)
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	p := &Pattern{re: re, fields: make([]string, re.NumSubexp()+1)}
+	for i, name := range re.SubexpNames() {
+		if field, ok := grokFields[name]; ok {
+			name = field
+		}
+		p.fields[i] = name
+	}
+	if !p.hasFields() {
+		return nil, fmt.Errorf("pattern has no named fields")
+	}
+
+	patternCacheMu.Lock()
+	patternCache[key] = p
+	patternCacheMu.Unlock()
+	return p, nil
+}
+
+// hasFields reports whether the pattern captures any named field
+func (p *Pattern) hasFields() bool {
+	for _, name := range p.fields {
+		if name != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Fields returns the named captures of a line, or nil if the pattern
+// doesn't match. Optional groups that didn't participate are left out.
+func (p *Pattern) Fields(text string) map[string]string {
+	if p == nil {
+		return nil
+	}
+	m := p.re.FindStringSubmatchIndex(text)
+	if m == nil {
+		return nil
+	}
+	fields := map[string]string{}
+	for i, name := range p.fields {
+		if name == "" || m[2*i] < 0 {
+			continue
+		}
+		fields[name] = text[m[2*i]:m[2*i+1]]
+	}
+	return fields
+}
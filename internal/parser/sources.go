@@ -0,0 +1,26 @@
+package parser
+
+import (
+	"log"
+
+	"github.com/yourusername/weblogview/internal/settings"
+)
+
+// ForSource creates the parser configured in settings for a source: the
+// timestamp layout stored under its key and the first custom parser
+// assigned to its file path or K8s container. A custom parser that no
+// longer compiles is logged and skipped.
+func ForSource(key, path, container string) *Parser {
+	appSettings := settings.GetInstance()
+	p := New(appSettings.GetTimestampLayout(key))
+
+	if cfg, ok := appSettings.FindParser(path, container); ok {
+		pattern, err := CompilePattern(cfg.Type, cfg.Pattern)
+		if err != nil {
+			log.Printf("Skipping parser %q: %v", cfg.Name, err)
+		} else {
+			p.Pattern = pattern
+		}
+	}
+	return p
+}
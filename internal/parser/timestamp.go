@@ -0,0 +1,208 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// isoTimeRe matches an RFC3339/ISO 8601 time, with T or a space
+	// between date and time and . or , before fractional seconds
+	isoTimeRe = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?`)
+
+	// slashTimeRe matches the nginx error log time: 2000/10/10 13:55:36
+	slashTimeRe = regexp.MustCompile(`^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}`)
+
+	// clfTimeRe matches the Apache/nginx common log time: [10/Oct/2000:13:55:36 -0700]
+	clfTimeRe = regexp.MustCompile(`\[(\d{2}/[A-Z][a-z]{2}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4})\]`)
+
+	// syslogTimeRe matches the BSD syslog time: Oct 11 22:14:15
+	syslogTimeRe = regexp.MustCompile(`^[A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}`)
+
+	// klogTimeRe matches a klog header: I0612 15:04:05.123456
+	klogTimeRe = regexp.MustCompile(`^[IWEF](\d{2})(\d{2}) (\d{2}:\d{2}:\d{2}(?:\.\d+)?)`)
+
+	// epochTimeRe matches a leading Unix time in seconds (optionally with a
+	// fraction) or milliseconds
+	epochTimeRe = regexp.MustCompile(`^(\d{13}|\d{10}(?:\.\d+)?)\b`)
+
+	// jsonTimeRe finds the time key of a JSON line
+	jsonTimeRe = regexp.MustCompile(`"(?:time|ts|timestamp|@timestamp|@t|datetime|date)"\s*:\s*(?:"([^"]+)"|(\d+(?:\.\d+)?))`)
+
+	// logfmtTimeRe finds the time key of a logfmt line
+	logfmtTimeRe = regexp.MustCompile(`(?:^|\s)(?:time|ts|timestamp)=(?:"([^"]*)"|(\S+))`)
+)
+
+// isoLayouts parse the normalized matches of isoTimeRe
+var isoLayouts = []string{
+	"2006-01-02T15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05.999999999Z0700",
+}
+
+// DetectTime finds the timestamp of a line. It recognizes RFC3339/ISO 8601
+// (optionally in brackets or after a syslog <PRI>), Apache/nginx common
+// log and nginx error log times, BSD syslog and klog headers, leading epoch
+// seconds or milliseconds, and time keys of JSON and logfmt lines. Times
+// without a zone are local; times without a year are placed in the past
+// year.
+func DetectTime(text string, now time.Time) (time.Time, bool) {
+	if text == "" {
+		return time.Time{}, false
+	}
+
+	switch text[0] {
+	case '{':
+		if m := jsonTimeRe.FindStringSubmatch(text); m != nil {
+			if m[1] != "" {
+				return parseTimeValue(m[1])
+			}
+			return parseEpoch(m[2])
+		}
+		return time.Time{}, false
+	case '<':
+		// Skip a syslog <PRI> and RFC5424 version
+		if end := strings.IndexByte(text, '>'); end > 0 && end <= 4 {
+			text = strings.TrimPrefix(text[end+1:], "1 ")
+		}
+	case '[':
+		if t, ok := detectLeadingTime(text[1:], now); ok {
+			return t, true
+		}
+	case 'I', 'W', 'E', 'F':
+		if m := klogTimeRe.FindStringSubmatch(text); m != nil {
+			t, err := time.ParseInLocation("01 02 15:04:05.999999999", m[1]+" "+m[2]+" "+m[3], time.Local)
+			if err == nil {
+				return withYear(t, now), true
+			}
+		}
+	}
+
+	if t, ok := detectLeadingTime(text, now); ok {
+		return t, true
+	}
+
+	head := text
+	if len(head) > levelHeadLength {
+		head = head[:levelHeadLength]
+	}
+	if m := clfTimeRe.FindStringSubmatch(head); m != nil {
+		if t, err := time.Parse("02/Jan/2006:15:04:05 -0700", m[1]); err == nil {
+			return t, true
+		}
+	}
+	if strings.Contains(text, "=") {
+		if m := logfmtTimeRe.FindStringSubmatch(text); m != nil {
+			value := m[1] + m[2]
+			if t, ok := parseTimeValue(value); ok {
+				return t, true
+			}
+			return parseEpoch(value)
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// detectLeadingTime parses a time at the start of text
+func detectLeadingTime(text string, now time.Time) (time.Time, bool) {
+	if text == "" {
+		return time.Time{}, false
+	}
+	c := text[0]
+	switch {
+	case c >= '0' && c <= '9':
+		if m := isoTimeRe.FindString(text); m != "" {
+			return parseTimeValue(m)
+		}
+		if m := slashTimeRe.FindString(text); m != "" {
+			t, err := time.ParseInLocation("2006/01/02 15:04:05", m, time.Local)
+			return t, err == nil
+		}
+		if m := epochTimeRe.FindString(text); m != "" {
+			return parseEpoch(m)
+		}
+	case c >= 'A' && c <= 'Z':
+		if m := syslogTimeRe.FindString(text); m != "" {
+			t, err := time.ParseInLocation(time.Stamp, m, time.Local)
+			if err == nil {
+				return withYear(t, now), true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseTimeValue parses an ISO 8601 time value
+func parseTimeValue(value string) (time.Time, bool) {
+	m := isoTimeRe.FindString(value)
+	if m == "" {
+		return time.Time{}, false
+	}
+
+	// Normalize to the layouts' separators
+	m = strings.Replace(m, " ", "T", 1)
+	m = strings.Replace(m, ",", ".", 1)
+
+	for _, layout := range isoLayouts {
+		if t, err := time.Parse(layout, m); err == nil {
+			return t, true
+		}
+	}
+	if t, err := time.ParseInLocation("2006-01-02T15:04:05.999999999", m, time.Local); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// parseEpoch parses Unix seconds (with optional fraction) or milliseconds
+func parseEpoch(value string) (time.Time, bool) {
+	if !strings.Contains(value, ".") && len(value) == 13 {
+		ms, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.UnixMilli(ms), true
+	}
+
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds < 1e8 || seconds >= 1e10 {
+		// Outside 1973-2286: more likely a counter than a time
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(seconds*float64(time.Second))), true
+}
+
+// withYear sets the year of a time parsed without one, choosing the
+// latest year that doesn't put it more than a day in the future
+func withYear(t, now time.Time) time.Time {
+	t = t.AddDate(now.Year()-t.Year(), 0, 0)
+	if t.After(now.Add(24 * time.Hour)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+	return t
+}
+
+// parseLayoutPrefix parses a time with a Go layout at the start of text.
+// Formatted times can be longer or shorter than their layout (month names,
+// fractions), so a few prefix lengths around the layout's are tried.
+func parseLayoutPrefix(layout, text string, now time.Time) (time.Time, bool) {
+	text = strings.TrimPrefix(text, "[")
+	base := len(layout)
+	for _, delta := range []int{0, 1, -1, 2, -2, 3, 4, 5, 6, 7, 8, 9} {
+		n := base + delta
+		if n <= 0 || n > len(text) {
+			continue
+		}
+		t, err := time.ParseInLocation(layout, text[:n], time.Local)
+		if err != nil {
+			continue
+		}
+		if t.Year() == 0 {
+			t = withYear(t, now)
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
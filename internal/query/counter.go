@@ -0,0 +1,97 @@
+package query
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/yourusername/weblogview/internal/logline"
+)
+
+// maxGroups limits the number of distinct groups a counter tracks; lines
+// of further groups are counted under otherGroup
+const maxGroups = 1000
+
+// otherGroup is the key of lines beyond maxGroups
+const otherGroup = "(other)"
+
+// Bucket is the count of one group of a count stage
+type Bucket struct {
+	Key   []string `json:"key"` // Values of the by fields, in order
+	Count int64    `json:"count"`
+}
+
+// Counter implements "| count by field, ...". It is safe for concurrent use.
+type Counter struct {
+	by     []string
+	mu     sync.Mutex
+	groups map[string]*Bucket
+}
+
+// NewCounter creates a counter grouping by the given fields
+func NewCounter(by []string) *Counter {
+	return &Counter{by: by, groups: map[string]*Bucket{}}
+}
+
+// Add counts a line
+func (c *Counter) Add(line logline.Line) {
+	key := make([]string, len(c.by))
+	for i, field := range c.by {
+		key[i], _ = line.Field(field)
+	}
+	c.add(key, 1)
+}
+
+// add adds n to the group of key
+func (c *Counter) add(key []string, n int64) {
+	id := strings.Join(key, "\x00")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	bucket, ok := c.groups[id]
+	if !ok {
+		if len(c.groups) >= maxGroups {
+			key = make([]string, len(c.by))
+			for i := range key {
+				key[i] = otherGroup
+			}
+			id = strings.Join(key, "\x00")
+			bucket = c.groups[id]
+		}
+		if bucket == nil {
+			bucket = &Bucket{Key: key}
+			c.groups[id] = bucket
+		}
+	}
+	bucket.Count += n
+}
+
+// Merge adds the buckets of another count of the same query
+func (c *Counter) Merge(buckets []Bucket) {
+	for _, b := range buckets {
+		c.add(b.Key, b.Count)
+	}
+}
+
+// Buckets returns the groups, largest first
+func (c *Counter) Buckets() []Bucket {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	buckets := make([]Bucket, 0, len(c.groups))
+	for _, b := range c.groups {
+		buckets = append(buckets, *b)
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		if buckets[i].Count != buckets[j].Count {
+			return buckets[i].Count > buckets[j].Count
+		}
+		return strings.Join(buckets[i].Key, "\x00") < strings.Join(buckets[j].Key, "\x00")
+	})
+	return buckets
+}
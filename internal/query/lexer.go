@@ -0,0 +1,149 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tokenKind identifies the kind of a token
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokString // "quoted"
+	tokRegex  // /pattern/
+	tokOp     // = != > >= < <= ~ !~
+	tokLParen
+	tokRParen
+	tokPipe
+	tokComma
+	tokNot // ! (the "not" keyword is a word)
+)
+
+// token is a lexical token with its position in the query
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// lex splits a query into tokens. A /regex/ literal is only recognized
+// right after ~ or !~ so that bare paths like /health stay words. Without
+// a closing slash the pattern runs to whitespace or ")", so path~/health
+// matches "/health".
+func lex(input string) ([]token, error) {
+	tokens := []token{}
+	i := 0
+	for i < len(input) {
+		c := input[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case c == '(':
+			tokens = append(tokens, token{tokLParen, "(", i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokRParen, ")", i})
+			i++
+		case c == '|' && strings.HasPrefix(input[i:], "||"):
+			tokens = append(tokens, token{tokWord, "or", i})
+			i += 2
+		case c == '|':
+			tokens = append(tokens, token{tokPipe, "|", i})
+			i++
+		case c == '&' && strings.HasPrefix(input[i:], "&&"):
+			tokens = append(tokens, token{tokWord, "and", i})
+			i += 2
+		case c == ',':
+			tokens = append(tokens, token{tokComma, ",", i})
+			i++
+
+		case c == '"' || c == '\'':
+			text, n, err := lexQuoted(input[i:], c)
+			if err != nil {
+				return nil, fmt.Errorf("at %d: %w", i, err)
+			}
+			tokens = append(tokens, token{tokString, text, i})
+			i += n
+
+		case c == '/' && afterMatchOp(tokens):
+			text, n, err := lexQuoted(input[i:], '/')
+			if err != nil || (i+n < len(input) && !endsPattern(input[i+n]) && input[i+n] != '|') {
+				// No closing slash where the literal would end
+				text, n = lexPattern(input[i:])
+			}
+			tokens = append(tokens, token{tokRegex, text, i})
+			i += n
+
+		case strings.ContainsRune("=!<>~", rune(c)):
+			op := string(c)
+			if i+1 < len(input) && (input[i+1] == '=' || (c == '!' && input[i+1] == '~')) {
+				op += string(input[i+1])
+			}
+			if op == "!" {
+				tokens = append(tokens, token{tokNot, op, i})
+			} else {
+				tokens = append(tokens, token{tokOp, op, i})
+			}
+			i += len(op)
+
+		default:
+			start := i
+			for i < len(input) && !isDelimiter(input[i]) {
+				i++
+			}
+			tokens = append(tokens, token{tokWord, input[start:i], start})
+		}
+	}
+
+	return append(tokens, token{tokEOF, "", len(input)}), nil
+}
+
+// lexQuoted reads a literal delimited by quote; a backslash escapes the
+// quote character (and itself, except in regex literals)
+func lexQuoted(input string, quote byte) (string, int, error) {
+	var b strings.Builder
+	for i := 1; i < len(input); i++ {
+		c := input[i]
+		switch {
+		case c == '\\' && i+1 < len(input) && (input[i+1] == quote || (quote != '/' && input[i+1] == '\\')):
+			b.WriteByte(input[i+1])
+			i++
+		case c == quote:
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated %c", quote)
+}
+
+// lexPattern reads a regex without delimiters, up to whitespace or ")"
+func lexPattern(input string) (string, int) {
+	n := 0
+	for n < len(input) && !endsPattern(input[n]) {
+		n++
+	}
+	return input[:n], n
+}
+
+// endsPattern reports whether c ends a regex without a closing slash
+func endsPattern(c byte) bool {
+	return strings.IndexByte(" \t\r\n)", c) >= 0
+}
+
+// afterMatchOp reports whether the last token is a regex match operator
+func afterMatchOp(tokens []token) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+	last := tokens[len(tokens)-1]
+	return last.kind == tokOp && (last.text == "~" || last.text == "!~")
+}
+
+// isDelimiter reports whether c ends a bare word
+func isDelimiter(c byte) bool {
+	return strings.IndexByte(" \t\r\n()|,=!<>~\"'", c) >= 0
+}
@@ -0,0 +1,226 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/weblogview/internal/logline"
+)
+
+// parser is a recursive descent parser over the token list
+type parser struct {
+	tokens []token
+	pos    int
+	now    time.Time // Reference for relative times such as time>=-15m
+}
+
+// peek returns the current token without consuming it
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// next consumes and returns the current token
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// isKeyword reports whether tok is the given keyword
+func isKeyword(tok token, keyword string) bool {
+	return tok.kind == tokWord && strings.EqualFold(tok.text, keyword)
+}
+
+// parseOr parses: and ("or" and)*
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for isKeyword(p.peek(), "or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orNode{left, right}
+	}
+	return left, nil
+}
+
+// parseAnd parses: unary (["and"] unary)*
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		if isKeyword(tok, "and") {
+			p.next()
+		} else if tok.kind == tokEOF || tok.kind == tokRParen || tok.kind == tokPipe || isKeyword(tok, "or") {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left, right}
+	}
+}
+
+// parseUnary parses: ("not" | "!") unary | primary
+func (p *parser) parseUnary() (node, error) {
+	if tok := p.peek(); tok.kind == tokNot || isKeyword(tok, "not") {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+// parsePrimary parses: "(" or ")" | field op value | term
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokLParen:
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, fmt.Errorf("at %d: expected )", closing.pos)
+		}
+		return n, nil
+
+	case tokString:
+		return &termNode{text: tok.text, lower: strings.ToLower(tok.text)}, nil
+
+	case tokWord:
+		if p.peek().kind == tokOp {
+			return p.parseComparison(tok)
+		}
+		return &termNode{text: tok.text, lower: strings.ToLower(tok.text)}, nil
+
+	case tokEOF:
+		return nil, fmt.Errorf("unexpected end of query")
+	}
+	return nil, fmt.Errorf("at %d: unexpected %q", tok.pos, tok.text)
+}
+
+// parseComparison parses the operator and value following a field name
+func (p *parser) parseComparison(field token) (node, error) {
+	op := p.next()
+	value := p.next()
+	switch value.kind {
+	case tokWord, tokString, tokRegex:
+	default:
+		return nil, fmt.Errorf("at %d: expected a value after %s", value.pos, op.text)
+	}
+
+	n := &compareNode{field: field.text, op: op.text, value: value.text, level: -1}
+
+	if op.text == "~" || op.text == "!~" {
+		// Regex matches are case-insensitive unless the pattern says otherwise
+		re, err := regexp.Compile("(?i)" + value.text)
+		if err != nil {
+			return nil, fmt.Errorf("at %d: invalid regex: %w", value.pos, err)
+		}
+		n.re = re
+		return n, nil
+	}
+	if value.kind == tokRegex {
+		return nil, fmt.Errorf("at %d: regex values need ~ or !~", value.pos)
+	}
+
+	if n.field == "time" {
+		t, err := ParseTime(value.text, p.now)
+		if err != nil {
+			return nil, fmt.Errorf("at %d: %w", value.pos, err)
+		}
+		n.time = t
+		return n, nil
+	}
+
+	if n.field == "level" {
+		n.level = logline.LevelRank(value.text)
+	}
+	if number, err := strconv.ParseFloat(value.text, 64); err == nil {
+		n.number = number
+		n.numeric = true
+	}
+	return n, nil
+}
+
+// parseAggregation parses: "count" ["by" field ("," field)*]
+func (p *parser) parseAggregation(q *Query) error {
+	tok := p.next()
+	if !isKeyword(tok, "count") {
+		return fmt.Errorf("at %d: expected count after |", tok.pos)
+	}
+	q.count = true
+
+	if !isKeyword(p.peek(), "by") {
+		return nil
+	}
+	p.next()
+
+	for {
+		field := p.next()
+		if field.kind != tokWord && field.kind != tokString {
+			return fmt.Errorf("at %d: expected a field name", field.pos)
+		}
+		q.by = append(q.by, field.text)
+		if p.peek().kind != tokComma {
+			return nil
+		}
+		p.next()
+	}
+}
+
+// timeLayouts are the absolute time formats accepted in time comparisons
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// clockLayouts are times of day, taken to be on the day of now
+var clockLayouts = []string{
+	"15:04:05.999999999",
+	"15:04",
+}
+
+// ParseTime parses an absolute time, a time of day (today), "now", or a
+// duration relative to now such as -15m (a leading minus is optional: 1h
+// means one hour ago)
+func ParseTime(value string, now time.Time) (time.Time, error) {
+	if strings.EqualFold(value, "now") {
+		return now, nil
+	}
+	if d, err := time.ParseDuration(strings.TrimPrefix(value, "-")); err == nil {
+		return now.Add(-d), nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range clockLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			year, month, day := now.Date()
+			return time.Date(year, month, day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), now.Location()), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", value)
+}
@@ -0,0 +1,214 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/weblogview/internal/logline"
+)
+
+// maxQueryLength limits the length of a query
+const maxQueryLength = 4096
+
+// Query is a parsed log query such as
+//
+//	level>=warn and service=checkout and not path~/health/ | count by host
+//
+// Bare words and quoted strings match the line text (case-insensitive),
+// field comparisons use =, !=, <, <=, >, >=, ~ (regex) and !~, and terms
+// combine with and, or, not and parentheses. Adjacent terms are and-ed.
+type Query struct {
+	root  node // nil matches every line
+	by    []string
+	count bool
+}
+
+// node is an expression in the query AST
+type node interface {
+	match(line logline.Line) bool
+}
+
+// Parse parses a query. An empty query matches every line.
+func Parse(input string) (*Query, error) {
+	if len(input) > maxQueryLength {
+		return nil, fmt.Errorf("query too long (max %d characters)", maxQueryLength)
+	}
+
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, now: time.Now()}
+	q := &Query{}
+	if p.peek().kind != tokPipe && p.peek().kind != tokEOF {
+		if q.root, err = p.parseOr(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.peek().kind == tokPipe {
+		p.next()
+		if err := p.parseAggregation(q); err != nil {
+			return nil, err
+		}
+	}
+
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("at %d: unexpected %q", tok.pos, tok.text)
+	}
+	return q, nil
+}
+
+// Match reports whether a line satisfies the query's filter expression
+func (q *Query) Match(line logline.Line) bool {
+	return q == nil || q.root == nil || q.root.match(line)
+}
+
+// Aggregates reports whether the query ends in a count stage
+func (q *Query) Aggregates() bool {
+	return q != nil && q.count
+}
+
+// NewCounter creates a counter for the query's count stage
+func (q *Query) NewCounter() *Counter {
+	return NewCounter(q.by)
+}
+
+// RequiredTerms returns the text terms every matching line must contain,
+// for narrowing a search with an index
+func (q *Query) RequiredTerms() []string {
+	if q == nil {
+		return nil
+	}
+	return requiredTerms(q.root)
+}
+
+// requiredTerms collects the terms of the top-level and chain
+func requiredTerms(n node) []string {
+	switch n := n.(type) {
+	case *andNode:
+		return append(requiredTerms(n.left), requiredTerms(n.right)...)
+	case *termNode:
+		return []string{n.text}
+	}
+	return nil
+}
+
+// andNode matches when both sides match
+type andNode struct{ left, right node }
+
+func (n *andNode) match(line logline.Line) bool {
+	return n.left.match(line) && n.right.match(line)
+}
+
+// orNode matches when either side matches
+type orNode struct{ left, right node }
+
+func (n *orNode) match(line logline.Line) bool {
+	return n.left.match(line) || n.right.match(line)
+}
+
+// notNode inverts its operand
+type notNode struct{ operand node }
+
+func (n *notNode) match(line logline.Line) bool {
+	return !n.operand.match(line)
+}
+
+// termNode matches text anywhere in the line, ignoring case
+type termNode struct {
+	text  string
+	lower string
+}
+
+func (n *termNode) match(line logline.Line) bool {
+	return containsFold(line.Text, n.lower)
+}
+
+// compareNode compares a field with a value
+type compareNode struct {
+	field string
+	op    string
+	value string
+
+	re      *regexp.Regexp // ~ and !~
+	number  float64
+	numeric bool
+	time    time.Time // time field
+	level   int       // level field, -1 if the value isn't a known level
+}
+
+func (n *compareNode) match(line logline.Line) bool {
+	if n.field == "time" {
+		t := line.Time()
+		if t.IsZero() {
+			return false
+		}
+		return compareOrdered(n.op, t.Compare(n.time))
+	}
+
+	value, ok := line.Field(n.field)
+	if !ok {
+		// A missing field is never equal to or matched by anything
+		return n.op == "!=" || n.op == "!~"
+	}
+
+	switch n.op {
+	case "~":
+		return n.re.MatchString(value)
+	case "!~":
+		return !n.re.MatchString(value)
+	}
+
+	if n.level >= 0 {
+		if level := logline.LevelRank(value); level >= 0 {
+			return compareOrdered(n.op, level-n.level)
+		}
+	}
+	if n.numeric {
+		if number, err := strconv.ParseFloat(value, 64); err == nil {
+			switch {
+			case number < n.number:
+				return compareOrdered(n.op, -1)
+			case number > n.number:
+				return compareOrdered(n.op, 1)
+			}
+			return compareOrdered(n.op, 0)
+		}
+	}
+	if n.op == "=" || n.op == "!=" {
+		return strings.EqualFold(value, n.value) == (n.op == "=")
+	}
+	return compareOrdered(n.op, strings.Compare(value, n.value))
+}
+
+// compareOrdered applies a comparison operator to a comparison result
+func compareOrdered(op string, cmp int) bool {
+	switch op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+// containsFold reports whether s contains the lowercase substr, ignoring case
+func containsFold(s, lowerSubstr string) bool {
+	if lowerSubstr == "" {
+		return true
+	}
+	return strings.Contains(strings.ToLower(s), lowerSubstr)
+}
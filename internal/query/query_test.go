@@ -0,0 +1,111 @@
+package query
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yourusername/weblogview/internal/logline"
+)
+
+// line builds a line with fields for matching
+func line(level string, fields map[string]string) logline.Line {
+	return logline.Line{Text: "request handled", Level: level, Fields: fields}
+}
+
+func TestParseRequestExample(t *testing.T) {
+	for _, input := range []string{
+		"level>=warn and service=checkout and not path~/health",
+		"level>=warn and service=checkout and not path~/health/",
+		"level>=warn service=checkout !path~health",
+		"(level>=warn and service=checkout) and not (path~/health)",
+	} {
+		q, err := Parse(input)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", input, err)
+			continue
+		}
+
+		cases := []struct {
+			line logline.Line
+			want bool
+		}{
+			{line("error", map[string]string{"service": "checkout", "path": "/api/pay"}), true},
+			{line("warn", map[string]string{"service": "Checkout"}), true},
+			{line("info", map[string]string{"service": "checkout", "path": "/api/pay"}), false},
+			{line("error", map[string]string{"service": "cart", "path": "/api/pay"}), false},
+			{line("error", map[string]string{"service": "checkout", "path": "/healthz"}), false},
+		}
+		for _, c := range cases {
+			if got := q.Match(c.line); got != c.want {
+				t.Errorf("%q matched %+v = %v, want %v", input, c.line, got, c.want)
+			}
+		}
+	}
+}
+
+func TestLexRegexValues(t *testing.T) {
+	cases := []struct {
+		input string
+		regex string
+		next  tokenKind
+	}{
+		{"path~/health", "/health", tokEOF},
+		{"path~/health/", "health", tokEOF},
+		{"path~/a b/ x", "a b", tokWord},
+		{"path~/api/v1 x", "/api/v1", tokWord},
+		{"(path~/health)", "/health", tokRParen},
+		{"path~/health/)", "health", tokRParen},
+		{"path~/warn/|count", "warn", tokPipe},
+		{`path~/a\/b/`, "a/b", tokEOF},
+		{"path!~/health|/ready x", "/health|/ready", tokWord},
+	}
+	for _, c := range cases {
+		tokens, err := lex(c.input)
+		if err != nil {
+			t.Errorf("lex(%q): %v", c.input, err)
+			continue
+		}
+		i := 0
+		for i < len(tokens) && tokens[i].kind != tokRegex {
+			i++
+		}
+		if i == len(tokens) {
+			t.Errorf("lex(%q): no regex token in %v", c.input, tokens)
+			continue
+		}
+		if tokens[i].text != c.regex || tokens[i+1].kind != c.next {
+			t.Errorf("lex(%q) = %q then %v, want %q then %v", c.input, tokens[i].text, tokens[i+1].kind, c.regex, c.next)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []struct {
+		input string
+		want  string
+	}{
+		{`msg="unterminated`, "unterminated"},
+		{"path~/(health", "invalid regex"},
+		{"level>=", "expected a value"},
+		{"(error", "expected )"},
+		{"error | sum", "expected count"},
+	}
+	for _, c := range cases {
+		if _, err := Parse(c.input); err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("Parse(%q) = %v, want an error containing %q", c.input, err, c.want)
+		}
+	}
+}
+
+func TestParseAggregation(t *testing.T) {
+	q, err := Parse("level>=warn | count by service, host")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !q.Aggregates() {
+		t.Error("Aggregates() = false, want true")
+	}
+	if got := strings.Join(q.by, ","); got != "service,host" {
+		t.Errorf("by = %q, want service,host", got)
+	}
+}
@@ -0,0 +1,194 @@
+package search
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/weblogview/internal/logline"
+	"github.com/yourusername/weblogview/internal/parser"
+)
+
+const (
+	// maxAccessPaths limits the distinct paths tracked; the rest are
+	// counted as "(other)"
+	maxAccessPaths = 10000
+
+	// maxLatencySamples is the reservoir size for latency percentiles
+	maxLatencySamples = 100000
+)
+
+// AccessStats summarizes the requests of an access log
+type AccessStats struct {
+	Requests      int64            `json:"requests"`
+	LinesScanned  int64            `json:"linesScanned"`
+	StatusClasses map[string]int64 `json:"statusClasses"` // "2xx", "4xx", ...
+	Statuses      map[string]int64 `json:"statuses"`
+	Latency       *LatencyStats    `json:"latency,omitempty"` // Only when the format logs latency
+	TopPaths      []PathStats      `json:"topPaths"`
+	Slowest       []SlowRequest    `json:"slowest"`
+	Canceled      bool             `json:"canceled"`
+}
+
+// LatencyStats are request latency percentiles in milliseconds
+type LatencyStats struct {
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P99 float64 `json:"p99"`
+	Max float64 `json:"max"`
+}
+
+// PathStats counts the requests of one path (without query string)
+type PathStats struct {
+	Path         string  `json:"path"`
+	Requests     int64   `json:"requests"`
+	Errors       int64   `json:"errors"` // 5xx responses
+	Bytes        int64   `json:"bytes"`
+	AvgLatencyMs float64 `json:"avgLatencyMs,omitempty"`
+
+	latencySum   float64
+	latencyCount int64
+}
+
+// SlowRequest is one of the slowest requests
+type SlowRequest struct {
+	Offset    int64   `json:"offset"` // Byte offset of the line, for read-range
+	Text      string  `json:"text"`
+	Method    string  `json:"method,omitempty"`
+	Path      string  `json:"path,omitempty"`
+	Status    int     `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+}
+
+// Access aggregates the access log lines stamped from "from" through "to"
+// (zero times leave the range open), found by binary search like
+// ReadTimeRange. top limits the paths and slow requests returned. Lines
+// are parsed with p, so custom parsers that extract status, path and
+// latency_ms fields are aggregated too.
+func Access(ctx context.Context, path string, from, to time.Time, top int, p *parser.Parser) (AccessStats, error) {
+	stats := AccessStats{
+		StatusClasses: map[string]int64{},
+		Statuses:      map[string]int64{},
+		TopPaths:      []PathStats{},
+		Slowest:       []SlowRequest{},
+	}
+
+	paths := map[string]*PathStats{}
+	var latencies []float64
+	var latencyCount int64
+	maxLatency := 0.0
+
+	err := Scan(ctx, path, from, to, p, func(lineOffset int64, line logline.Line) error {
+		stats.LinesScanned++
+
+		status, err := strconv.Atoi(line.Fields["status"])
+		if err != nil || status < 100 || status > 599 {
+			return nil
+		}
+		stats.Requests++
+		stats.Statuses[strconv.Itoa(status)]++
+		stats.StatusClasses[strconv.Itoa(status/100)+"xx"]++
+
+		requestPath, _, _ := strings.Cut(line.Fields["path"], "?")
+		if requestPath == "" {
+			requestPath = "(unknown)"
+		}
+		ps := paths[requestPath]
+		if ps == nil {
+			if len(paths) >= maxAccessPaths {
+				requestPath = "(other)"
+				ps = paths[requestPath]
+			}
+			if ps == nil {
+				ps = &PathStats{Path: requestPath}
+				paths[requestPath] = ps
+			}
+		}
+		ps.Requests++
+		if status >= 500 {
+			ps.Errors++
+		}
+		bytes, _ := strconv.ParseInt(line.Fields["bytes"], 10, 64)
+		ps.Bytes += bytes
+
+		latency, err := strconv.ParseFloat(line.Fields["latency_ms"], 64)
+		if err != nil {
+			return nil
+		}
+		ps.latencySum += latency
+		ps.latencyCount++
+
+		// Reservoir sampling keeps the percentiles' memory bounded
+		latencyCount++
+		if len(latencies) < maxLatencySamples {
+			latencies = append(latencies, latency)
+		} else if i := rand.Int63n(latencyCount); i < maxLatencySamples {
+			latencies[i] = latency
+		}
+		if latency > maxLatency {
+			maxLatency = latency
+		}
+
+		if top > 0 && (len(stats.Slowest) < top || latency > stats.Slowest[len(stats.Slowest)-1].LatencyMs) {
+			stats.Slowest = append(stats.Slowest, SlowRequest{
+				Offset:    lineOffset,
+				Text:      truncate(line.Text),
+				Method:    line.Fields["method"],
+				Path:      line.Fields["path"],
+				Status:    status,
+				LatencyMs: latency,
+			})
+			sort.SliceStable(stats.Slowest, func(i, j int) bool {
+				return stats.Slowest[i].LatencyMs > stats.Slowest[j].LatencyMs
+			})
+			if len(stats.Slowest) > top {
+				stats.Slowest = stats.Slowest[:top]
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// A canceled scan returns what was aggregated so far
+		if ctx.Err() == nil || err != ctx.Err() {
+			return stats, err
+		}
+		stats.Canceled = true
+	}
+
+	if len(latencies) > 0 {
+		sort.Float64s(latencies)
+		stats.Latency = &LatencyStats{
+			P50: percentile(latencies, 0.50),
+			P90: percentile(latencies, 0.90),
+			P99: percentile(latencies, 0.99),
+			Max: maxLatency,
+		}
+	}
+
+	for _, ps := range paths {
+		if ps.latencyCount > 0 {
+			ps.AvgLatencyMs = ps.latencySum / float64(ps.latencyCount)
+		}
+		stats.TopPaths = append(stats.TopPaths, *ps)
+	}
+	sort.Slice(stats.TopPaths, func(i, j int) bool {
+		if stats.TopPaths[i].Requests != stats.TopPaths[j].Requests {
+			return stats.TopPaths[i].Requests > stats.TopPaths[j].Requests
+		}
+		return stats.TopPaths[i].Path < stats.TopPaths[j].Path
+	})
+	if top > 0 && len(stats.TopPaths) > top {
+		stats.TopPaths = stats.TopPaths[:top]
+	}
+
+	return stats, nil
+}
+
+// percentile returns the q-th quantile of sorted values
+func percentile(sorted []float64, q float64) float64 {
+	i := int(q * float64(len(sorted)-1))
+	return sorted[i]
+}
@@ -0,0 +1,55 @@
+package search
+
+import (
+	"context"
+	"time"
+
+	"github.com/yourusername/weblogview/internal/fieldstats"
+	"github.com/yourusername/weblogview/internal/filter"
+	"github.com/yourusername/weblogview/internal/logline"
+	"github.com/yourusername/weblogview/internal/parser"
+)
+
+// FieldOptions selects the lines of a file whose fields are summarized
+type FieldOptions struct {
+	From, To time.Time      // Zero times leave the range open
+	Filter   *filter.Filter // Lines must pass it (nil for all); its counts aren't updated
+	Window   int            // Only the last Window lines passing the filter (0 for all)
+	Parser   *parser.Parser
+}
+
+// FieldStats counts the fields of the lines of a file stamped from
+// opts.From through opts.To into a, found by binary search like
+// ReadTimeRange. progress, if not nil, is called every so often during the
+// scan. Returns the context's error if the scan was canceled.
+func FieldStats(ctx context.Context, path string, opts FieldOptions, a *fieldstats.Aggregator, progress func(Progress)) error {
+	// Keep a ring of the last Window matches so memory stays bounded
+	var ring []logline.Line
+	start := 0
+	keep := func(line logline.Line) {
+		switch {
+		case opts.Window <= 0:
+			a.Add(line)
+		case len(ring) < opts.Window:
+			ring = append(ring, line)
+		default:
+			ring[start] = line
+			start = (start + 1) % opts.Window
+		}
+	}
+
+	err := scan(ctx, path, opts.From, opts.To, opts.Parser, func(_ int64, line logline.Line) error {
+		if opts.Filter.MatchLine(line) {
+			keep(line)
+		}
+		return nil
+	}, progress)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range append(ring[start:], ring[:start]...) {
+		a.Add(line)
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package search
+
+import (
+	"context"
+	"time"
+
+	"github.com/yourusername/weblogview/internal/histogram"
+	"github.com/yourusername/weblogview/internal/logline"
+	"github.com/yourusername/weblogview/internal/parser"
+)
+
+// Histogram counts the lines of a file stamped from "from" through "to"
+// (zero times leave the range open) into b, found by binary search like
+// ReadTimeRange. Lines are parsed with p, so levels and fields are
+// available for grouping. Lines appended after the scan started are left
+// to the live stream. Returns the context's error if the scan was
+// canceled.
+func Histogram(ctx context.Context, path string, from, to time.Time, b *histogram.Builder, p *parser.Parser) error {
+	return Scan(ctx, path, from, to, p, func(_ int64, line logline.Line) error {
+		b.Add(line)
+		return nil
+	})
+}
@@ -0,0 +1,94 @@
+package search
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yourusername/weblogview/internal/logline"
+	"github.com/yourusername/weblogview/internal/parser"
+)
+
+// progressInterval is the shortest time between two progress reports
+const progressInterval = 500 * time.Millisecond
+
+// Progress reports how far a scan has read
+type Progress struct {
+	BytesScanned int64 `json:"bytesScanned"`
+	BytesTotal   int64 `json:"bytesTotal"`
+	LinesScanned int64 `json:"linesScanned"`
+}
+
+// Scan calls fn with the byte offset and parsed line of each line of a
+// file stamped from "from" through "to" (zero times leave the range open),
+// found by binary search like ReadTimeRange. Returns fn's first error, or
+// the context's error if the scan was canceled.
+func Scan(ctx context.Context, path string, from, to time.Time, p *parser.Parser, fn func(offset int64, line logline.Line) error) error {
+	return scan(ctx, path, from, to, p, fn, nil)
+}
+
+// scan is Scan calling progress, if not nil, every so often during the
+// scan and once when it completes
+func scan(ctx context.Context, path string, from, to time.Time, p *parser.Parser, fn func(offset int64, line logline.Line) error, progress func(Progress)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	offset := int64(0)
+	if !from.IsZero() {
+		if offset, err = SeekTime(file, info.Size(), from, p); err != nil {
+			return err
+		}
+	}
+
+	report := Progress{BytesScanned: offset, BytesTotal: info.Size()}
+	lastReport := time.Now()
+
+	// Lines appended after the scan started are left out
+	reader := bufio.NewReader(io.NewSectionReader(file, offset, info.Size()-offset))
+	for {
+		if report.LinesScanned%1000 == 0 {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if progress != nil && time.Since(lastReport) >= progressInterval {
+				progress(report)
+				lastReport = time.Now()
+			}
+		}
+
+		raw, err := reader.ReadString('\n')
+		if raw == "" {
+			if err != nil && err != io.EOF {
+				return err
+			}
+			break
+		}
+		lineOffset := report.BytesScanned
+		report.BytesScanned += int64(len(raw))
+		report.LinesScanned++
+
+		line := p.Parse(strings.TrimRight(raw, "\r\n"))
+		if !to.IsZero() && line.Timestamp != 0 && line.Time().After(to) {
+			break
+		}
+		if err := fn(lineOffset, line); err != nil {
+			return err
+		}
+	}
+
+	if progress != nil {
+		progress(report)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package search
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yourusername/weblogview/internal/fieldstats"
+	"github.com/yourusername/weblogview/internal/logline"
+	"github.com/yourusername/weblogview/internal/parser"
+)
+
+// writeLog writes lines to a temporary file
+func writeLog(t *testing.T, text string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const scanLog = `2024-03-01T10:00:00Z status=200 path=/a latency_ms=5
+2024-03-01T10:01:00Z status=500 path=/b latency_ms=50
+2024-03-01T10:02:00Z status=200 path=/a latency_ms=7
+2024-03-01T10:03:00Z status=404 path=/c
+`
+
+func TestScanRange(t *testing.T) {
+	path := writeLog(t, scanLog)
+	from := time.Date(2024, 3, 1, 10, 1, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 1, 10, 2, 0, 0, time.UTC)
+
+	var offsets []int64
+	var paths []string
+	err := Scan(context.Background(), path, from, to, parser.New(""), func(offset int64, line logline.Line) error {
+		offsets = append(offsets, offset)
+		paths = append(paths, line.Fields["path"])
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 || paths[0] != "/b" || paths[1] != "/a" {
+		t.Errorf("paths = %v, want /b and /a", paths)
+	}
+	if len(offsets) != 2 || offsets[0] != 53 || offsets[1] != 107 {
+		t.Errorf("offsets = %v, want 53 and 107", offsets)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := Scan(ctx, path, time.Time{}, time.Time{}, parser.New(""), func(int64, logline.Line) error { return nil }); err != context.Canceled {
+		t.Errorf("canceled scan returned %v", err)
+	}
+}
+
+func TestAccessAndFieldStats(t *testing.T) {
+	path := writeLog(t, scanLog)
+
+	stats, err := Access(context.Background(), path, time.Time{}, time.Time{}, 10, parser.New(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Requests != 4 || stats.StatusClasses["2xx"] != 2 || stats.Statuses["500"] != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+	if len(stats.Slowest) != 3 || stats.Slowest[0].Offset != 53 || stats.Slowest[0].LatencyMs != 50 {
+		t.Errorf("slowest = %+v, want the /b request first", stats.Slowest)
+	}
+
+	a := fieldstats.New("path")
+	var last Progress
+	err = FieldStats(context.Background(), path, FieldOptions{Window: 2, Parser: parser.New("")}, a, func(p Progress) {
+		last = p
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Lines() != 2 {
+		t.Errorf("counted %d lines, want the window of 2", a.Lines())
+	}
+	if last.LinesScanned != 4 || last.BytesScanned != last.BytesTotal {
+		t.Errorf("last progress = %+v, want the whole file", last)
+	}
+}
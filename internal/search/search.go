@@ -0,0 +1,269 @@
+package search
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/yourusername/weblogview/internal/parser"
+	"github.com/yourusername/weblogview/internal/query"
+)
+
+const (
+	// maxContextLines limits the context lines returned around a match
+	maxContextLines = 20
+
+	// maxLineLength truncates very long lines in results
+	maxLineLength = 64 * 1024
+)
+
+// Options describes a search
+type Options struct {
+	Query         string `json:"query"`
+	Regex         bool   `json:"regex,omitempty"`         // Treat the query as a regular expression (RE2)
+	Language      bool   `json:"language,omitempty"`      // The query is in the query language (see internal/query)
+	CaseSensitive bool   `json:"caseSensitive,omitempty"` // Case-insensitive by default
+	Context       int    `json:"context,omitempty"`       // Lines of context before and after each match
+	MaxResults    int    `json:"maxResults,omitempty"`    // Stop after this many matches (0 = unlimited)
+
+	// Parser reads the metadata of lines for query language searches
+	// (nil detects it automatically)
+	Parser *parser.Parser `json:"-"`
+}
+
+// Match is a line that matched a search
+type Match struct {
+	Line   int64    `json:"line"`   // 1-based line number
+	Offset int64    `json:"offset"` // Byte offset of the start of the line
+	Text   string   `json:"text"`
+	Start  int      `json:"start"` // Byte offset of the first match within the line
+	End    int      `json:"end"`
+	Before []string `json:"before,omitempty"`
+	After  []string `json:"after,omitempty"`
+}
+
+// Summary describes a finished search
+type Summary struct {
+	Matches      int64 `json:"matches"`
+	LinesScanned int64 `json:"linesScanned"`
+	BytesScanned int64 `json:"bytesScanned"`
+	Truncated    bool  `json:"truncated"` // MaxResults was reached
+	Canceled     bool  `json:"canceled"`
+	Indexed      bool  `json:"indexed,omitempty"` // Only the regions selected by an index were scanned
+
+	// Counts holds the result of a query language count stage
+	Counts []query.Bucket `json:"counts,omitempty"`
+}
+
+// Compile builds the regular expression for a search
+func Compile(opts Options) (*regexp.Regexp, error) {
+	if opts.Query == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+
+	pattern := opts.Query
+	if !opts.Regex {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if !opts.CaseSensitive {
+		pattern = "(?i)" + pattern
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	return re, nil
+}
+
+// Validate checks that a search can be run
+func Validate(opts Options) error {
+	if opts.Language {
+		_, err := query.Parse(opts.Query)
+		return err
+	}
+	_, err := Compile(opts)
+	return err
+}
+
+// matcher reports whether a line matches and where
+type matcher func(text string) (start, end int, ok bool)
+
+// newMatcher builds the line matcher of a search, and a counter when the
+// query has a count stage
+func newMatcher(opts Options) (matcher, *query.Counter, error) {
+	if opts.Language {
+		q, err := query.Parse(opts.Query)
+		if err != nil {
+			return nil, nil, err
+		}
+		var counter *query.Counter
+		if q.Aggregates() {
+			counter = q.NewCounter()
+		}
+		p := opts.Parser
+		return func(text string) (int, int, bool) {
+			line := p.Parse(text)
+			if !q.Match(line) {
+				return 0, 0, false
+			}
+			if counter != nil {
+				counter.Add(line)
+			}
+			return 0, 0, true
+		}, counter, nil
+	}
+
+	re, err := Compile(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return func(text string) (int, int, bool) {
+		loc := re.FindStringIndex(text)
+		if loc == nil {
+			return 0, 0, false
+		}
+		return loc[0], loc[1], true
+	}, nil, nil
+}
+
+// File scans a whole file for opts.Query and calls fn for each match as
+// soon as its trailing context is available. Cancelling ctx stops the scan;
+// the summary then has Canceled set and the error is nil.
+func File(ctx context.Context, path string, opts Options, fn func(Match) error) (Summary, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Summary{}, err
+	}
+	defer file.Close()
+
+	return Reader(ctx, file, opts, fn)
+}
+
+// Reader scans r like File
+func Reader(ctx context.Context, r io.Reader, opts Options, fn func(Match) error) (Summary, error) {
+	return Section(ctx, r, 1, 0, opts, fn)
+}
+
+// Section scans r, a part of a larger file that starts at byte offset and
+// 1-based line number line, so that matches carry file positions. With a
+// count stage the whole input is scanned so the counts are complete, and
+// MaxResults only limits the matches passed to fn.
+func Section(ctx context.Context, r io.Reader, line, offset int64, opts Options, fn func(Match) error) (summary Summary, err error) {
+	match, counter, err := newMatcher(opts)
+	if err != nil {
+		return Summary{}, err
+	}
+	defer func() {
+		summary.Counts = counter.Buckets()
+	}()
+
+	contextLines := opts.Context
+	if contextLines < 0 {
+		contextLines = 0
+	}
+	if contextLines > maxContextLines {
+		contextLines = maxContextLines
+	}
+
+	reader := bufio.NewReaderSize(r, 64*1024)
+	before := []string{}  // Ring of the previous lines for leading context
+	pending := []*Match{} // Matches still waiting for trailing context
+
+	flush := func(all bool) error {
+		for len(pending) > 0 && (all || len(pending[0].After) >= contextLines) {
+			if err := fn(*pending[0]); err != nil {
+				return err
+			}
+			pending = pending[1:]
+		}
+		return nil
+	}
+
+	for {
+		// Check for cancellation every 4096 lines
+		if summary.LinesScanned%4096 == 0 {
+			select {
+			case <-ctx.Done():
+				summary.Canceled = true
+				return summary, flush(true)
+			default:
+			}
+		}
+
+		raw, err := reader.ReadString('\n')
+		if raw == "" && err != nil {
+			if err == io.EOF {
+				return summary, flush(true)
+			}
+			return summary, err
+		}
+
+		lineOffset := offset + summary.BytesScanned
+		summary.BytesScanned += int64(len(raw))
+		summary.LinesScanned++
+		text := truncate(strings.TrimRight(raw, "\r\n"))
+
+		for _, m := range pending {
+			if len(m.After) < contextLines {
+				m.After = append(m.After, text)
+			}
+		}
+		if err := flush(false); err != nil {
+			return summary, err
+		}
+
+		if opts.MaxResults > 0 && summary.Matches >= int64(opts.MaxResults) {
+			// Further matches aren't passed on; with a count stage they are
+			// still counted, otherwise finding one is enough to know the
+			// results are truncated
+			if counter != nil || !summary.Truncated {
+				if _, _, ok := match(text); ok {
+					summary.Truncated = true
+				}
+			}
+			if counter == nil && summary.Truncated && len(pending) == 0 {
+				return summary, nil
+			}
+		} else if start, end, ok := match(text); ok {
+			summary.Matches++
+			m := &Match{
+				Line:   line + summary.LinesScanned - 1,
+				Offset: lineOffset,
+				Text:   text,
+				Start:  start,
+				End:    end,
+			}
+			if contextLines > 0 {
+				m.Before = append([]string{}, before...)
+			}
+			pending = append(pending, m)
+			if err := flush(false); err != nil {
+				return summary, err
+			}
+		}
+
+		if contextLines > 0 {
+			before = append(before, text)
+			if len(before) > contextLines {
+				before = before[1:]
+			}
+		}
+
+		if err == io.EOF {
+			return summary, flush(true)
+		}
+	}
+}
+
+// truncate shortens overly long lines
+func truncate(line string) string {
+	if len(line) > maxLineLength {
+		return line[:maxLineLength]
+	}
+	return line
+}
@@ -0,0 +1,158 @@
+package search
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yourusername/weblogview/internal/parser"
+)
+
+const (
+	// seekScanSize is the region size below which SeekTime scans linearly
+	seekScanSize = 64 * 1024
+
+	// maxProbeLines limits how many lines are read looking for a timestamp
+	// at a probe position (continuation lines, stack traces)
+	maxProbeLines = 1000
+)
+
+// TimeRange is a block of lines read by timestamp
+type TimeRange struct {
+	Offset     int64    `json:"offset"`     // Byte offset of the first line
+	NextOffset int64    `json:"nextOffset"` // Byte offset after the last line
+	Lines      []string `json:"lines"`
+	Truncated  bool     `json:"truncated"` // maxLines was reached before the end of the range
+}
+
+// ReadTimeRange reads the lines stamped from "from" through "to" without
+// scanning the file from the start. Lines without a timestamp (e.g. stack
+// traces) are kept with the line before them. A zero from starts at the
+// beginning, a zero to reads to the end (up to maxLines).
+func ReadTimeRange(path string, from, to time.Time, maxLines int, p *parser.Parser) (TimeRange, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return TimeRange{}, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return TimeRange{}, err
+	}
+
+	offset := int64(0)
+	if !from.IsZero() {
+		if offset, err = SeekTime(file, info.Size(), from, p); err != nil {
+			return TimeRange{}, err
+		}
+	}
+
+	result := TimeRange{Offset: offset, NextOffset: offset, Lines: []string{}}
+	reader := bufio.NewReader(io.NewSectionReader(file, offset, info.Size()-offset))
+	for {
+		raw, err := reader.ReadString('\n')
+		if raw == "" {
+			if err != nil && err != io.EOF {
+				return result, err
+			}
+			return result, nil
+		}
+
+		text := strings.TrimRight(raw, "\r\n")
+		if !to.IsZero() {
+			if t := p.Time(text); !t.IsZero() && t.After(to) {
+				return result, nil
+			}
+		}
+		if maxLines > 0 && len(result.Lines) >= maxLines {
+			result.Truncated = true
+			return result, nil
+		}
+
+		result.Lines = append(result.Lines, text)
+		result.NextOffset += int64(len(raw))
+	}
+}
+
+// SeekTime returns the offset of the first line stamped at or after t,
+// or size if there is none. It binary searches on line timestamps, so the
+// file must be (roughly) in chronological order.
+func SeekTime(r io.ReaderAt, size int64, t time.Time, p *parser.Parser) (int64, error) {
+	lo, hi := int64(0), size
+	for hi-lo > seekScanSize {
+		mid := lo + (hi-lo)/2
+		stamp, start, err := firstStamp(r, size, mid, p)
+		if err != nil {
+			return 0, err
+		}
+		if stamp.IsZero() || !stamp.Before(t) {
+			hi = mid
+			continue
+		}
+		// The line at start is too early, so the answer is after it
+		lo = start + 1
+	}
+
+	// Scan the remaining region (and beyond, if needed) line by line
+	offset, err := lineStart(r, size, lo)
+	if err != nil {
+		return 0, err
+	}
+	reader := bufio.NewReader(io.NewSectionReader(r, offset, size-offset))
+	for {
+		raw, err := reader.ReadString('\n')
+		if raw == "" {
+			if err != nil && err != io.EOF {
+				return 0, err
+			}
+			return size, nil
+		}
+		if stamp := p.Time(strings.TrimRight(raw, "\r\n")); !stamp.IsZero() && !stamp.Before(t) {
+			return offset, nil
+		}
+		offset += int64(len(raw))
+	}
+}
+
+// firstStamp returns the timestamp and start offset of the first stamped
+// line starting at or after offset, looking at up to maxProbeLines lines
+func firstStamp(r io.ReaderAt, size, offset int64, p *parser.Parser) (time.Time, int64, error) {
+	start, err := lineStart(r, size, offset)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+
+	reader := bufio.NewReader(io.NewSectionReader(r, start, size-start))
+	for i := 0; i < maxProbeLines; i++ {
+		raw, err := reader.ReadString('\n')
+		if raw == "" {
+			if err != nil && err != io.EOF {
+				return time.Time{}, 0, err
+			}
+			break
+		}
+		if stamp := p.Time(strings.TrimRight(raw, "\r\n")); !stamp.IsZero() {
+			return stamp, start, nil
+		}
+		start += int64(len(raw))
+	}
+	return time.Time{}, 0, nil
+}
+
+// lineStart returns the offset of the first line starting at or after offset
+func lineStart(r io.ReaderAt, size, offset int64) (int64, error) {
+	if offset <= 0 {
+		return 0, nil
+	}
+
+	// offset starts a line if the byte before it ends one
+	reader := bufio.NewReader(io.NewSectionReader(r, offset-1, size-offset+1))
+	skipped, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+	return offset - 1 + int64(len(skipped)), nil
+}
@@ -1,14 +1,39 @@
 package server
 
 import (
+	"context"
 	"embed"
 	"encoding/json"
 	"fmt"
 	"io/fs"
+	"log"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/yourusername/weblogview/internal/alerts"
+	"github.com/yourusername/weblogview/internal/bookmarks"
+	"github.com/yourusername/weblogview/internal/compare"
 	"github.com/yourusername/weblogview/internal/config"
+	"github.com/yourusername/weblogview/internal/correlate"
+	"github.com/yourusername/weblogview/internal/export"
+	"github.com/yourusername/weblogview/internal/fieldstats"
+	"github.com/yourusername/weblogview/internal/filter"
+	"github.com/yourusername/weblogview/internal/histogram"
+	"github.com/yourusername/weblogview/internal/index"
+	"github.com/yourusername/weblogview/internal/ingest"
+	"github.com/yourusername/weblogview/internal/logline"
+	"github.com/yourusername/weblogview/internal/multiline"
+	"github.com/yourusername/weblogview/internal/parser"
+	"github.com/yourusername/weblogview/internal/query"
+	"github.com/yourusername/weblogview/internal/search"
 	"github.com/yourusername/weblogview/internal/settings"
+	"github.com/yourusername/weblogview/internal/sources"
+	"github.com/yourusername/weblogview/internal/templates"
 	"github.com/yourusername/weblogview/internal/watcher"
 	"github.com/yourusername/weblogview/internal/websocket"
 )
@@ -16,18 +41,42 @@ import (
 //go:embed static
 var staticFiles embed.FS
 
+const (
+	// maxTemplateLines limits the lines of a file mined for templates
+	maxTemplateLines = 1000000
+
+	// maxCorrelatedLines limits the lines returned for a correlation key
+	maxCorrelatedLines = 10000
+
+	// defaultCompareLines and maxCompareLines limit the lines compared per
+	// side
+	defaultCompareLines = 10000
+	maxCompareLines     = 100000
+
+	// defaultSnapshotLines and maxSnapshotLines limit the lines of an HTML
+	// snapshot
+	defaultSnapshotLines = 10000
+	maxSnapshotLines     = 100000
+)
+
 // Server represents the HTTP server
 type Server struct {
-	config *config.Config
-	hub    *websocket.Hub
+	config  *config.Config
+	hub     *websocket.Hub
+	streams *sources.Registry
+	alerts  *alerts.Engine
 }
 
 // New creates a new server instance
 func New(cfg *config.Config) *Server {
-	hub := websocket.NewHub()
+	streams := sources.NewRegistry(cfg.MaxLinesMemory)
+	engine := alerts.NewEngine(cfg, streams)
+	hub := websocket.NewHub(streams, engine)
 	return &Server{
-		config: cfg,
-		hub:    hub,
+		config:  cfg,
+		hub:     hub,
+		streams: streams,
+		alerts:  engine,
 	}
 }
 
@@ -36,17 +85,58 @@ func (s *Server) Start() error {
 	// Start the WebSocket hub
 	go s.hub.Run()
 
+	// Start evaluating alert rules
+	s.alerts.Reload()
+
+	// Start the Fluent Forward receiver if enabled
+	if s.config.ForwardAddr != "" {
+		forward := ingest.NewForwardServer(s.streams)
+		go func() {
+			if err := forward.ListenAndServe(s.config.ForwardAddr); err != nil {
+				log.Printf("Fluent Forward receiver stopped: %v", err)
+			}
+		}()
+	}
+
 	// Register HTTP handlers
 	http.HandleFunc("/", s.handleIndex)
 	http.HandleFunc("/api/health", s.handleHealth)
 	http.HandleFunc("/api/settings", s.handleSettings)
 	http.HandleFunc("/api/recent-files", s.handleRecentFiles)
 	http.HandleFunc("/api/recent-namespaces", s.handleRecentNamespaces)
+	http.HandleFunc("/api/recent-hosts", s.handleRecentHosts)
 	http.HandleFunc("/api/k8s/contexts", s.handleK8sContexts)
 	http.HandleFunc("/api/k8s/switch-context", s.handleK8sSwitchContext)
 	http.HandleFunc("/api/k8s/namespaces", s.handleK8sNamespaces)
 	http.HandleFunc("/api/k8s/pods", s.handleK8sPods)
 	http.HandleFunc("/api/k8s/containers", s.handleK8sContainers)
+	http.HandleFunc("/api/docker/containers", s.handleDockerContainers)
+	http.HandleFunc("/api/journal/units", s.handleJournalUnits)
+	http.HandleFunc("/api/ssh/hosts", s.handleSSHHosts)
+	http.HandleFunc("/api/streams", s.handleStreams)
+	http.HandleFunc("/api/search", s.handleSearch)
+	http.HandleFunc("/api/index", s.handleIndexFile)
+	http.HandleFunc("/api/time-range", s.handleTimeRange)
+	http.HandleFunc("/api/timestamp-layouts", s.handleTimestampLayouts)
+	http.HandleFunc("/api/fields", s.handleFields)
+	http.HandleFunc("/api/columns", s.handleColumns)
+	http.HandleFunc("/api/parsers", s.handleParsers)
+	http.HandleFunc("/api/parsers/test", s.handleTestParser)
+	http.HandleFunc("/api/grok-patterns", s.handleGrokPatterns)
+	http.HandleFunc("/api/multiline", s.handleMultiline)
+	http.HandleFunc("/api/access-stats", s.handleAccessStats)
+	http.HandleFunc("/api/templates", s.handleTemplates)
+	http.HandleFunc("/api/sources/", s.handleSourceHistogram)
+	http.HandleFunc("/api/alerts", s.handleAlerts)
+	http.HandleFunc("/api/alerts/rules", s.handleAlertRules)
+	http.HandleFunc("/api/anomalies", s.handleAnomalies)
+	http.HandleFunc("/api/correlate", s.handleCorrelate)
+	http.HandleFunc("/api/correlation", s.handleCorrelation)
+	http.HandleFunc("/api/compare", s.handleCompare)
+	http.HandleFunc("/api/field-stats", s.handleFieldStats)
+	http.HandleFunc("/api/bookmarks", s.handleBookmarks)
+	http.HandleFunc("/api/export", s.handleExport)
+	http.Handle("/v1/logs", ingest.NewOTLPHandler(s.streams))
 	http.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
 		websocket.HandleWebSocket(s.hub, s.config, w, r)
 	})
@@ -189,6 +279,23 @@ func (s *Server) handleRecentNamespaces(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// handleRecentHosts handles recent SSH hosts GET requests
+func (s *Server) handleRecentHosts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	appSettings := settings.GetInstance()
+	recentHosts := appSettings.GetRecentHosts()
+
+	if err := json.NewEncoder(w).Encode(recentHosts); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
 // handleK8sContexts handles listing Kubernetes contexts
 func (s *Server) handleK8sContexts(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -317,3 +424,1377 @@ func (s *Server) handleK8sContainers(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
+
+// handleDockerContainers handles listing Docker containers
+func (s *Server) handleDockerContainers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	all := r.URL.Query().Get("all") == "true"
+
+	containers, err := watcher.ListDockerContainers("", all)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to list containers: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(containers); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleJournalUnits handles listing systemd units with journal entries
+func (s *Server) handleJournalUnits(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	units, err := watcher.ListJournalUnits()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to list units: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(units); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleSSHHosts handles listing host aliases from the ssh config
+func (s *Server) handleSSHHosts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	hosts, err := watcher.ListSSHHosts()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to list hosts: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(hosts); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleStreams handles listing streams pushed by ingest receivers
+func (s *Server) handleStreams(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(s.streams.List()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleSearch searches a whole file and streams the matches as NDJSON,
+// one match per line followed by a final summary line
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	path := query.Get("path")
+	if path == "" {
+		http.Error(w, "path parameter required", http.StatusBadRequest)
+		return
+	}
+
+	opts := search.Options{
+		Query:         query.Get("q"),
+		Regex:         query.Get("regex") == "true",
+		Language:      query.Get("lang") == "true",
+		CaseSensitive: query.Get("case") == "true",
+		Parser:        parser.ForSource(path, path, ""),
+	}
+	opts.Context, _ = strconv.Atoi(query.Get("context"))
+	opts.MaxResults, _ = strconv.Atoi(query.Get("max"))
+
+	if err := search.Validate(opts); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid query: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+
+	// The search stops when the client disconnects
+	summary, err := index.Search(r.Context(), path, opts, func(m search.Match) error {
+		if err := encoder.Encode(m); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+
+	result := struct {
+		Summary search.Summary `json:"summary"`
+		Error   string         `json:"error,omitempty"`
+	}{Summary: summary}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	encoder.Encode(result)
+}
+
+// handleIndexFile reports (GET), builds or updates (POST) and deletes
+// (DELETE) the on-disk search index of a file
+func (s *Server) handleIndexFile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	path := r.URL.Query().Get("path")
+	if path == "" {
+		http.Error(w, "path parameter required", http.StatusBadRequest)
+		return
+	}
+
+	var status index.Status
+	switch r.Method {
+	case "GET":
+		status = index.Get(path)
+	case "POST":
+		var err error
+		status, err = index.Build(path)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to index file: %v", err), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	case "DELETE":
+		if err := index.Remove(path); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to remove index: %v", err), http.StatusInternalServerError)
+			return
+		}
+		status = index.Get(path)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	json.NewEncoder(w).Encode(status)
+}
+
+// handleTimeRange handles reading the lines of a file between two times
+func (s *Server) handleTimeRange(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params := r.URL.Query()
+	path := params.Get("path")
+	if path == "" {
+		http.Error(w, "path parameter required", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	var from, to time.Time
+	var err error
+	if v := params.Get("from"); v != "" {
+		if from, err = query.ParseTime(v, now); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid from: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	if v := params.Get("to"); v != "" {
+		if to, err = query.ParseTime(v, now); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid to: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	max, _ := strconv.Atoi(params.Get("max"))
+	if max <= 0 || max > s.config.ChunkSize {
+		max = s.config.ChunkSize
+	}
+
+	p := parser.ForSource(path, path, "")
+	result, err := search.ReadTimeRange(path, from, to, max, p)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read time range: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleTimestampLayouts handles per-source timestamp layout GET/POST requests
+func (s *Server) handleTimestampLayouts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	appSettings := settings.GetInstance()
+
+	switch r.Method {
+	case "GET":
+		json.NewEncoder(w).Encode(appSettings.GetTimestampLayouts())
+	case "POST":
+		var req struct {
+			Source string `json:"source"`
+			Layout string `json:"layout"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+			return
+		}
+		if req.Source == "" {
+			http.Error(w, "source required", http.StatusBadRequest)
+			return
+		}
+		if req.Layout != "" {
+			if err := parser.ValidateLayout(req.Layout); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		if err := appSettings.SetTimestampLayout(req.Source, req.Layout); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to save layout: %v", err), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(appSettings.GetTimestampLayouts())
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleFields handles discovering the fields of a file from its first lines
+func (s *Server) handleFields(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params := r.URL.Query()
+	path := params.Get("path")
+	if path == "" {
+		http.Error(w, "path parameter required", http.StatusBadRequest)
+		return
+	}
+
+	lines, _ := strconv.Atoi(params.Get("lines"))
+	if lines <= 0 || lines > s.config.ChunkSize {
+		lines = s.config.ChunkSize
+	}
+
+	// Sample the start of the file
+	texts, err := watcher.ReadFile(path, lines)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read file: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	p := parser.ForSource(path, path, "")
+	fields := parser.NewFieldSet()
+	for _, text := range texts {
+		fields.Add(p.Parse(text))
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"lines":   fields.Lines(),
+		"fields":  fields.List(),
+		"columns": settings.GetInstance().GetFieldColumns(path),
+	})
+}
+
+// handleColumns handles per-source field column GET/POST requests
+func (s *Server) handleColumns(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	appSettings := settings.GetInstance()
+
+	switch r.Method {
+	case "GET":
+		json.NewEncoder(w).Encode(appSettings.GetAllFieldColumns())
+	case "POST":
+		var req struct {
+			Source  string   `json:"source"`
+			Columns []string `json:"columns"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+			return
+		}
+		if req.Source == "" {
+			http.Error(w, "source required", http.StatusBadRequest)
+			return
+		}
+		if err := appSettings.SetFieldColumns(req.Source, req.Columns); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to save columns: %v", err), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(appSettings.GetAllFieldColumns())
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleParsers handles custom parser GET/POST/DELETE requests
+func (s *Server) handleParsers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	appSettings := settings.GetInstance()
+
+	switch r.Method {
+	case "GET":
+	case "POST":
+		var cfg settings.ParserConfig
+		if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+			return
+		}
+		if cfg.Name == "" {
+			http.Error(w, "name required", http.StatusBadRequest)
+			return
+		}
+		if cfg.Type == "" {
+			cfg.Type = parser.PatternGrok
+		}
+		if _, err := parser.CompilePattern(cfg.Type, cfg.Pattern); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := appSettings.SetParser(cfg); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to save parser: %v", err), http.StatusInternalServerError)
+			return
+		}
+	case "DELETE":
+		if err := appSettings.DeleteParser(r.URL.Query().Get("name")); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	json.NewEncoder(w).Encode(appSettings.GetParsers())
+}
+
+// handleTestParser handles trying a pattern on sample lines
+func (s *Server) handleTestParser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Type    string   `json:"type"`
+		Pattern string   `json:"pattern"`
+		Lines   []string `json:"lines"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.Type == "" {
+		req.Type = parser.PatternGrok
+	}
+
+	pattern, err := parser.CompilePattern(req.Type, req.Pattern)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// One entry per line, null where the pattern doesn't match
+	results := make([]map[string]string, len(req.Lines))
+	for i, line := range req.Lines {
+		results[i] = pattern.Fields(line)
+	}
+	json.NewEncoder(w).Encode(results)
+}
+
+// handleGrokPatterns handles listing the built-in grok patterns
+func (s *Server) handleGrokPatterns(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(parser.GrokPatterns)
+}
+
+// handleMultiline handles multi-line grouping settings GET/POST requests
+func (s *Server) handleMultiline(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	appSettings := settings.GetInstance()
+
+	switch r.Method {
+	case "GET":
+	case "POST":
+		var cfg settings.MultilineConfig
+		if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+			return
+		}
+		if _, err := multiline.NewGrouper(multiline.Options{Patterns: cfg.Patterns}, nil); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := appSettings.SetMultiline(cfg); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to save settings: %v", err), http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	json.NewEncoder(w).Encode(appSettings.GetMultiline())
+}
+
+// handleAccessStats handles aggregating the access log lines of a file
+// over a time range
+func (s *Server) handleAccessStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params := r.URL.Query()
+	path := params.Get("path")
+	if path == "" {
+		http.Error(w, "path parameter required", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	var from, to time.Time
+	var err error
+	if v := params.Get("from"); v != "" {
+		if from, err = query.ParseTime(v, now); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid from: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	if v := params.Get("to"); v != "" {
+		if to, err = query.ParseTime(v, now); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid to: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	top, _ := strconv.Atoi(params.Get("top"))
+	if top <= 0 {
+		top = 10
+	} else if top > 100 {
+		top = 100
+	}
+
+	// The scan stops when the client disconnects
+	stats, err := search.Access(r.Context(), path, from, to, top, parser.ForSource(path, path, ""))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read access log: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(stats)
+}
+
+// handleTemplates handles mining the message templates of a file
+func (s *Server) handleTemplates(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params := r.URL.Query()
+	path := params.Get("path")
+	if path == "" {
+		http.Error(w, "path parameter required", http.StatusBadRequest)
+		return
+	}
+
+	lines, _ := strconv.Atoi(params.Get("lines"))
+	if lines <= 0 || lines > maxTemplateLines {
+		lines = maxTemplateLines
+	}
+	top, _ := strconv.Atoi(params.Get("top"))
+
+	texts, err := watcher.ReadFile(path, lines)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read file: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	p := parser.ForSource(path, path, "")
+	miner := templates.NewMiner()
+	for _, text := range texts {
+		miner.Add(text, p.Time(text))
+	}
+
+	list := miner.Templates()
+	if top > 0 && len(list) > top {
+		list = list[:top]
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"lines":     len(texts),
+		"templates": list,
+	})
+}
+
+// handleSourceHistogram handles /api/sources/{id}/histogram, counting the
+// lines of an ingested stream (by stream ID) or a file (by path, URL
+// encoded) per time bucket
+func (s *Server) handleSourceHistogram(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	rest := strings.TrimPrefix(r.URL.EscapedPath(), "/api/sources/")
+	i := strings.LastIndex(rest, "/")
+	if i <= 0 || rest[i+1:] != "histogram" {
+		http.NotFound(w, r)
+		return
+	}
+	id, err := url.PathUnescape(rest[:i])
+	if err != nil || id == "" {
+		http.Error(w, "source id required", http.StatusBadRequest)
+		return
+	}
+
+	params := r.URL.Query()
+	bucket, err := histogram.ParseBucket(params.Get("bucket"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid bucket: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	var from, to time.Time
+	if v := params.Get("from"); v != "" {
+		if from, err = query.ParseTime(v, now); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid from: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	if v := params.Get("to"); v != "" {
+		if to, err = query.ParseTime(v, now); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid to: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	b := histogram.New(bucket, params.Get("by"))
+	if stream := s.streams.Get(id); stream != nil {
+		p := parser.ForSource(id, "", "")
+		for _, line := range stream.Recent(0) {
+			p.Enrich(&line)
+			t := line.Time()
+			if !t.IsZero() && ((!from.IsZero() && t.Before(from)) || (!to.IsZero() && t.After(to))) {
+				continue
+			}
+			b.Add(line)
+		}
+	} else if err := search.Histogram(r.Context(), id, from, to, b, parser.ForSource(id, id, "")); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read file: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	json.NewEncoder(w).Encode(b.Histogram())
+}
+
+// handleAlerts handles listing the recently fired alerts
+func (s *Server) handleAlerts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	json.NewEncoder(w).Encode(s.alerts.History())
+}
+
+// handleAlertRules handles listing, saving and deleting alert rules
+func (s *Server) handleAlertRules(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	appSettings := settings.GetInstance()
+
+	switch r.Method {
+	case "GET":
+	case "POST":
+		var rule settings.AlertRule
+		if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+			return
+		}
+		if err := alerts.Validate(rule); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := appSettings.SetAlertRule(rule); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to save alert rule: %v", err), http.StatusInternalServerError)
+			return
+		}
+		s.alerts.Reload()
+	case "DELETE":
+		if err := appSettings.DeleteAlertRule(r.URL.Query().Get("name")); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		s.alerts.Reload()
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	json.NewEncoder(w).Encode(appSettings.GetAlertRules())
+}
+
+// handleAnomalies handles listing the line rate anomalies of a source (all
+// sources if none is given), most recent first
+func (s *Server) handleAnomalies(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params := r.URL.Query()
+	var since time.Time
+	if v := params.Get("since"); v != "" {
+		var err error
+		if since, err = query.ParseTime(v, time.Now()); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid since: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	json.NewEncoder(w).Encode(s.alerts.Anomalies().Anomalies(params.Get("source"), since))
+}
+
+// CorrelatedSource reports the lines found in one source for a
+// correlation key
+type CorrelatedSource struct {
+	Source string `json:"source"`
+	Lines  int    `json:"lines"`
+	Error  string `json:"error,omitempty"`
+}
+
+// handleCorrelate handles finding the lines carrying a correlation key
+// (such as a trace ID) in every open source: files open in any tab
+// (searched on disk, with their index if built), the recent lines of
+// other sources open in tabs, and all ingested streams. The lines are
+// merged in time order.
+func (s *Server) handleCorrelate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params := r.URL.Query()
+	id := params.Get("id")
+	if id == "" {
+		http.Error(w, "id parameter required", http.StatusBadRequest)
+		return
+	}
+	limit, _ := strconv.Atoi(params.Get("limit"))
+	if limit <= 0 || limit > maxCorrelatedLines {
+		limit = maxCorrelatedLines
+	}
+
+	extractor, err := correlate.NewExtractor(settings.GetInstance().GetCorrelation())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid correlation settings: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	var entries []correlate.Entry
+	searched := []CorrelatedSource{}
+	truncated := false
+
+	// match adds a line if it carries the key. Each source stops at the
+	// limit; the merged lines are cut to it afterwards, so the earliest
+	// lines are kept whichever source they come from.
+	before := 0
+	match := func(source string, line logline.Line, number, offset int64) bool {
+		if len(entries)-before >= limit {
+			truncated = true
+			return false
+		}
+		if extractor.Has(line, id) {
+			entries = append(entries, correlate.Entry{Source: source, LineNumber: number, Offset: offset, Line: line})
+		}
+		return true
+	}
+
+	for _, open := range s.hub.OpenSources() {
+		before = len(entries)
+		result := CorrelatedSource{Source: open.Key}
+		if open.Path != "" {
+			// The scan stops at the limit or when the client disconnects
+			ctx, cancel := context.WithCancel(r.Context())
+			p := parser.ForSource(open.Path, open.Path, "")
+			opts := search.Options{Query: id, CaseSensitive: true}
+			_, err := index.Search(ctx, open.Path, opts, func(m search.Match) error {
+				if !match(open.Key, p.Parse(m.Text), m.Line, m.Offset) {
+					cancel()
+				}
+				return nil
+			})
+			cancel()
+			if err != nil {
+				result.Error = err.Error()
+			}
+		} else {
+			for _, line := range open.Lines {
+				if strings.Contains(line.Text, id) || len(line.Fields) > 0 {
+					if !match(open.Key, line, 0, 0) {
+						break
+					}
+				}
+			}
+		}
+		result.Lines = len(entries) - before
+		searched = append(searched, result)
+	}
+
+	for _, info := range s.streams.List() {
+		stream := s.streams.Get(info.ID)
+		if stream == nil {
+			continue
+		}
+		before = len(entries)
+		p := parser.ForSource(info.ID, "", "")
+		for _, line := range stream.Recent(0) {
+			if !strings.Contains(line.Text, id) && len(line.Fields) == 0 {
+				continue
+			}
+			p.Enrich(&line)
+			if !match(info.ID, line, 0, 0) {
+				break
+			}
+		}
+		searched = append(searched, CorrelatedSource{Source: info.ID, Lines: len(entries) - before})
+	}
+
+	merged := correlate.Merge(entries)
+	if len(merged) > limit {
+		merged = merged[:limit]
+		truncated = true
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":        id,
+		"sources":   searched,
+		"lines":     merged,
+		"truncated": truncated,
+	})
+}
+
+// handleCorrelation handles getting and setting how correlation keys are
+// extracted
+func (s *Server) handleCorrelation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	appSettings := settings.GetInstance()
+
+	switch r.Method {
+	case "GET":
+	case "POST":
+		var cfg settings.CorrelationConfig
+		if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+			return
+		}
+		if _, err := correlate.NewExtractor(cfg); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := appSettings.SetCorrelation(cfg); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to save settings: %v", err), http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	json.NewEncoder(w).Encode(appSettings.GetCorrelation())
+}
+
+// CompareSide selects the lines of one side of a comparison
+type CompareSide struct {
+	Source string `json:"source"` // File path, ingested stream ID or key of a source open in a tab
+	From   string `json:"from,omitempty"`
+	To     string `json:"to,omitempty"`
+	Lines  int    `json:"lines,omitempty"` // Files: the first lines of the range; other sources: the last
+}
+
+// handleCompare handles comparing two sources, such as a healthy and a
+// failing pod or the same file before and after a deploy
+func (s *Server) handleCompare(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		A     CompareSide `json:"a"`
+		B     CompareSide `json:"b"`
+		Align bool        `json:"align"`
+		Top   int         `json:"top"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	a, err := s.compareLines(req.A)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read a: %v", err), http.StatusBadRequest)
+		return
+	}
+	b, err := s.compareLines(req.B)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read b: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	json.NewEncoder(w).Encode(compare.Compare(a, b, compare.Options{Top: req.Top, Align: req.Align}))
+}
+
+// compareLines reads the lines of one side of a comparison
+func (s *Server) compareLines(side CompareSide) ([]logline.Line, error) {
+	if side.Source == "" {
+		return nil, fmt.Errorf("source required")
+	}
+	maxLines := side.Lines
+	if maxLines <= 0 {
+		maxLines = defaultCompareLines
+	} else if maxLines > maxCompareLines {
+		maxLines = maxCompareLines
+	}
+
+	now := time.Now()
+	var from, to time.Time
+	var err error
+	if side.From != "" {
+		if from, err = query.ParseTime(side.From, now); err != nil {
+			return nil, fmt.Errorf("invalid from: %v", err)
+		}
+	}
+	if side.To != "" {
+		if to, err = query.ParseTime(side.To, now); err != nil {
+			return nil, fmt.Errorf("invalid to: %v", err)
+		}
+	}
+
+	if buffered, ok := s.bufferedLines(side.Source); ok {
+		var lines []logline.Line
+		for _, line := range buffered {
+			if inTimeRange(line, from, to) {
+				lines = append(lines, line)
+			}
+		}
+		if len(lines) > maxLines {
+			lines = lines[len(lines)-maxLines:]
+		}
+		return lines, nil
+	}
+
+	p := parser.ForSource(side.Source, side.Source, "")
+	block, err := search.ReadTimeRange(side.Source, from, to, maxLines, p)
+	if err != nil {
+		return nil, err
+	}
+	lines := make([]logline.Line, len(block.Lines))
+	for i, text := range block.Lines {
+		lines[i] = p.Parse(text)
+	}
+	return lines, nil
+}
+
+// bufferedLines returns the lines held in memory for a source, enriched
+// by its parser: the buffer of an ingested stream or the recent lines of a
+// source open in a tab that can't be read again. Files aren't buffered.
+func (s *Server) bufferedLines(source string) ([]logline.Line, bool) {
+	var buffered []logline.Line
+	found := false
+	if stream := s.streams.Get(source); stream != nil {
+		buffered, found = stream.Recent(0), true
+	} else {
+		for _, open := range s.hub.OpenSources() {
+			if open.Key == source && open.Path == "" {
+				buffered, found = open.Lines, true
+				break
+			}
+		}
+	}
+	if !found {
+		return nil, false
+	}
+
+	p := parser.ForSource(source, "", "")
+	lines := make([]logline.Line, len(buffered))
+	for i, line := range buffered {
+		p.Enrich(&line)
+		lines[i] = line
+	}
+	return lines, true
+}
+
+// timeRangeParams parses the from and to parameters of a request (see
+// query.ParseTime); missing ones are zero
+func timeRangeParams(params url.Values) (from, to time.Time, err error) {
+	now := time.Now()
+	if v := params.Get("from"); v != "" {
+		if from, err = query.ParseTime(v, now); err != nil {
+			return from, to, fmt.Errorf("Invalid from: %v", err)
+		}
+	}
+	if v := params.Get("to"); v != "" {
+		if to, err = query.ParseTime(v, now); err != nil {
+			return from, to, fmt.Errorf("Invalid to: %v", err)
+		}
+	}
+	return from, to, nil
+}
+
+// filterParams compiles the filter given by the include and exclude
+// (repeatable), case, q and minLevel parameters of a request
+func filterParams(params url.Values) (*filter.Filter, error) {
+	return filter.New(filter.Options{
+		Include:       params["include"],
+		Exclude:       params["exclude"],
+		CaseSensitive: params.Get("case") == "true",
+		Query:         params.Get("q"),
+		MinLevel:      params.Get("minLevel"),
+	})
+}
+
+// inTimeRange reports whether a line is stamped from "from" through "to"
+// (zero times leave the range open); lines without a timestamp always are
+func inTimeRange(line logline.Line, from, to time.Time) bool {
+	t := line.Time()
+	return t.IsZero() || ((from.IsZero() || !t.Before(from)) && (to.IsZero() || !t.After(to)))
+}
+
+// handleFieldStats handles summarizing fields of structured logs: the top
+// values, cardinality and numeric percentiles of the lines passing a
+// filter in a time range, over the loaded window (the default) or the
+// whole file (scope=file). The result is streamed as JSON lines: progress
+// reports while a file is scanned, then the stats.
+func (s *Server) handleFieldStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params := r.URL.Query()
+	source := params.Get("source")
+	if source == "" {
+		http.Error(w, "source parameter required", http.StatusBadRequest)
+		return
+	}
+	fields := params["field"]
+	if len(fields) == 0 {
+		http.Error(w, "field parameter required", http.StatusBadRequest)
+		return
+	}
+	scope := params.Get("scope")
+	if scope != "" && scope != "window" && scope != "file" {
+		http.Error(w, "scope must be window or file", http.StatusBadRequest)
+		return
+	}
+
+	from, to, err := timeRangeParams(params)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f, err := filterParams(params)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid filter: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// The loaded window holds at most as many lines as a tab keeps
+	window := s.config.MaxLinesMemory
+	if lines, _ := strconv.Atoi(params.Get("lines")); lines > 0 && (window <= 0 || lines < window) {
+		window = lines
+	}
+	if scope == "file" {
+		window = 0
+	}
+
+	top, _ := strconv.Atoi(params.Get("top"))
+	if top <= 0 {
+		top = 10
+	} else if top > 100 {
+		top = 100
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+
+	a := fieldstats.New(fields...)
+	if buffered, ok := s.bufferedLines(source); ok {
+		var lines []logline.Line
+		for _, line := range buffered {
+			if inTimeRange(line, from, to) && f.MatchLine(line) {
+				lines = append(lines, line)
+			}
+		}
+		if window > 0 && len(lines) > window {
+			lines = lines[len(lines)-window:]
+		}
+		for _, line := range lines {
+			a.Add(line)
+		}
+	} else {
+		opts := search.FieldOptions{
+			From:   from,
+			To:     to,
+			Filter: f,
+			Window: window,
+			Parser: parser.ForSource(source, source, ""),
+		}
+		// The scan stops when the client disconnects
+		err = search.FieldStats(r.Context(), source, opts, a, func(p search.Progress) {
+			encoder.Encode(struct {
+				Progress search.Progress `json:"progress"`
+			}{p})
+			if flusher != nil {
+				flusher.Flush()
+			}
+		})
+	}
+
+	result := struct {
+		Stats fieldstats.Result `json:"stats"`
+		Error string            `json:"error,omitempty"`
+	}{Stats: a.Result(top)}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	if err := encoder.Encode(result); err != nil {
+		log.Printf("Field stats of %s failed: %v", source, err)
+	}
+}
+
+// handleBookmarks handles listing (GET), creating (POST) and deleting
+// (DELETE ?id=N) bookmarks. Listed with a source, the bookmarks are
+// resolved to its current lines; a deletion returns the remaining ones.
+func (s *Server) handleBookmarks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	store := bookmarks.GetInstance()
+
+	switch r.Method {
+	case "GET":
+		source := r.URL.Query().Get("source")
+		if source == "" {
+			json.NewEncoder(w).Encode(store.List(""))
+			return
+		}
+		positions, err := s.resolveBookmarks(source, store.List(source))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to resolve bookmarks: %v", err), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(positions)
+	case "POST":
+		var b bookmarks.Bookmark
+		if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+			return
+		}
+		b, err := store.Add(b)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to save bookmark: %v", err), http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(b)
+	case "DELETE":
+		id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "id parameter required", http.StatusBadRequest)
+			return
+		}
+		if err := store.Delete(id); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(store.List(""))
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// resolveBookmarks finds the lines of a source's bookmarks: in the lines
+// held in memory for streams and sources open in tabs, otherwise in the
+// file. Bookmarks of sources that aren't open are returned unresolved.
+func (s *Server) resolveBookmarks(source string, list []bookmarks.Bookmark) ([]bookmarks.Position, error) {
+	if lines, ok := s.bufferedLines(source); ok {
+		return bookmarks.ResolveLines(list, lines), nil
+	}
+	if info, err := os.Stat(source); err == nil && info.Mode().IsRegular() {
+		return bookmarks.ResolveFile(source, list)
+	}
+	return bookmarks.ResolveLines(list, nil), nil
+}
+
+// handleExport handles exporting the lines of a source that pass a filter
+// in a time range as plain text, NDJSON or CSV, optionally gzipped. Files
+// are read on the backend and streamed as they are read, so an export
+// isn't limited by memory; other sources export the lines held in memory.
+// format=html produces a self-contained HTML snapshot of the last lines
+// instead.
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params := r.URL.Query()
+	source := params.Get("source")
+	if source == "" {
+		http.Error(w, "source parameter required", http.StatusBadRequest)
+		return
+	}
+	from, to, err := timeRangeParams(params)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f, err := filterParams(params)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid filter: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	buffered, isBuffered := s.bufferedLines(source)
+	if !isBuffered {
+		if _, err := os.Stat(source); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to read file: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	format := params.Get("format")
+	compress := params.Get("gzip") == "true"
+	if format == export.FormatHTML {
+		viewer, err := fs.ReadFile(staticFiles, "static/snapshot/snapshot.js")
+		if err != nil {
+			http.Error(w, "The snapshot viewer isn't built; run npm run build in web/", http.StatusServiceUnavailable)
+			return
+		}
+		maxLines, _ := strconv.Atoi(params.Get("lines"))
+		if maxLines <= 0 {
+			maxLines = defaultSnapshotLines
+		} else if maxLines > maxSnapshotLines {
+			maxLines = maxSnapshotLines
+		}
+		snap, err := s.snapshot(r.Context(), source, buffered, isBuffered, from, to, f, maxLines)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to read file: %v", err), http.StatusInternalServerError)
+			return
+		}
+		snap.Filter = filterDescription(params)
+
+		w.Header().Set("Content-Type", export.ContentType(format, compress))
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", exportName(source)+export.Extension(format, compress)))
+		if err := export.WriteSnapshot(w, snap, viewer, compress); err != nil {
+			log.Printf("Snapshot of %s failed: %v", source, err)
+		}
+		return
+	}
+
+	var columns []string
+	for _, column := range strings.Split(params.Get("columns"), ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			columns = append(columns, column)
+		}
+	}
+	ew, err := export.NewWriter(w, format, columns, compress)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid export: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", export.ContentType(format, compress))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", exportName(source)+export.Extension(format, compress)))
+
+	if isBuffered {
+		for _, line := range buffered {
+			if !inTimeRange(line, from, to) || !f.MatchLine(line) {
+				continue
+			}
+			if err = ew.Write(export.Record{Source: source, Line: line}); err != nil {
+				break
+			}
+		}
+	} else {
+		// The scan stops when the client disconnects
+		err = search.Scan(r.Context(), source, from, to, parser.ForSource(source, source, ""), func(offset int64, line logline.Line) error {
+			if !f.MatchLine(line) {
+				return nil
+			}
+			return ew.Write(export.Record{Source: source, Offset: offset, Line: line})
+		})
+	}
+	if err != nil {
+		// Headers are out; leave the download visibly cut short
+		log.Printf("Export of %s failed: %v", source, err)
+		return
+	}
+	ew.Close()
+}
+
+// exportName returns the base name of an export of a source: the file name
+// for files, the source key with separators replaced otherwise
+func exportName(source string) string {
+	name := filepath.Base(source)
+	if strings.Contains(source, ":") {
+		name = strings.Map(func(r rune) rune {
+			if r == ':' || r == '/' || r == '\\' {
+				return '-'
+			}
+			return r
+		}, source)
+	}
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	if name == "" || name == "." {
+		name = "export"
+	}
+	return name
+}
+
+// snapshot collects the last maxLines lines of a source passing a filter
+// in a time range, with the source's bookmarks marked on their lines
+func (s *Server) snapshot(ctx context.Context, source string, buffered []logline.Line, isBuffered bool, from, to time.Time, f *filter.Filter, maxLines int) (export.Snapshot, error) {
+	snap := export.Snapshot{Source: source, Created: time.Now()}
+	list := bookmarks.GetInstance().List(source)
+
+	// Bookmarks by line: the position in the buffer, or the file offset
+	marked := map[int64][]int{}
+	var positions []bookmarks.Position
+	if isBuffered {
+		positions = bookmarks.ResolveLines(list, buffered)
+	} else {
+		var err error
+		if positions, err = bookmarks.ResolveFile(source, list); err != nil {
+			return snap, err
+		}
+	}
+	for i, p := range positions {
+		if !p.Found {
+			continue
+		}
+		key := p.Offset
+		if isBuffered {
+			key = p.Line - 1
+		}
+		marked[key] = append(marked[key], i)
+	}
+
+	// Keep a ring of the last maxLines matches so memory stays bounded
+	type kept struct {
+		key  int64
+		line logline.Line
+	}
+	ring := make([]kept, 0, maxLines)
+	start := 0
+	keep := func(key int64, line logline.Line) {
+		if len(ring) < maxLines {
+			ring = append(ring, kept{key, line})
+			return
+		}
+		ring[start] = kept{key, line}
+		start = (start + 1) % maxLines
+		snap.Truncated = true
+	}
+
+	if isBuffered {
+		for i, line := range buffered {
+			if inTimeRange(line, from, to) && f.MatchLine(line) {
+				keep(int64(i), line)
+			}
+		}
+	} else {
+		err := search.Scan(ctx, source, from, to, parser.ForSource(source, source, ""), func(offset int64, line logline.Line) error {
+			if f.MatchLine(line) {
+				keep(offset, line)
+			}
+			return nil
+		})
+		if err != nil {
+			return snap, err
+		}
+	}
+
+	shown := map[int]bool{}
+	for _, k := range append(ring[start:], ring[:start]...) {
+		line := export.SnapshotLine{Line: k.line}
+		for _, i := range marked[k.key] {
+			if line.Bookmark == nil {
+				line.Bookmark = &positions[i].Bookmark
+			}
+			shown[i] = true
+		}
+		snap.Lines = append(snap.Lines, line)
+	}
+	for i, p := range positions {
+		snap.Bookmarks = append(snap.Bookmarks, export.SnapshotBookmark{Position: p, Shown: shown[i]})
+	}
+	return snap, nil
+}
+
+// filterDescription describes the filter and time range parameters of a
+// request, one condition per entry
+func filterDescription(params url.Values) []string {
+	var list []string
+	for _, pattern := range params["include"] {
+		list = append(list, "include: "+pattern)
+	}
+	for _, pattern := range params["exclude"] {
+		list = append(list, "exclude: "+pattern)
+	}
+	if params.Get("case") == "true" {
+		list = append(list, "case sensitive")
+	}
+	for _, name := range []string{"q", "minLevel", "from", "to"} {
+		if v := params.Get(name); v != "" {
+			list = append(list, name+": "+v)
+		}
+	}
+	return list
+}
@@ -2,20 +2,67 @@ package settings
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"sync"
 )
 
+// ParserConfig is a user-defined parser for a custom line format
+type ParserConfig struct {
+	Name       string   `json:"name"`
+	Type       string   `json:"type"` // "grok" or "regex" (Go regular expression with named groups)
+	Pattern    string   `json:"pattern"`
+	Files      []string `json:"files,omitempty"`      // File path globs (matched against the full path or the file name)
+	Containers []string `json:"containers,omitempty"` // K8s container names
+}
+
+// MultilineConfig controls joining stack traces and wrapped messages into
+// single events
+type MultilineConfig struct {
+	Enabled        bool     `json:"enabled"`                  // Group lines of unstructured sources by default
+	Patterns       []string `json:"patterns,omitempty"`       // Regexes of continuation lines (default: indentation, Caused by:, Traceback, exception headers)
+	Timestamp      bool     `json:"timestamp"`                // Lines that don't start with a timestamp continue the previous event
+	MaxLines       int      `json:"maxLines,omitempty"`       // Maximum lines per event (default: 500)
+	FlushTimeoutMs int      `json:"flushTimeoutMs,omitempty"` // How long a live event waits for more lines (default: 1000ms)
+}
+
+// CorrelationConfig controls how the correlation key (such as a trace ID)
+// of a line is found
+type CorrelationConfig struct {
+	Fields   []string `json:"fields,omitempty"`   // Field names holding the key, first found wins (default: common trace ID names)
+	Patterns []string `json:"patterns,omitempty"` // Regexes whose first group is the key, tried after the fields (default: W3C traceparent)
+}
+
+// AlertRule fires an alert when its pattern matches a number of lines
+// within a sliding time window
+type AlertRule struct {
+	Name          string   `json:"name"`
+	Pattern       string   `json:"pattern"`                 // Query language expression, or a Go regular expression when Regex is set
+	Regex         bool     `json:"regex,omitempty"`         // Match Pattern as a regular expression on the line text
+	Threshold     int      `json:"threshold,omitempty"`     // Matches within the window that fire the alert (default: 1)
+	WindowSeconds int      `json:"windowSeconds,omitempty"` // Length of the sliding window (default: 60)
+	Sources       []string `json:"sources,omitempty"`       // File paths or source IDs (globs allowed); empty for every source
+	Webhook       string   `json:"webhook,omitempty"`       // URL that alerts are POSTed to as JSON
+	Disabled      bool     `json:"disabled,omitempty"`
+}
+
 // Settings represents application settings
 type Settings struct {
-	TailLines            int      `json:"tailLines"`            // Number of lines to load initially
-	RenderAnsiTopPane    bool     `json:"renderAnsiTopPane"`    // Render ANSI codes in top pane (default: true - prettified)
-	RenderAnsiBottomPane bool     `json:"renderAnsiBottomPane"` // Render ANSI codes in bottom pane (default: true - prettified)
-	PollingIntervalMs    int      `json:"pollingIntervalMs"`    // Polling interval in milliseconds (default: 500ms)
-	SourceNameFormat     string   `json:"sourceNameFormat"`     // Format for merged log source names: "container", "pod", or "namespace/pod"
-	RecentFiles          []string `json:"recentFiles"`          // Recently opened files (max 10)
-	RecentNamespaces     []string `json:"recentNamespaces"`     // Recently used K8s namespaces (max 10)
+	TailLines            int                 `json:"tailLines"`            // Number of lines to load initially
+	RenderAnsiTopPane    bool                `json:"renderAnsiTopPane"`    // Render ANSI codes in top pane (default: true - prettified)
+	RenderAnsiBottomPane bool                `json:"renderAnsiBottomPane"` // Render ANSI codes in bottom pane (default: true - prettified)
+	PollingIntervalMs    int                 `json:"pollingIntervalMs"`    // Polling interval in milliseconds (default: 500ms)
+	SourceNameFormat     string              `json:"sourceNameFormat"`     // Format for merged log source names: "container", "pod", or "namespace/pod"
+	RecentFiles          []string            `json:"recentFiles"`          // Recently opened files (max 10)
+	RecentNamespaces     []string            `json:"recentNamespaces"`     // Recently used K8s namespaces (max 10)
+	RecentHosts          []string            `json:"recentHosts"`          // Recently used SSH host aliases (max 10)
+	TimestampLayouts     map[string]string   `json:"timestampLayouts"`     // Go time layouts by source (file path or source ID)
+	FieldColumns         map[string][]string `json:"fieldColumns"`         // Fields shown as columns by source
+	Parsers              []ParserConfig      `json:"parsers"`              // Custom line parsers, first match wins
+	Multiline            MultilineConfig     `json:"multiline"`            // Multi-line event grouping
+	AlertRules           []AlertRule         `json:"alertRules"`           // Alert rules evaluated on every watched source
+	Correlation          CorrelationConfig   `json:"correlation"`          // Correlation key extraction
 	mu                   sync.RWMutex
 }
 
@@ -35,6 +82,11 @@ func GetInstance() *Settings {
 			SourceNameFormat:     "container", // Default to container name
 			RecentFiles:          []string{},  // Empty list
 			RecentNamespaces:     []string{},  // Empty list
+			RecentHosts:          []string{},  // Empty list
+			TimestampLayouts:     map[string]string{},
+			FieldColumns:         map[string][]string{},
+			Parsers:              []ParserConfig{},
+			AlertRules:           []AlertRule{},
 		}
 		instance.Load()
 	})
@@ -198,6 +250,272 @@ func (s *Settings) GetRecentNamespaces() []string {
 	return result
 }
 
+// AddRecentHost adds an SSH host alias to the recent list
+func (s *Settings) AddRecentHost(host string) error {
+	if host == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Remove if already exists
+	for i, h := range s.RecentHosts {
+		if h == host {
+			s.RecentHosts = append(s.RecentHosts[:i], s.RecentHosts[i+1:]...)
+			break
+		}
+	}
+
+	// Add to front
+	s.RecentHosts = append([]string{host}, s.RecentHosts...)
+
+	// Keep only last 10
+	if len(s.RecentHosts) > 10 {
+		s.RecentHosts = s.RecentHosts[:10]
+	}
+
+	// Save to disk
+	return s.saveUnlocked()
+}
+
+// GetRecentHosts returns the list of recent SSH hosts
+func (s *Settings) GetRecentHosts() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Return a copy to prevent external modification
+	result := make([]string, len(s.RecentHosts))
+	copy(result, s.RecentHosts)
+	return result
+}
+
+// SetTimestampLayout sets the timestamp layout of a source; an empty
+// layout removes it
+func (s *Settings) SetTimestampLayout(source, layout string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.TimestampLayouts == nil {
+		s.TimestampLayouts = map[string]string{}
+	}
+	if layout == "" {
+		delete(s.TimestampLayouts, source)
+	} else {
+		s.TimestampLayouts[source] = layout
+	}
+
+	return s.saveUnlocked()
+}
+
+// GetTimestampLayout returns the timestamp layout of a source, if any
+func (s *Settings) GetTimestampLayout(source string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.TimestampLayouts[source]
+}
+
+// GetTimestampLayouts returns the timestamp layouts of all sources
+func (s *Settings) GetTimestampLayouts() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Return a copy to prevent external modification
+	result := make(map[string]string, len(s.TimestampLayouts))
+	for source, layout := range s.TimestampLayouts {
+		result[source] = layout
+	}
+	return result
+}
+
+// SetFieldColumns sets the fields shown as columns for a source; no
+// columns removes the entry
+func (s *Settings) SetFieldColumns(source string, columns []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.FieldColumns == nil {
+		s.FieldColumns = map[string][]string{}
+	}
+	if len(columns) == 0 {
+		delete(s.FieldColumns, source)
+	} else {
+		s.FieldColumns[source] = append([]string(nil), columns...)
+	}
+
+	return s.saveUnlocked()
+}
+
+// GetFieldColumns returns the fields shown as columns for a source
+func (s *Settings) GetFieldColumns(source string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]string(nil), s.FieldColumns[source]...)
+}
+
+// GetAllFieldColumns returns the column settings of all sources
+func (s *Settings) GetAllFieldColumns() map[string][]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Return a copy to prevent external modification
+	result := make(map[string][]string, len(s.FieldColumns))
+	for source, columns := range s.FieldColumns {
+		result[source] = append([]string(nil), columns...)
+	}
+	return result
+}
+
+// GetParsers returns the custom parsers
+func (s *Settings) GetParsers() []ParserConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]ParserConfig, len(s.Parsers))
+	copy(result, s.Parsers)
+	return result
+}
+
+// SetParser adds a custom parser or replaces the one with the same name
+func (s *Settings) SetParser(cfg ParserConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, existing := range s.Parsers {
+		if existing.Name == cfg.Name {
+			s.Parsers[i] = cfg
+			return s.saveUnlocked()
+		}
+	}
+	s.Parsers = append(s.Parsers, cfg)
+
+	return s.saveUnlocked()
+}
+
+// DeleteParser removes a custom parser by name
+func (s *Settings) DeleteParser(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, existing := range s.Parsers {
+		if existing.Name == name {
+			s.Parsers = append(s.Parsers[:i], s.Parsers[i+1:]...)
+			return s.saveUnlocked()
+		}
+	}
+	return fmt.Errorf("parser not found: %s", name)
+}
+
+// FindParser returns the first custom parser assigned to a file path or a
+// K8s container name
+func (s *Settings) FindParser(path, container string) (ParserConfig, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, cfg := range s.Parsers {
+		if path != "" {
+			for _, glob := range cfg.Files {
+				if matched, _ := filepath.Match(glob, path); matched {
+					return cfg, true
+				}
+				if matched, _ := filepath.Match(glob, filepath.Base(path)); matched {
+					return cfg, true
+				}
+			}
+		}
+		if container != "" {
+			for _, name := range cfg.Containers {
+				if name == container {
+					return cfg, true
+				}
+			}
+		}
+	}
+	return ParserConfig{}, false
+}
+
+// GetMultiline returns the multi-line grouping settings
+func (s *Settings) GetMultiline() MultilineConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cfg := s.Multiline
+	cfg.Patterns = append([]string(nil), s.Multiline.Patterns...)
+	return cfg
+}
+
+// SetMultiline sets the multi-line grouping settings
+func (s *Settings) SetMultiline(cfg MultilineConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Multiline = cfg
+	return s.saveUnlocked()
+}
+
+// GetAlertRules returns the alert rules
+func (s *Settings) GetAlertRules() []AlertRule {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]AlertRule, len(s.AlertRules))
+	for i, rule := range s.AlertRules {
+		rule.Sources = append([]string(nil), rule.Sources...)
+		result[i] = rule
+	}
+	return result
+}
+
+// SetAlertRule adds an alert rule or replaces the one with the same name
+func (s *Settings) SetAlertRule(rule AlertRule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, existing := range s.AlertRules {
+		if existing.Name == rule.Name {
+			s.AlertRules[i] = rule
+			return s.saveUnlocked()
+		}
+	}
+	s.AlertRules = append(s.AlertRules, rule)
+
+	return s.saveUnlocked()
+}
+
+// DeleteAlertRule removes an alert rule by name
+func (s *Settings) DeleteAlertRule(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, existing := range s.AlertRules {
+		if existing.Name == name {
+			s.AlertRules = append(s.AlertRules[:i], s.AlertRules[i+1:]...)
+			return s.saveUnlocked()
+		}
+	}
+	return fmt.Errorf("alert rule not found: %s", name)
+}
+
+// GetCorrelation returns the correlation key settings
+func (s *Settings) GetCorrelation() CorrelationConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return CorrelationConfig{
+		Fields:   append([]string(nil), s.Correlation.Fields...),
+		Patterns: append([]string(nil), s.Correlation.Patterns...),
+	}
+}
+
+// SetCorrelation sets the correlation key settings
+func (s *Settings) SetCorrelation(cfg CorrelationConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Correlation = cfg
+	return s.saveUnlocked()
+}
+
 // saveUnlocked saves settings without locking (internal use only)
 func (s *Settings) saveUnlocked() error {
 	settingsPath := getSettingsPath()
@@ -0,0 +1,209 @@
+package sources
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yourusername/weblogview/internal/logline"
+)
+
+// Stream is a named log stream that lines are pushed into, e.g. by an
+// ingest receiver. It keeps a ring buffer of recent lines so new
+// subscribers can be sent the latest history.
+type Stream struct {
+	ID   string
+	Name string
+	Kind string
+
+	mu          sync.RWMutex
+	lines       []logline.Line
+	start       int // Index of the oldest line once the buffer is full
+	maxLines    int
+	total       int64
+	lastSeen    time.Time
+	subscribers map[int]func([]logline.Line)
+	nextSubID   int
+	observer    func(*Stream, []logline.Line) // Registry-wide observer, see Registry.Observe
+}
+
+// StreamInfo describes a stream for listing
+type StreamInfo struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	Kind     string    `json:"kind"`
+	Lines    int64     `json:"lines"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// Append adds lines to the stream and forwards them to subscribers
+func (s *Stream) Append(lines []logline.Line) {
+	if len(lines) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	for _, line := range lines {
+		if len(s.lines) < s.maxLines {
+			s.lines = append(s.lines, line)
+		} else {
+			s.lines[s.start] = line
+			s.start = (s.start + 1) % s.maxLines
+		}
+	}
+	s.total += int64(len(lines))
+	s.lastSeen = time.Now()
+
+	subscribers := make([]func([]logline.Line), 0, len(s.subscribers))
+	for _, fn := range s.subscribers {
+		subscribers = append(subscribers, fn)
+	}
+
+	observer := s.observer
+	s.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(lines)
+	}
+	if observer != nil {
+		observer(s, lines)
+	}
+}
+
+// Subscribe registers fn to receive new lines and returns the last n
+// buffered lines (all if n <= 0) together with a function that removes
+// the subscription
+func (s *Stream) Subscribe(n int, fn func([]logline.Line)) ([]logline.Line, func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.nextSubID
+	s.nextSubID++
+	s.subscribers[id] = fn
+
+	unsubscribe := func() {
+		s.mu.Lock()
+		delete(s.subscribers, id)
+		s.mu.Unlock()
+	}
+
+	return s.recentUnlocked(n), unsubscribe
+}
+
+// Recent returns the last n buffered lines (all if n <= 0)
+func (s *Stream) Recent(n int) []logline.Line {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.recentUnlocked(n)
+}
+
+// recentUnlocked returns the last n buffered lines in order (internal use only)
+func (s *Stream) recentUnlocked(n int) []logline.Line {
+	ordered := make([]logline.Line, 0, len(s.lines))
+	ordered = append(ordered, s.lines[s.start:]...)
+	ordered = append(ordered, s.lines[:s.start]...)
+
+	if n > 0 && len(ordered) > n {
+		ordered = ordered[len(ordered)-n:]
+	}
+	return ordered
+}
+
+// Info returns a summary of the stream
+func (s *Stream) Info() StreamInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return StreamInfo{
+		ID:       s.ID,
+		Name:     s.Name,
+		Kind:     s.Kind,
+		Lines:    s.total,
+		LastSeen: s.lastSeen,
+	}
+}
+
+// Registry holds the streams known to the server
+type Registry struct {
+	mu       sync.RWMutex
+	streams  map[string]*Stream
+	maxLines int
+	observer func(*Stream, []logline.Line)
+}
+
+// NewRegistry creates a registry whose streams buffer up to maxLines lines each
+func NewRegistry(maxLines int) *Registry {
+	if maxLines <= 0 {
+		maxLines = 10000
+	}
+	return &Registry{
+		streams:  make(map[string]*Stream),
+		maxLines: maxLines,
+	}
+}
+
+// StreamID returns the registry key of a stream
+func StreamID(kind, name string) string {
+	return kind + ":" + name
+}
+
+// GetOrCreate returns the stream with the given kind and name, creating it if needed
+func (r *Registry) GetOrCreate(kind, name string) *Stream {
+	id := StreamID(kind, name)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stream, ok := r.streams[id]; ok {
+		return stream
+	}
+
+	stream := &Stream{
+		ID:          id,
+		Name:        name,
+		Kind:        kind,
+		maxLines:    r.maxLines,
+		subscribers: make(map[int]func([]logline.Line)),
+		observer:    r.observer,
+	}
+	r.streams[id] = stream
+	return stream
+}
+
+// Observe registers fn to receive the lines appended to every stream,
+// including streams created later, whether or not anyone subscribed
+func (r *Registry) Observe(fn func(*Stream, []logline.Line)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.observer = fn
+	for _, stream := range r.streams {
+		stream.mu.Lock()
+		stream.observer = fn
+		stream.mu.Unlock()
+	}
+}
+
+// Get returns the stream with the given ID, or nil if it doesn't exist
+func (r *Registry) Get(id string) *Stream {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.streams[id]
+}
+
+// List returns a summary of all streams sorted by ID
+func (r *Registry) List() []StreamInfo {
+	r.mu.RLock()
+	streams := make([]*Stream, 0, len(r.streams))
+	for _, stream := range r.streams {
+		streams = append(streams, stream)
+	}
+	r.mu.RUnlock()
+
+	infos := make([]StreamInfo, 0, len(streams))
+	for _, stream := range streams {
+		infos = append(infos, stream.Info())
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+
+	return infos
+}
@@ -0,0 +1,327 @@
+package templates
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// treeDepth is the number of leading tokens that route a line to its
+	// candidate clusters
+	treeDepth = 2
+
+	// maxChildren limits the branches of a tree node; further tokens share
+	// the wildcard branch
+	maxChildren = 100
+
+	// similarityThreshold is the fraction of equal tokens a line needs to
+	// join a cluster
+	similarityThreshold = 0.4
+
+	// maxTokens limits the tokens considered per line
+	maxTokens = 100
+
+	// maxClusters limits the templates a miner tracks; lines that would
+	// start a new one after that get no template
+	maxClusters = 10000
+
+	// maxExamples is the number of example lines kept per template
+	maxExamples = 3
+
+	// maxExampleLength truncates long example lines
+	maxExampleLength = 1000
+
+	// Wildcard marks a variable position in a template
+	Wildcard = "<*>"
+)
+
+var (
+	uuidRe = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	ipRe   = regexp.MustCompile(`^\d{1,3}(?:\.\d{1,3}){3}(?::\d+)?$`)
+	hexRe  = regexp.MustCompile(`^(?:0x)?[0-9a-fA-F]{8,}$`)
+	numRe  = regexp.MustCompile(`^[-+]?\d+(?:[.,]\d+)*(?:[a-zA-Zµ%]{1,3})?$`)
+	timeRe = regexp.MustCompile(`^[\d:.,\-/+TZ]+$`)
+)
+
+// Template is a kind of message with its variable parts masked
+type Template struct {
+	ID        int      `json:"id"`
+	Pattern   string   `json:"template"`
+	Count     int64    `json:"count"`
+	FirstSeen int64    `json:"firstSeen,omitempty"` // Unix milliseconds, 0 if no line had a time
+	LastSeen  int64    `json:"lastSeen,omitempty"`
+	Examples  []string `json:"examples"`
+}
+
+// cluster is a template being mined
+type cluster struct {
+	id        int
+	tokens    []string
+	count     int64
+	firstSeen int64
+	lastSeen  int64
+	examples  []string
+}
+
+// node is a node of the prefix tree
+type node struct {
+	children map[string]*node
+	clusters []*cluster
+}
+
+// Miner groups lines into templates online, following the Drain
+// algorithm: lines are tokenized, variable-looking tokens (numbers, IPs,
+// UUIDs, hex IDs) are masked, a fixed-depth prefix tree on the token count
+// and leading tokens picks candidate templates, and the line joins the
+// most similar one (turning differing tokens into <*>) or starts a new
+// one. It is safe for concurrent use.
+type Miner struct {
+	mu       sync.Mutex
+	byLength map[int]*node
+	clusters []*cluster // Indexed by ID-1
+}
+
+// NewMiner creates an empty miner
+func NewMiner() *Miner {
+	return &Miner{byLength: map[int]*node{}}
+}
+
+// Add mines a line seen at t (the zero time if unknown) and returns its
+// template ID, or 0 if the miner is full
+func (m *Miner) Add(text string, t time.Time) int {
+	var seen int64
+	if !t.IsZero() {
+		seen = t.UnixMilli()
+	}
+	tokens := tokenize(text)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	leaf := m.leaf(tokens, true)
+	c := bestMatch(leaf.clusters, tokens)
+	if c == nil {
+		if len(m.clusters) >= maxClusters {
+			return 0
+		}
+		c = &cluster{
+			id:        len(m.clusters) + 1,
+			tokens:    tokens,
+			firstSeen: seen,
+		}
+		m.clusters = append(m.clusters, c)
+		leaf.clusters = append(leaf.clusters, c)
+	} else {
+		for i, token := range tokens {
+			if c.tokens[i] != token {
+				c.tokens[i] = Wildcard
+			}
+		}
+	}
+
+	c.count++
+	if seen != 0 {
+		if seen > c.lastSeen {
+			c.lastSeen = seen
+		}
+		if c.firstSeen == 0 || seen < c.firstSeen {
+			c.firstSeen = seen
+		}
+	}
+	if len(c.examples) < maxExamples {
+		if len(text) > maxExampleLength {
+			text = text[:maxExampleLength]
+		}
+		c.examples = append(c.examples, text)
+	}
+	return c.id
+}
+
+// Match returns the ID of the template a line belongs to without changing
+// the templates, or 0 if none fits
+func (m *Miner) Match(text string) int {
+	tokens := tokenize(text)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	leaf := m.leaf(tokens, false)
+	if leaf == nil {
+		return 0
+	}
+	if c := bestMatch(leaf.clusters, tokens); c != nil {
+		return c.id
+	}
+	return 0
+}
+
+// Templates returns the templates, most frequent first
+func (m *Miner) Templates() []Template {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list := make([]Template, 0, len(m.clusters))
+	for _, c := range m.clusters {
+		list = append(list, Template{
+			ID:        c.id,
+			Pattern:   strings.Join(c.tokens, " "),
+			Count:     c.count,
+			FirstSeen: c.firstSeen,
+			LastSeen:  c.lastSeen,
+			Examples:  append([]string(nil), c.examples...),
+		})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// leaf walks the prefix tree to the leaf for tokens, creating nodes when
+// create is set; it returns nil if the leaf doesn't exist
+func (m *Miner) leaf(tokens []string, create bool) *node {
+	n := m.byLength[len(tokens)]
+	if n == nil {
+		if !create {
+			return nil
+		}
+		n = &node{children: map[string]*node{}}
+		m.byLength[len(tokens)] = n
+	}
+
+	for depth := 0; depth < treeDepth && depth < len(tokens); depth++ {
+		key := tokens[depth]
+		if hasDigit(key) || isMask(key) {
+			key = Wildcard
+		}
+		child := n.children[key]
+		if child == nil {
+			if !create {
+				child = n.children[Wildcard]
+				if child == nil {
+					return nil
+				}
+			} else {
+				if len(n.children) >= maxChildren {
+					key = Wildcard
+				}
+				if child = n.children[key]; child == nil {
+					child = &node{children: map[string]*node{}}
+					n.children[key] = child
+				}
+			}
+		}
+		n = child
+	}
+	return n
+}
+
+// bestMatch returns the most similar cluster if it passes the threshold
+func bestMatch(clusters []*cluster, tokens []string) *cluster {
+	var best *cluster
+	bestSimilarity, bestWildcards := -1.0, 0
+	for _, c := range clusters {
+		same, wildcards := 0, 0
+		for i, token := range c.tokens {
+			switch {
+			case token == Wildcard:
+				wildcards++
+			case token == tokens[i]:
+				same++
+			}
+		}
+		similarity := 1.0
+		if len(tokens) > 0 {
+			similarity = float64(same) / float64(len(tokens))
+		}
+		if similarity > bestSimilarity || (similarity == bestSimilarity && wildcards > bestWildcards) {
+			best, bestSimilarity, bestWildcards = c, similarity, wildcards
+		}
+	}
+	if best == nil || bestSimilarity < similarityThreshold {
+		return nil
+	}
+	return best
+}
+
+// Normalize returns the first line of text with its variable tokens
+// masked as in templates, so lines that differ only in timestamps, IDs and
+// numbers compare equal
+func Normalize(text string) string {
+	return strings.Join(tokenize(text), " ")
+}
+
+// tokenize splits the first line of text into masked tokens
+func tokenize(text string) []string {
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		text = text[:i]
+	}
+	fields := strings.Fields(text)
+	if len(fields) > maxTokens {
+		fields = fields[:maxTokens]
+	}
+	for i, field := range fields {
+		fields[i] = mask(field)
+	}
+	return fields
+}
+
+// mask replaces the variable part of a token. Surrounding punctuation is
+// kept, and in key=value tokens only the value is masked.
+func mask(token string) string {
+	if key, value, ok := strings.Cut(token, "="); ok && key != "" {
+		return key + "=" + mask(value)
+	}
+
+	start := strings.IndexFunc(token, isWordRune)
+	if start < 0 {
+		return token
+	}
+	last := strings.LastIndexFunc(token, isWordRune)
+	_, size := utf8.DecodeRuneInString(token[last:])
+	end := last + size
+	if end < len(token) && token[end] == '%' {
+		end++
+	}
+	core := token[start:end]
+
+	var masked string
+	switch {
+	case uuidRe.MatchString(core):
+		masked = "<UUID>"
+	case ipRe.MatchString(core):
+		masked = "<IP>"
+	case numRe.MatchString(core):
+		masked = "<NUM>"
+	case hexRe.MatchString(core) && hasDigit(core):
+		masked = "<HEX>"
+	case timeRe.MatchString(core) && hasDigit(core):
+		masked = "<TIME>"
+	default:
+		return token
+	}
+	return token[:start] + masked + token[end:]
+}
+
+// isWordRune reports whether r can be part of a maskable value
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// hasDigit reports whether s contains an ASCII digit
+func hasDigit(s string) bool {
+	return strings.ContainsAny(s, "0123456789")
+}
+
+// isMask reports whether a token is a mask or wildcard
+func isMask(token string) bool {
+	return strings.HasPrefix(token, "<") && strings.HasSuffix(token, ">")
+}
@@ -0,0 +1,63 @@
+package watcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// DockerContainer represents a Docker container
+type DockerContainer struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Image  string `json:"image"`
+	State  string `json:"state"`
+	Status string `json:"status"`
+}
+
+// ListDockerContainers returns the containers known to the Docker daemon.
+// Stopped containers are included when all is true.
+func ListDockerContainers(socketPath string, all bool) ([]DockerContainer, error) {
+	client := newDockerClient(socketPath)
+
+	query := url.Values{}
+	if all {
+		query.Set("all", "1")
+	}
+
+	resp, err := client.get(context.TODO(), "/containers/json", query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var items []struct {
+		ID     string   `json:"Id"`
+		Names  []string `json:"Names"`
+		Image  string   `json:"Image"`
+		State  string   `json:"State"`
+		Status string   `json:"Status"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&items); err != nil {
+		return nil, fmt.Errorf("failed to decode containers: %w", err)
+	}
+
+	containers := make([]DockerContainer, 0, len(items))
+	for _, item := range items {
+		name := item.ID
+		if len(item.Names) > 0 {
+			name = strings.TrimPrefix(item.Names[0], "/")
+		}
+		containers = append(containers, DockerContainer{
+			ID:     item.ID,
+			Name:   name,
+			Image:  item.Image,
+			State:  item.State,
+			Status: item.Status,
+		})
+	}
+
+	return containers, nil
+}
@@ -0,0 +1,144 @@
+package watcher
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// fakeDockerSocket serves handler as a Docker Engine API on a temporary
+// Unix socket and returns the socket path
+func fakeDockerSocket(t *testing.T, handler http.Handler) string {
+	t.Helper()
+
+	socketPath := filepath.Join(t.TempDir(), "docker.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+
+	server := httptest.NewUnstartedServer(handler)
+	server.Listener = listener
+	server.Start()
+	t.Cleanup(server.Close)
+
+	return socketPath
+}
+
+// dockerFrame encodes a payload as one frame of a multiplexed log stream
+func dockerFrame(stream byte, payload string) []byte {
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(payload)))
+	return append(header, payload...)
+}
+
+func TestListDockerContainers(t *testing.T) {
+	var gotAll string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		gotAll = r.URL.Query().Get("all")
+		json.NewEncoder(w).Encode([]map[string]interface{}{
+			{"Id": "abc123", "Names": []string{"/web"}, "Image": "nginx:1.25", "State": "running", "Status": "Up 2 hours"},
+			{"Id": "def456", "Names": []string{}, "Image": "redis", "State": "exited", "Status": "Exited (0) 1 minute ago"},
+		})
+	})
+	socketPath := fakeDockerSocket(t, mux)
+
+	containers, err := ListDockerContainers(socketPath, true)
+	if err != nil {
+		t.Fatalf("ListDockerContainers: %v", err)
+	}
+	if gotAll != "1" {
+		t.Errorf("all = %q, want 1", gotAll)
+	}
+
+	want := []DockerContainer{
+		{ID: "abc123", Name: "web", Image: "nginx:1.25", State: "running", Status: "Up 2 hours"},
+		{ID: "def456", Name: "def456", Image: "redis", State: "exited", Status: "Exited (0) 1 minute ago"},
+	}
+	if !reflect.DeepEqual(containers, want) {
+		t.Errorf("containers = %+v, want %+v", containers, want)
+	}
+}
+
+func TestListDockerContainersAPIError(t *testing.T) {
+	socketPath := fakeDockerSocket(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"message": "daemon is shutting down"})
+	}))
+
+	_, err := ListDockerContainers(socketPath, false)
+	if err == nil || !bytes.Contains([]byte(err.Error()), []byte("daemon is shutting down")) {
+		t.Errorf("err = %v, want the API message", err)
+	}
+}
+
+func TestDemuxDockerStream(t *testing.T) {
+	var stream bytes.Buffer
+	stream.Write(dockerFrame(1, "first line\nsecond "))
+	stream.Write(dockerFrame(2, "error: boom\r\n"))
+	stream.Write(dockerFrame(1, "line\n"))
+	stream.Write(dockerFrame(2, "no newline at end"))
+
+	var lines []string
+	if err := DemuxDockerStream(&stream, func(line string) { lines = append(lines, line) }); err != nil {
+		t.Fatalf("DemuxDockerStream: %v", err)
+	}
+
+	want := []string{"first line", "error: boom", "second line", "no newline at end"}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("lines = %q, want %q", lines, want)
+	}
+}
+
+func TestDemuxDockerStreamTruncatedFrame(t *testing.T) {
+	frame := dockerFrame(1, "complete\npartial")
+	var lines []string
+	err := DemuxDockerStream(bytes.NewReader(frame[:len(frame)-3]), func(line string) { lines = append(lines, line) })
+	if err == nil {
+		t.Error("expected an error for a truncated frame")
+	}
+	if len(lines) == 0 || lines[0] != "complete" {
+		t.Errorf("lines = %q, want the complete line first", lines)
+	}
+}
+
+func TestDockerWatcherFollowsLogs(t *testing.T) {
+	var gotQuery map[string]string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/containers/web/json", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"Id": "abc123", "Name": "/web", "Config": map[string]bool{"Tty": false}})
+	})
+	mux.HandleFunc("/containers/web/logs", func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = map[string]string{}
+		for key := range r.URL.Query() {
+			gotQuery[key] = r.URL.Query().Get(key)
+		}
+		w.Write(dockerFrame(1, "hello\n"))
+		w.Write(dockerFrame(2, "oops\n"))
+	})
+	socketPath := fakeDockerSocket(t, mux)
+
+	w, err := NewDockerWatcher(DockerConfig{SocketPath: socketPath, ContainerID: "web", TailLines: 50, Since: "1700000000"})
+	if err != nil {
+		t.Fatalf("NewDockerWatcher: %v", err)
+	}
+
+	var lines []string
+	// The fake stream ends, which the watcher reports as an error
+	w.Watch(func(batch []string) { lines = append(lines, batch...) })
+
+	if want := []string{"hello", "oops"}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("lines = %q, want %q", lines, want)
+	}
+	if gotQuery["tail"] != "50" || gotQuery["since"] != "1700000000" || gotQuery["follow"] != "1" {
+		t.Errorf("query = %v", gotQuery)
+	}
+}
@@ -0,0 +1,313 @@
+package watcher
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultDockerSocket is the default location of the Docker Engine API socket
+const DefaultDockerSocket = "/var/run/docker.sock"
+
+// DockerWatcher watches Docker container logs
+type DockerWatcher struct {
+	client      *dockerClient
+	containerID string
+	tailLines   int64
+	since       string
+	ctx         context.Context
+	cancel      context.CancelFunc
+}
+
+// DockerConfig contains configuration for a Docker container log stream
+type DockerConfig struct {
+	SocketPath  string // Path to the Engine API socket (default: DOCKER_HOST or /var/run/docker.sock)
+	ContainerID string // Container ID or name
+	TailLines   int64  // Number of lines to load initially (0 = all)
+	Since       string // Only return logs since this time (RFC3339, unix seconds or duration like "10m")
+}
+
+// NewDockerWatcher creates a new Docker container log watcher
+func NewDockerWatcher(cfg DockerConfig) (*DockerWatcher, error) {
+	if cfg.ContainerID == "" {
+		return nil, fmt.Errorf("container is required")
+	}
+
+	since, err := parseDockerSince(cfg.Since, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &DockerWatcher{
+		client:      newDockerClient(cfg.SocketPath),
+		containerID: cfg.ContainerID,
+		tailLines:   cfg.TailLines,
+		since:       since,
+		ctx:         ctx,
+		cancel:      cancel,
+	}, nil
+}
+
+// Watch streams logs from the Docker container
+func (w *DockerWatcher) Watch(callback func([]string)) error {
+	defer w.cancel()
+
+	// Containers started with a TTY send a raw stream instead of the multiplexed format
+	info, err := w.client.inspectContainer(w.ctx, w.containerID)
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	query.Set("follow", "1")
+	query.Set("stdout", "1")
+	query.Set("stderr", "1")
+	if w.tailLines > 0 {
+		query.Set("tail", strconv.FormatInt(w.tailLines, 10))
+	} else {
+		query.Set("tail", "all")
+	}
+	if w.since != "" {
+		query.Set("since", w.since)
+	}
+
+	resp, err := w.client.get(w.ctx, "/containers/"+url.PathEscape(w.containerID)+"/logs", query)
+	if err != nil {
+		return fmt.Errorf("failed to open log stream: %w", err)
+	}
+	defer resp.Body.Close()
+
+	log.Printf("Started watching container %s", info.Name)
+
+	emit := func(line string) {
+		callback([]string{line})
+	}
+
+	if info.Config.Tty {
+		err = readRawLines(resp.Body, emit)
+	} else {
+		err = DemuxDockerStream(resp.Body, emit)
+	}
+
+	select {
+	case <-w.ctx.Done():
+		log.Println("Docker watcher stopped")
+		return nil
+	default:
+	}
+
+	if err != nil {
+		return fmt.Errorf("error reading log stream: %w", err)
+	}
+
+	log.Printf("Docker stream ended for container %s", info.Name)
+	return fmt.Errorf("log stream ended - container may have stopped")
+}
+
+// Stop stops watching the container logs
+func (w *DockerWatcher) Stop() {
+	if w.cancel != nil {
+		w.cancel()
+	}
+}
+
+// DemuxDockerStream splits a multiplexed Docker log stream into lines.
+// Each frame starts with an 8-byte header: the stream type (1 = stdout,
+// 2 = stderr), three zero bytes and the big-endian payload size. Partial
+// lines are buffered per stream so interleaved frames don't mix output.
+func DemuxDockerStream(r io.Reader, emit func(string)) error {
+	header := make([]byte, 8)
+	partial := map[byte]*bytes.Buffer{}
+
+	// Emit every complete line, keep the remainder for the next frame
+	emitLines := func(buf *bytes.Buffer) {
+		for {
+			idx := bytes.IndexByte(buf.Bytes(), '\n')
+			if idx < 0 {
+				return
+			}
+			line := string(buf.Next(idx + 1))
+			emit(strings.TrimRight(line, "\r\n"))
+		}
+	}
+
+	flush := func() {
+		for _, stream := range []byte{1, 2} {
+			if buf := partial[stream]; buf != nil && buf.Len() > 0 {
+				emitLines(buf)
+				if buf.Len() > 0 {
+					emit(buf.String())
+					buf.Reset()
+				}
+			}
+		}
+	}
+
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			flush()
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		stream := header[0]
+		size := binary.BigEndian.Uint32(header[4:])
+
+		buf := partial[stream]
+		if buf == nil {
+			buf = &bytes.Buffer{}
+			partial[stream] = buf
+		}
+
+		if _, err := io.CopyN(buf, r, int64(size)); err != nil {
+			flush()
+			return err
+		}
+
+		emitLines(buf)
+	}
+}
+
+// readRawLines reads a non-multiplexed (TTY) log stream line by line
+func readRawLines(r io.Reader, emit func(string)) error {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			emit(strings.TrimRight(line, "\r\n"))
+		}
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// parseDockerSince converts a user supplied since value into the unix
+// timestamp format expected by the Engine API
+func parseDockerSince(since string, now time.Time) (string, error) {
+	if since == "" {
+		return "", nil
+	}
+
+	if _, err := strconv.ParseInt(since, 10, 64); err == nil {
+		return since, nil
+	}
+
+	if d, err := time.ParseDuration(since); err == nil {
+		return strconv.FormatInt(now.Add(-d).Unix(), 10), nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, since); err == nil {
+		return strconv.FormatInt(t.Unix(), 10), nil
+	}
+
+	return "", fmt.Errorf("invalid since value '%s': use RFC3339, unix seconds or a duration like 10m", since)
+}
+
+// dockerClient is a minimal Docker Engine API client over a Unix socket
+type dockerClient struct {
+	http *http.Client
+}
+
+// newDockerClient creates a client for the Engine API socket at socketPath
+func newDockerClient(socketPath string) *dockerClient {
+	if socketPath == "" {
+		socketPath = dockerSocketPath()
+	}
+
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socketPath)
+		},
+	}
+
+	return &dockerClient{
+		http: &http.Client{Transport: transport},
+	}
+}
+
+// dockerSocketPath returns the socket from DOCKER_HOST or the default location
+func dockerSocketPath() string {
+	if host := os.Getenv("DOCKER_HOST"); strings.HasPrefix(host, "unix://") {
+		return strings.TrimPrefix(host, "unix://")
+	}
+	return DefaultDockerSocket
+}
+
+// get performs a GET request against the Engine API
+func (c *dockerClient) get(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	u := "http://docker" + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to docker: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		if apiErr.Message == "" {
+			apiErr.Message = resp.Status
+		}
+		return nil, fmt.Errorf("docker API error: %s", apiErr.Message)
+	}
+
+	return resp, nil
+}
+
+// dockerContainerInfo is the subset of the container inspect response we use
+type dockerContainerInfo struct {
+	ID     string `json:"Id"`
+	Name   string `json:"Name"`
+	Config struct {
+		Tty bool `json:"Tty"`
+	} `json:"Config"`
+}
+
+// inspectContainer returns low-level information about a container
+func (c *dockerClient) inspectContainer(ctx context.Context, id string) (*dockerContainerInfo, error) {
+	resp, err := c.get(ctx, "/containers/"+url.PathEscape(id)+"/json", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var info dockerContainerInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("failed to decode container info: %w", err)
+	}
+	info.Name = strings.TrimPrefix(info.Name, "/")
+
+	return &info, nil
+}
@@ -0,0 +1,301 @@
+package watcher
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/weblogview/internal/logline"
+	"github.com/yourusername/weblogview/internal/parser"
+)
+
+// JournalWatcher watches systemd journal entries
+type JournalWatcher struct {
+	unit      string
+	path      string
+	tailLines int64
+	ctx       context.Context
+	cancel    context.CancelFunc
+}
+
+// JournalConfig contains configuration for a journal source
+type JournalConfig struct {
+	Unit      string // Only show entries for this systemd unit (optional)
+	Path      string // Read a captured export-format file instead of running journalctl (optional)
+	TailLines int64  // Number of entries to load initially
+}
+
+// JournalEntry is a single journal entry in export format
+type JournalEntry struct {
+	Fields map[string]string
+}
+
+// NewJournalWatcher creates a new journal watcher
+func NewJournalWatcher(cfg JournalConfig) (*JournalWatcher, error) {
+	if cfg.Path != "" {
+		if _, err := os.Stat(cfg.Path); err != nil {
+			return nil, fmt.Errorf("file not found: %s", cfg.Path)
+		}
+	} else if _, err := exec.LookPath("journalctl"); err != nil {
+		return nil, fmt.Errorf("journalctl not found: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &JournalWatcher{
+		unit:      cfg.Unit,
+		path:      cfg.Path,
+		tailLines: cfg.TailLines,
+		ctx:       ctx,
+		cancel:    cancel,
+	}, nil
+}
+
+// Watch streams journal entries. When reading from an export file the last
+// entries are sent once and Watch returns nil at the end of the file.
+func (w *JournalWatcher) Watch(callback func([]logline.Line)) error {
+	defer w.cancel()
+
+	emit := func(entry JournalEntry) error {
+		callback([]logline.Line{entry.Line()})
+		return nil
+	}
+
+	if w.path != "" {
+		return w.readFile(callback)
+	}
+
+	args := []string{"-o", "export", "-f", "-n", strconv.FormatInt(w.tailLines, 10)}
+	if w.unit != "" {
+		args = append(args, "-u", w.unit)
+	}
+
+	cmd := exec.CommandContext(w.ctx, "journalctl", args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open journal stream: %w", err)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start journalctl: %w", err)
+	}
+
+	log.Printf("Started watching journal (unit: %q)", w.unit)
+
+	parseErr := ParseJournalExport(stdout, emit)
+	waitErr := cmd.Wait()
+
+	select {
+	case <-w.ctx.Done():
+		log.Println("Journal watcher stopped")
+		return nil
+	default:
+	}
+
+	if parseErr != nil {
+		return fmt.Errorf("error reading journal: %w", parseErr)
+	}
+	if waitErr != nil {
+		return fmt.Errorf("journalctl failed: %v: %s", waitErr, strings.TrimSpace(stderr.String()))
+	}
+	return fmt.Errorf("journal stream ended")
+}
+
+// readFile sends the last tailLines entries of the unit from an export
+// file in one batch (all entries when tailLines is 0)
+func (w *JournalWatcher) readFile(callback func([]logline.Line)) error {
+	file, err := os.Open(w.path)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	var records []logline.Line
+	err = ParseJournalExport(file, func(entry JournalEntry) error {
+		if w.unit != "" && entry.Unit() != w.unit {
+			return nil
+		}
+		records = append(records, entry.Line())
+		if w.tailLines > 0 && int64(len(records)) > 2*w.tailLines {
+			// Drop the entries that can no longer be in the tail
+			records = append(records[:0], records[int64(len(records))-w.tailLines:]...)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if w.tailLines > 0 && int64(len(records)) > w.tailLines {
+		records = records[int64(len(records))-w.tailLines:]
+	}
+	if len(records) > 0 {
+		callback(records)
+	}
+	return nil
+}
+
+// Stop stops watching the journal
+func (w *JournalWatcher) Stop() {
+	if w.cancel != nil {
+		w.cancel()
+	}
+}
+
+// ParseJournalExport parses the journal export format and calls fn for every
+// entry. Entries are separated by an empty line. Each field is either
+// "KEY=value\n" or, for binary-safe values, "KEY\n" followed by a 64-bit
+// little-endian length, the raw data and a trailing newline.
+func ParseJournalExport(r io.Reader, fn func(JournalEntry) error) error {
+	reader := bufio.NewReader(r)
+	entry := JournalEntry{Fields: map[string]string{}}
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				// Flush an entry that isn't terminated by an empty line
+				if len(entry.Fields) > 0 {
+					return fn(entry)
+				}
+				return nil
+			}
+			return err
+		}
+		line = strings.TrimSuffix(line, "\n")
+
+		if line == "" {
+			if len(entry.Fields) > 0 {
+				if err := fn(entry); err != nil {
+					return err
+				}
+			}
+			entry = JournalEntry{Fields: map[string]string{}}
+			continue
+		}
+
+		if key, value, ok := strings.Cut(line, "="); ok {
+			entry.Fields[key] = value
+			continue
+		}
+
+		// Binary field: size-prefixed value follows the field name
+		var size uint64
+		if err := binary.Read(reader, binary.LittleEndian, &size); err != nil {
+			return fmt.Errorf("invalid binary field %s: %w", line, err)
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return fmt.Errorf("invalid binary field %s: %w", line, err)
+		}
+		if b, err := reader.ReadByte(); err != nil || b != '\n' {
+			return fmt.Errorf("invalid binary field %s: missing terminator", line)
+		}
+		entry.Fields[line] = string(data)
+	}
+}
+
+// Message returns the log message of the entry
+func (e JournalEntry) Message() string {
+	return e.Fields["MESSAGE"]
+}
+
+// Unit returns the systemd unit that logged the entry
+func (e JournalEntry) Unit() string {
+	if unit := e.Fields["_SYSTEMD_UNIT"]; unit != "" {
+		return unit
+	}
+	return e.Fields["SYSLOG_IDENTIFIER"]
+}
+
+// Priority returns the syslog priority (0 = emerg … 7 = debug), or -1 if unset
+func (e JournalEntry) Priority() int {
+	p, err := strconv.Atoi(e.Fields["PRIORITY"])
+	if err != nil {
+		return -1
+	}
+	return p
+}
+
+// PID returns the process ID that logged the entry, or 0 if unknown
+func (e JournalEntry) PID() int {
+	pid, _ := strconv.Atoi(e.Fields["_PID"])
+	return pid
+}
+
+// Timestamp returns the wallclock time the entry was received
+func (e JournalEntry) Timestamp() time.Time {
+	usec, err := strconv.ParseInt(e.Fields["__REALTIME_TIMESTAMP"], 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMicro(usec)
+}
+
+// Line converts the entry into a log line in journalctl's short-iso style
+func (e JournalEntry) Line() logline.Line {
+	ts := e.Timestamp()
+
+	var text strings.Builder
+	if !ts.IsZero() {
+		text.WriteString(ts.Format("2006-01-02T15:04:05.000Z07:00"))
+		text.WriteByte(' ')
+	}
+	if unit := e.Unit(); unit != "" {
+		text.WriteString(unit)
+		if pid := e.PID(); pid > 0 {
+			fmt.Fprintf(&text, "[%d]", pid)
+		}
+		text.WriteString(": ")
+	}
+	text.WriteString(e.Message())
+
+	line := logline.New(text.String())
+	line.SetTime(ts)
+	line.Level = parser.SyslogLevel(e.Priority())
+	line.Fields = map[string]string{}
+	if unit := e.Unit(); unit != "" {
+		line.Fields["unit"] = unit
+	}
+	if p := e.Priority(); p >= 0 {
+		line.Fields["priority"] = strconv.Itoa(p)
+	}
+	if pid := e.PID(); pid > 0 {
+		line.Fields["pid"] = strconv.Itoa(pid)
+	}
+	if host := e.Fields["_HOSTNAME"]; host != "" {
+		line.Fields["hostname"] = host
+	}
+
+	return line
+}
+
+// ListJournalUnits returns the systemd units that have journal entries
+func ListJournalUnits() ([]string, error) {
+	out, err := exec.Command("journalctl", "-F", "_SYSTEMD_UNIT").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list units: %w", err)
+	}
+
+	units := []string{}
+	for _, unit := range strings.Split(string(out), "\n") {
+		if unit = strings.TrimSpace(unit); unit != "" {
+			units = append(units, unit)
+		}
+	}
+	sort.Strings(units)
+
+	return units, nil
+}
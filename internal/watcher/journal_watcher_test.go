@@ -0,0 +1,126 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/weblogview/internal/logline"
+)
+
+// readJournalFixture parses a captured export-format file from testdata
+func readJournalFixture(t *testing.T, name string) ([]JournalEntry, error) {
+	t.Helper()
+
+	file, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var entries []JournalEntry
+	err = ParseJournalExport(file, func(entry JournalEntry) error {
+		entries = append(entries, entry)
+		return nil
+	})
+	return entries, err
+}
+
+func TestParseJournalExport(t *testing.T) {
+	entries, err := readJournalFixture(t, "journal.export")
+	if err != nil {
+		t.Fatalf("ParseJournalExport: %v", err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("got %d entries, want 4", len(entries))
+	}
+
+	first := entries[0]
+	if first.Message() != "started worker process" || first.Unit() != "nginx.service" || first.PID() != 812 || first.Priority() != 6 {
+		t.Errorf("first entry = %v", first.Fields)
+	}
+	if want := time.UnixMicro(1700000000123456); !first.Timestamp().Equal(want) {
+		t.Errorf("timestamp = %v, want %v", first.Timestamp(), want)
+	}
+
+	// Binary fields keep newlines, "=" and NUL bytes of their value
+	binary := entries[1]
+	if got, want := binary.Message(), "upstream failed\nkey=value\x00tail"; got != want {
+		t.Errorf("binary message = %q, want %q", got, want)
+	}
+	if got, want := binary.Fields["BINARY_BLOB"], "\x01\x02\n\x03"; got != want {
+		t.Errorf("binary blob = %q, want %q", got, want)
+	}
+	if binary.Priority() != 3 {
+		t.Errorf("priority = %d, want 3", binary.Priority())
+	}
+
+	// Entries without a unit fall back to the syslog identifier
+	if unit := entries[2].Unit(); unit != "sshd" {
+		t.Errorf("unit = %q, want sshd", unit)
+	}
+	if pid := entries[2].PID(); pid != 0 {
+		t.Errorf("pid = %d, want 0", pid)
+	}
+}
+
+func TestParseJournalExportTruncatedBinaryField(t *testing.T) {
+	entries, err := readJournalFixture(t, "journal-truncated.export")
+	if err == nil || !strings.Contains(err.Error(), "invalid binary field MESSAGE") {
+		t.Errorf("err = %v, want an invalid binary field error", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("got %d entries before the error, want 1", len(entries))
+	}
+}
+
+func TestJournalEntryLine(t *testing.T) {
+	entries, err := readJournalFixture(t, "journal.export")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	line := entries[1].Line()
+	if line.Level != "error" {
+		t.Errorf("level = %q, want error", line.Level)
+	}
+	if line.Timestamp != 1700000001000 {
+		t.Errorf("timestamp = %d, want 1700000001000", line.Timestamp)
+	}
+	if !strings.Contains(line.Text, "nginx.service[812]: upstream failed") {
+		t.Errorf("text = %q", line.Text)
+	}
+	if line.Fields["unit"] != "nginx.service" || line.Fields["priority"] != "3" || line.Fields["pid"] != "812" {
+		t.Errorf("fields = %v", line.Fields)
+	}
+}
+
+func TestJournalWatcherFileTail(t *testing.T) {
+	w, err := NewJournalWatcher(JournalConfig{
+		Unit:      "nginx.service",
+		Path:      filepath.Join("testdata", "journal.export"),
+		TailLines: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var batches [][]logline.Line
+	if err := w.Watch(func(records []logline.Line) { batches = append(batches, records) }); err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	if len(batches) != 1 {
+		t.Fatalf("got %d batches, want the tail in one", len(batches))
+	}
+
+	var messages []string
+	for _, line := range batches[0] {
+		messages = append(messages, line.Text[strings.Index(line.Text, ": ")+2:])
+	}
+	want := []string{"upstream failed\nkey=value\x00tail", "reloaded"}
+	if strings.Join(messages, "|") != strings.Join(want, "|") {
+		t.Errorf("messages = %q, want %q", messages, want)
+	}
+}
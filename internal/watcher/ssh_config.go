@@ -0,0 +1,182 @@
+package watcher
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/client-go/util/homedir"
+)
+
+// SSHHost is a host entry resolved from an OpenSSH client config
+type SSHHost struct {
+	Alias         string   `json:"alias"`
+	HostName      string   `json:"hostName"`
+	User          string   `json:"user"`
+	Port          string   `json:"port"`
+	IdentityFiles []string `json:"identityFiles,omitempty"`
+}
+
+// sshConfigBlock is a single "Host" section of an ssh config file
+type sshConfigBlock struct {
+	patterns []string
+	options  map[string][]string
+}
+
+// defaultSSHConfigPath returns the path of the user's ssh config
+func defaultSSHConfigPath() string {
+	return filepath.Join(homedir.HomeDir(), ".ssh", "config")
+}
+
+// parseSSHConfig reads the Host blocks of an OpenSSH client config file.
+// Only the options needed to open a connection are kept; Match blocks and
+// Include directives are not supported.
+func parseSSHConfig(configPath string) ([]sshConfigBlock, error) {
+	file, err := os.Open(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	// Options before the first Host line apply to every host
+	blocks := []sshConfigBlock{{patterns: []string{"*"}, options: map[string][]string{}}}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Keyword and arguments are separated by whitespace and/or "="
+		key, value := line, ""
+		if idx := strings.IndexAny(line, " \t="); idx >= 0 {
+			key, value = line[:idx], strings.TrimLeft(line[idx:], " \t=")
+		}
+		key = strings.ToLower(key)
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+
+		if key == "host" {
+			blocks = append(blocks, sshConfigBlock{patterns: strings.Fields(value), options: map[string][]string{}})
+			continue
+		}
+
+		current := blocks[len(blocks)-1]
+		current.options[key] = append(current.options[key], value)
+	}
+
+	return blocks, scanner.Err()
+}
+
+// matches reports whether the block applies to the given alias
+func (b sshConfigBlock) matches(alias string) bool {
+	matched := false
+	for _, pattern := range b.patterns {
+		negate := strings.HasPrefix(pattern, "!")
+		if ok, _ := path.Match(strings.TrimPrefix(pattern, "!"), alias); ok {
+			if negate {
+				return false
+			}
+			matched = true
+		}
+	}
+	return matched
+}
+
+// ResolveSSHHost resolves an alias (optionally "user@alias:port") using the
+// ssh config at configPath. As in OpenSSH, the first value found for each
+// option wins.
+func ResolveSSHHost(configPath, target string) (*SSHHost, error) {
+	if configPath == "" {
+		configPath = defaultSSHConfigPath()
+	}
+
+	user, alias := "", target
+	if u, h, ok := strings.Cut(target, "@"); ok {
+		user, alias = u, h
+	}
+	port := ""
+	if h, p, ok := strings.Cut(alias, ":"); ok {
+		alias, port = h, p
+	}
+	if alias == "" {
+		return nil, fmt.Errorf("host is required")
+	}
+
+	blocks, err := parseSSHConfig(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ssh config: %w", err)
+	}
+
+	host := &SSHHost{Alias: alias, User: user, Port: port}
+	for _, block := range blocks {
+		if !block.matches(alias) {
+			continue
+		}
+		if v := block.options["hostname"]; host.HostName == "" && len(v) > 0 {
+			host.HostName = v[0]
+		}
+		if v := block.options["user"]; host.User == "" && len(v) > 0 {
+			host.User = v[0]
+		}
+		if v := block.options["port"]; host.Port == "" && len(v) > 0 {
+			host.Port = v[0]
+		}
+		for _, identity := range block.options["identityfile"] {
+			host.IdentityFiles = append(host.IdentityFiles, expandHome(identity))
+		}
+	}
+
+	if host.HostName == "" {
+		host.HostName = alias
+	}
+	host.HostName = strings.ReplaceAll(host.HostName, "%h", alias)
+	if host.User == "" {
+		host.User = os.Getenv("USER")
+		if host.User == "" {
+			host.User = os.Getenv("USERNAME")
+		}
+	}
+	if host.Port == "" {
+		host.Port = "22"
+	}
+
+	return host, nil
+}
+
+// ListSSHHosts returns the concrete (non-wildcard) host aliases defined in
+// the user's ssh config
+func ListSSHHosts() ([]string, error) {
+	blocks, err := parseSSHConfig(defaultSSHConfigPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ssh config: %w", err)
+	}
+
+	hosts := []string{}
+	if len(blocks) <= 1 {
+		// No config file, or no Host blocks in it
+		return hosts, nil
+	}
+	for _, block := range blocks[1:] {
+		for _, pattern := range block.patterns {
+			if !strings.ContainsAny(pattern, "*?!") {
+				hosts = append(hosts, pattern)
+			}
+		}
+	}
+
+	return hosts, nil
+}
+
+// expandHome expands a leading ~ to the user's home directory
+func expandHome(p string) string {
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		return filepath.Join(homedir.HomeDir(), p[1:])
+	}
+	return p
+}
@@ -0,0 +1,335 @@
+package watcher
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"k8s.io/client-go/util/homedir"
+)
+
+// SSHWatcher tails a file on a remote host over SSH
+type SSHWatcher struct {
+	host      *SSHHost
+	path      string
+	tailLines int64
+	clientCfg *ssh.ClientConfig
+	agentConn net.Conn // Connection to ssh-agent, if one is running
+	mu        sync.Mutex
+	client    *ssh.Client // Set while Watch is connected, for range reads
+	ctx       context.Context
+	cancel    context.CancelFunc
+}
+
+// SSHConfig contains configuration for a remote file source
+type SSHConfig struct {
+	Host           string // Host alias from the ssh config, optionally "user@host:port"
+	Path           string // Remote file path
+	TailLines      int64  // Number of lines to load initially
+	ConfigPath     string // ssh config file (default: ~/.ssh/config)
+	KnownHostsPath string // known_hosts file (default: ~/.ssh/known_hosts)
+}
+
+// NewSSHWatcher creates a new remote file watcher
+func NewSSHWatcher(cfg SSHConfig) (*SSHWatcher, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+
+	host, err := ResolveSSHHost(cfg.ConfigPath, cfg.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	clientCfg, agentConn, err := sshClientConfig(host, cfg.KnownHostsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &SSHWatcher{
+		host:      host,
+		path:      cfg.Path,
+		tailLines: cfg.TailLines,
+		clientCfg: clientCfg,
+		agentConn: agentConn,
+		ctx:       ctx,
+		cancel:    cancel,
+	}, nil
+}
+
+// Watch connects to the remote host, sends the last lines of the file to
+// initial in one batch and then streams the lines appended after them to
+// callback. "tail -F" keeps following the path across rotation and
+// truncation.
+func (w *SSHWatcher) Watch(initial, callback func([]string)) error {
+	defer w.cancel()
+	if w.agentConn != nil {
+		defer w.agentConn.Close()
+	}
+
+	addr := net.JoinHostPort(w.host.HostName, w.host.Port)
+	client, err := sshDial(w.ctx, addr, w.clientCfg)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", w.host.Alias, err)
+	}
+	defer client.Close()
+
+	// Closing the client unblocks the reader when the watcher is stopped
+	go func() {
+		<-w.ctx.Done()
+		client.Close()
+	}()
+
+	w.mu.Lock()
+	w.client = client
+	w.mu.Unlock()
+	defer func() {
+		w.mu.Lock()
+		w.client = nil
+		w.mu.Unlock()
+	}()
+
+	// The size is taken first so the follow starts right after the initial
+	// lines, without gaps or duplicates
+	size, lines, err := w.readTail(client)
+	if err != nil {
+		select {
+		case <-w.ctx.Done():
+			return nil
+		default:
+		}
+		return err
+	}
+	initial(lines)
+
+	session, err := client.NewSession()
+	if err != nil {
+		return fmt.Errorf("failed to open session: %w", err)
+	}
+	defer session.Close()
+
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open remote stream: %w", err)
+	}
+	var stderr bytes.Buffer
+	session.Stderr = &stderr
+
+	cmd := fmt.Sprintf("tail -c +%d -F -- %s", size+1, shellQuote(w.path))
+	if err := session.Start(cmd); err != nil {
+		return fmt.Errorf("failed to start tail: %w", err)
+	}
+
+	log.Printf("Started watching %s:%s", w.host.Alias, w.path)
+
+	reader := bufio.NewReader(stdout)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			callback([]string{strings.TrimRight(line, "\r\n")})
+		}
+		if err != nil {
+			select {
+			case <-w.ctx.Done():
+				log.Println("SSH watcher stopped")
+				return nil
+			default:
+			}
+
+			if err == io.EOF {
+				session.Wait()
+				if msg := strings.TrimSpace(stderr.String()); msg != "" {
+					return fmt.Errorf("remote tail ended: %s", msg)
+				}
+				return fmt.Errorf("remote tail ended - connection may have been closed")
+			}
+			return fmt.Errorf("error reading remote stream: %w", err)
+		}
+	}
+}
+
+// readTail returns the size of the remote file and its last tailLines
+// lines up to that size
+func (w *SSHWatcher) readTail(client *ssh.Client) (int64, []string, error) {
+	cmd := fmt.Sprintf(`f=%s; size=$(wc -c < "$f") || exit 1; echo $size; head -c $size -- "$f" | tail -n %d`,
+		shellQuote(w.path), w.tailLines)
+	out, err := runSSH(client, cmd)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read %s: %w", w.path, err)
+	}
+
+	first, rest, _ := strings.Cut(out, "\n")
+	size, err := strconv.ParseInt(strings.TrimSpace(first), 10, 64)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read %s: unexpected size %q", w.path, first)
+	}
+
+	lines := []string{}
+	for _, line := range strings.SplitAfter(rest, "\n") {
+		if line != "" {
+			lines = append(lines, strings.TrimRight(line, "\r\n"))
+		}
+	}
+	return size, lines, nil
+}
+
+// ReadRange reads up to maxLines lines of the remote file starting at byte
+// offset and returns them together with the offset of the line that
+// follows, like ReadRange for local files. Watch must be running.
+func (w *SSHWatcher) ReadRange(offset int64, maxLines int) ([]string, int64, error) {
+	w.mu.Lock()
+	client := w.client
+	w.mu.Unlock()
+	if client == nil {
+		return nil, 0, fmt.Errorf("not connected to %s", w.host.Alias)
+	}
+
+	cmd := fmt.Sprintf("tail -c +%d -- %s", offset+1, shellQuote(w.path))
+	if maxLines > 0 {
+		cmd += fmt.Sprintf(" | head -n %d", maxLines)
+	}
+	out, err := runSSH(client, cmd)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	lines := []string{}
+	next := offset
+	for _, raw := range strings.SplitAfter(out, "\n") {
+		if raw == "" {
+			continue
+		}
+		next += int64(len(raw))
+		lines = append(lines, strings.TrimRight(raw, "\r\n"))
+	}
+	return lines, next, nil
+}
+
+// Path returns the remote path of the watched file
+func (w *SSHWatcher) Path() string {
+	return w.path
+}
+
+// runSSH runs a command in a new session and returns its output; the
+// remote error output is part of the error if it fails
+func runSSH(client *ssh.Client, cmd string) (string, error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return "", fmt.Errorf("failed to open session: %w", err)
+	}
+	defer session.Close()
+
+	var stdout, stderr bytes.Buffer
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+	if err := session.Run(cmd); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s", msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// Stop stops watching the remote file
+func (w *SSHWatcher) Stop() {
+	if w.cancel != nil {
+		w.cancel()
+	}
+}
+
+// sshDial connects to addr; ctx cancels the TCP dial, cfg.Timeout bounds the handshake
+func sshDial(ctx context.Context, addr string, cfg *ssh.ClientConfig) (*ssh.Client, error) {
+	d := net.Dialer{Timeout: cfg.Timeout}
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, cfg)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
+// sshClientConfig builds the client configuration for a host using the ssh
+// agent and the configured (or default) identity files. The returned agent
+// connection (nil if no agent is running) must be closed by the caller.
+func sshClientConfig(host *SSHHost, knownHostsPath string) (*ssh.ClientConfig, net.Conn, error) {
+	if knownHostsPath == "" {
+		knownHostsPath = filepath.Join(homedir.HomeDir(), ".ssh", "known_hosts")
+	}
+
+	hostKeyCallback, err := knownhosts.New(knownHostsPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load known_hosts: %w", err)
+	}
+
+	auth := []ssh.AuthMethod{}
+
+	var agentConn net.Conn
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			agentConn = conn
+			auth = append(auth, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		}
+	}
+
+	identities := host.IdentityFiles
+	if len(identities) == 0 {
+		for _, name := range []string{"id_ed25519", "id_ecdsa", "id_rsa"} {
+			identities = append(identities, filepath.Join(homedir.HomeDir(), ".ssh", name))
+		}
+	}
+
+	signers := []ssh.Signer{}
+	for _, identity := range identities {
+		key, err := os.ReadFile(identity)
+		if err != nil {
+			continue
+		}
+		signer, err := ssh.ParsePrivateKey(key)
+		if err != nil {
+			// Passphrase protected keys are expected to be loaded in the agent
+			continue
+		}
+		signers = append(signers, signer)
+	}
+	if len(signers) > 0 {
+		auth = append(auth, ssh.PublicKeys(signers...))
+	}
+
+	if len(auth) == 0 {
+		return nil, nil, fmt.Errorf("no ssh credentials available: start ssh-agent or configure an IdentityFile")
+	}
+
+	return &ssh.ClientConfig{
+		User:            host.User,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         10 * time.Second,
+	}, agentConn, nil
+}
+
+// shellQuote quotes s for safe use as a single POSIX shell argument
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,322 @@
+package watcher
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// testSSHServer is an in-process SSH server that runs exec requests with
+// the local shell, so the remote commands of SSHWatcher run for real
+type testSSHServer struct {
+	listener net.Listener
+	config   *ssh.ServerConfig
+	hostKey  ssh.PublicKey
+}
+
+// startTestSSHServer starts a server with a new host key and writes an ssh
+// config (alias "testhost"), the identity it accepts and a known_hosts
+// file to dir. It returns the config and known_hosts paths.
+func startTestSSHServer(t *testing.T, dir string) (string, string) {
+	t.Helper()
+
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	_, hostPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostSigner, err := ssh.NewSignerFromKey(hostPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientPub, clientPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authorized, err := ssh.NewPublicKey(clientPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(clientPriv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity := filepath.Join(dir, "id_test")
+	if err := os.WriteFile(identity, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if conn.User() == "tester" && string(key.Marshal()) == string(authorized.Marshal()) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("unknown key for %s", conn.User())
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("tcp listen unavailable: %v", err)
+	}
+	server := &testSSHServer{listener: listener, config: config, hostKey: hostSigner.PublicKey()}
+	go server.serve()
+	t.Cleanup(func() { listener.Close() })
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	knownHostsPath := filepath.Join(dir, "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(listener.Addr().String())}, server.hostKey)
+	if err := os.WriteFile(knownHostsPath, []byte(line+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	configPath := filepath.Join(dir, "config")
+	sshConfig := fmt.Sprintf("Host testhost\n  HostName %s\n  Port %s\n  User tester\n  IdentityFile %s\n", host, port, identity)
+	if err := os.WriteFile(configPath, []byte(sshConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the identity file may authenticate
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	return configPath, knownHostsPath
+}
+
+// serve accepts connections until the listener is closed
+func (s *testSSHServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+// handle runs the sessions of one connection
+func (s *testSSHServer) handle(conn net.Conn) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, s.config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "session only")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go s.session(channel, requests)
+	}
+}
+
+// session runs the exec request of a session with sh and reports its exit
+// status; the command is killed when the client closes the channel
+func (s *testSSHServer) session(channel ssh.Channel, requests <-chan *ssh.Request) {
+	defer channel.Close()
+
+	for req := range requests {
+		if req.Type != "exec" || len(req.Payload) < 4 {
+			req.Reply(false, nil)
+			continue
+		}
+		command := string(req.Payload[4 : 4+binary.BigEndian.Uint32(req.Payload)])
+		req.Reply(true, nil)
+
+		cmd := exec.Command("sh", "-c", command)
+		cmd.Stdout = channel
+		cmd.Stderr = channel.Stderr()
+		if err := cmd.Start(); err != nil {
+			channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{127}))
+			return
+		}
+
+		var once sync.Once
+		kill := func() { once.Do(func() { cmd.Process.Kill() }) }
+		go func() {
+			// Requests end when the client closes the channel
+			for req := range requests {
+				req.Reply(false, nil)
+			}
+			kill()
+		}()
+
+		status := uint32(0)
+		if err := cmd.Wait(); err != nil {
+			status = 1
+		}
+		channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+		return
+	}
+}
+
+// waitForLines waits until ch has delivered n lines
+func waitForLines(t *testing.T, ch <-chan []string, n int) []string {
+	t.Helper()
+
+	var lines []string
+	timeout := time.After(10 * time.Second)
+	for len(lines) < n {
+		select {
+		case batch := <-ch:
+			lines = append(lines, batch...)
+		case <-timeout:
+			t.Fatalf("timed out waiting for %d lines, got %q", n, lines)
+		}
+	}
+	return lines
+}
+
+func TestSSHWatcher(t *testing.T) {
+	dir := t.TempDir()
+	configPath, knownHostsPath := startTestSSHServer(t, dir)
+
+	logPath := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(logPath, []byte("one\ntwo\nthree\nfour\nfive\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := NewSSHWatcher(SSHConfig{
+		Host:           "testhost",
+		Path:           logPath,
+		TailLines:      2,
+		ConfigPath:     configPath,
+		KnownHostsPath: knownHostsPath,
+	})
+	if err != nil {
+		t.Fatalf("NewSSHWatcher: %v", err)
+	}
+
+	initial := make(chan []string, 1)
+	live := make(chan []string, 100)
+	done := make(chan error, 1)
+	go func() {
+		done <- w.Watch(func(lines []string) { initial <- lines }, func(lines []string) { live <- lines })
+	}()
+
+	// The initial tail arrives in one batch
+	select {
+	case lines := <-initial:
+		if want := []string{"four", "five"}; !reflect.DeepEqual(lines, want) {
+			t.Errorf("initial = %q, want %q", lines, want)
+		}
+	case err := <-done:
+		t.Fatalf("Watch ended before the initial lines: %v", err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the initial lines")
+	}
+
+	// Appended lines follow without repeating the initial ones
+	appendFile(t, logPath, "six\nseven\n")
+	if got, want := waitForLines(t, live, 2), []string{"six", "seven"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("live = %q, want %q", got, want)
+	}
+
+	// Range reads page through the remote file by offset
+	lines, next, err := w.ReadRange(4, 3)
+	if err != nil {
+		t.Fatalf("ReadRange: %v", err)
+	}
+	if want := []string{"two", "three", "four"}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("range = %q, want %q", lines, want)
+	}
+	if want := int64(len("one\ntwo\nthree\nfour\n")); next != want {
+		t.Errorf("next offset = %d, want %d", next, want)
+	}
+
+	// The path is followed across rotation
+	if err := os.Rename(logPath, logPath+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(logPath, []byte("rotated\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := waitForLines(t, live, 1); got[len(got)-1] != "rotated" {
+		t.Errorf("after rotation = %q, want rotated", got)
+	}
+
+	w.Stop()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Watch after Stop: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Watch did not return after Stop")
+	}
+
+	if _, _, err := w.ReadRange(0, 1); err == nil {
+		t.Error("ReadRange after Stop succeeded, want an error")
+	}
+}
+
+func TestSSHWatcherMissingFile(t *testing.T) {
+	dir := t.TempDir()
+	configPath, knownHostsPath := startTestSSHServer(t, dir)
+
+	w, err := NewSSHWatcher(SSHConfig{
+		Host:           "testhost",
+		Path:           filepath.Join(dir, "missing.log"),
+		TailLines:      10,
+		ConfigPath:     configPath,
+		KnownHostsPath: knownHostsPath,
+	})
+	if err != nil {
+		t.Fatalf("NewSSHWatcher: %v", err)
+	}
+
+	err = w.Watch(func([]string) { t.Error("initial lines sent for a missing file") }, func([]string) {})
+	if err == nil || !strings.Contains(err.Error(), "missing.log") {
+		t.Errorf("err = %v, want an error naming the file", err)
+	}
+}
+
+func TestListSSHHostsWithoutConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	hosts, err := ListSSHHosts()
+	if err != nil {
+		t.Fatalf("ListSSHHosts: %v", err)
+	}
+	if len(hosts) != 0 {
+		t.Errorf("hosts = %q, want none", hosts)
+	}
+}
+
+// appendFile appends text to a file
+func appendFile(t *testing.T, path, text string) {
+	t.Helper()
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(text); err != nil {
+		t.Fatal(err)
+	}
+}
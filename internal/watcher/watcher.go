@@ -2,9 +2,11 @@ package watcher
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -12,6 +14,10 @@ import (
 	"github.com/yourusername/weblogview/internal/config"
 )
 
+// cancelCheckLines is how many lines a whole-file scan reads between
+// checks for cancellation
+const cancelCheckLines = 1024
+
 // FileWatcher watches a file for changes and streams new lines
 type FileWatcher struct {
 	path            string
@@ -26,7 +32,8 @@ type FileWatcher struct {
 	wg              sync.WaitGroup
 }
 
-// NewFileWatcher creates a new file watcher
+// NewFileWatcher creates a new file watcher. With tailLines 0 it only
+// follows lines appended after Start, without reading the file.
 func NewFileWatcher(path string, tailLines int, cfg *config.Config) (*FileWatcher, error) {
 	// Check if file exists
 	if _, err := os.Stat(path); err != nil {
@@ -80,6 +87,11 @@ func (fw *FileWatcher) Start() error {
 	return nil
 }
 
+// Path returns the path of the watched file
+func (fw *FileWatcher) Path() string {
+	return fw.path
+}
+
 // Stop stops watching the file
 func (fw *FileWatcher) Stop() {
 	close(fw.stopChan)
@@ -98,6 +110,13 @@ func (fw *FileWatcher) Stop() {
 
 // readTail reads the last N lines from the file
 func (fw *FileWatcher) readTail() error {
+	if fw.tailLines <= 0 {
+		// Follow-only: start at the current end of the file
+		offset, err := fw.file.Seek(0, io.SeekEnd)
+		fw.offset = offset
+		return err
+	}
+
 	// For simplicity, we'll read the entire file and take last N lines
 	// TODO: Optimize for large files by seeking from the end
 	lines := []string{}
@@ -135,6 +154,72 @@ func (fw *FileWatcher) readTail() error {
 	return nil
 }
 
+// ReadTailFiltered scans the whole file and returns the last N lines for
+// which match returns true
+func (fw *FileWatcher) ReadTailFiltered(ctx context.Context, match func(string) bool) ([]string, error) {
+	return fw.ReadTailEvents(ctx, nil, match)
+}
+
+// ReadTailEvents scans the whole file, joins lines for which continues
+// returns true to the event before them (continues gets the event's line
+// count so far; nil keeps every line separate) and returns the last N
+// events for which match returns true. The scan stops early with the
+// context's error when ctx is canceled.
+func (fw *FileWatcher) ReadTailEvents(ctx context.Context, continues func(line string, eventLines int) bool, match func(string) bool) ([]string, error) {
+	file, err := os.Open(fw.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+
+	// Set a larger buffer for long lines - max 10MB per line
+	buf := make([]byte, 0, fw.config.BufferSize)
+	scanner.Buffer(buf, 10*1024*1024)
+
+	// Keep a ring of the last N matches so memory stays bounded
+	ring := make([]string, 0, fw.tailLines)
+	start := 0
+	keep := func(event string) {
+		if !match(event) {
+			return
+		}
+		if len(ring) < fw.tailLines {
+			ring = append(ring, event)
+		} else if fw.tailLines > 0 {
+			ring[start] = event
+			start = (start + 1) % fw.tailLines
+		}
+	}
+
+	var event []string
+	for scanned := 0; scanner.Scan(); scanned++ {
+		if scanned%cancelCheckLines == 0 && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		line := scanner.Text()
+		if continues == nil {
+			keep(line)
+			continue
+		}
+		if len(event) > 0 && !continues(line, len(event)) {
+			keep(strings.Join(event, "\n"))
+			event = event[:0]
+		}
+		event = append(event, line)
+	}
+	if len(event) > 0 {
+		keep(strings.Join(event, "\n"))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return append(ring[start:], ring[:start]...), nil
+}
+
 // watch monitors the file for changes
 func (fw *FileWatcher) watch() {
 	defer fw.wg.Done()
@@ -247,3 +332,37 @@ func ReadFile(path string, maxLines int) ([]string, error) {
 
 	return lines, nil
 }
+
+// ReadRange reads up to maxLines lines starting at byte offset and returns
+// them together with the offset of the line that follows
+func ReadRange(path string, offset int64, maxLines int) ([]string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, 0, err
+	}
+
+	lines := []string{}
+	reader := bufio.NewReader(file)
+	next := offset
+
+	for maxLines <= 0 || len(lines) < maxLines {
+		raw, err := reader.ReadString('\n')
+		if raw != "" {
+			next += int64(len(raw))
+			lines = append(lines, strings.TrimRight(raw, "\r\n"))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return lines, next, nil
+}
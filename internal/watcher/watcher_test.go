@@ -0,0 +1,44 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yourusername/weblogview/internal/config"
+)
+
+func TestFileWatcherFollowOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte("old one\nold two\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.New("localhost", 0)
+	cfg.PollingInterval = 20 * time.Millisecond
+	fw, err := NewFileWatcher(path, 0, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fw.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer fw.Stop()
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString("new line\n")
+	file.Close()
+
+	select {
+	case line := <-fw.Lines:
+		if line != "new line" {
+			t.Errorf("first line = %q, want the appended line", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no line received after appending")
+	}
+}
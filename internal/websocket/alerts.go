@@ -0,0 +1,80 @@
+package websocket
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/yourusername/weblogview/internal/alerts"
+	"github.com/yourusername/weblogview/internal/anomaly"
+	"github.com/yourusername/weblogview/internal/logline"
+	"github.com/yourusername/weblogview/internal/query"
+)
+
+// broadcastAlert sends an alert to every client
+func (h *Hub) broadcastAlert(alert alerts.Alert) {
+	data, err := json.Marshal(Message{Type: "alert", Alert: &alert})
+	if err != nil {
+		return
+	}
+	h.broadcast <- data
+}
+
+// annotateAnomaly sends an anomaly to the clients that have its source
+// open, dropping it for clients whose send buffer is full
+func (h *Hub) annotateAnomaly(a anomaly.Anomaly) {
+	data, err := json.Marshal(Message{Type: "anomaly", Anomaly: &a})
+	if err != nil {
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for client := range h.clients {
+		if client.currentSource() == a.Source {
+			client.safeSend(data)
+		}
+	}
+}
+
+// sourceOpen reports whether any client has a source open
+func (h *Hub) sourceOpen(source string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for client := range h.clients {
+		if client.currentSource() == source {
+			return true
+		}
+	}
+	return false
+}
+
+// handleGetAnomalies sends the anomalies flagged on the open source, most
+// recent first (ended at or after msg.From if set)
+func (c *Client) handleGetAnomalies(msg *Message) {
+	var since time.Time
+	if msg.From != "" {
+		var err error
+		if since, err = query.ParseTime(msg.From, time.Now()); err != nil {
+			c.sendError("Invalid time: " + err.Error())
+			return
+		}
+	}
+
+	source := c.currentSource()
+	list := []anomaly.Anomaly{}
+	if source != "" {
+		list = c.hub.alerts.Anomalies().Anomalies(source, since)
+	}
+	c.sendMessage(Message{Type: "anomalies", Anomalies: list})
+}
+
+// feedAlerts evaluates the alert rules on new lines of the open source
+func (c *Client) feedAlerts(records []logline.Line) {
+	c.hub.alerts.Feed(c, c.currentSource(), records)
+}
+
+// releaseAlerts lets another client evaluate the alert rules on the
+// sources this client had open
+func (c *Client) releaseAlerts() {
+	c.hub.alerts.Release(c, c.hub.sourceOpen)
+}
@@ -0,0 +1,41 @@
+package websocket
+
+import (
+	"github.com/yourusername/weblogview/internal/bookmarks"
+	"github.com/yourusername/weblogview/internal/logline"
+)
+
+// handleGetBookmarks sends the bookmarks of the open source resolved to
+// its lines: line numbers in the file for files, positions in the loaded
+// lines for other sources. Files are scanned in the background.
+func (c *Client) handleGetBookmarks() {
+	source := c.currentSource()
+	if source == "" {
+		c.sendMessage(Message{Type: "bookmarks"})
+		return
+	}
+	list := bookmarks.GetInstance().List(source)
+
+	if c.watcher != nil {
+		path := c.watcher.Path()
+		go func() {
+			positions, err := bookmarks.ResolveFile(path, list)
+			if err != nil {
+				c.sendError("Failed to resolve bookmarks: " + err.Error())
+				return
+			}
+			c.sendMessage(Message{Type: "bookmarks", Bookmarks: positions})
+		}()
+		return
+	}
+
+	var lines []logline.Line
+	if c.stream != nil {
+		lines = c.enrichLines(c.stream.Recent(0))
+	} else {
+		c.recentMu.Lock()
+		lines = append(lines, c.recent...)
+		c.recentMu.Unlock()
+	}
+	c.sendMessage(Message{Type: "bookmarks", Bookmarks: bookmarks.ResolveLines(list, lines)})
+}
@@ -1,14 +1,29 @@
 package websocket
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/yourusername/weblogview/internal/alerts"
+	"github.com/yourusername/weblogview/internal/anomaly"
+	"github.com/yourusername/weblogview/internal/bookmarks"
 	"github.com/yourusername/weblogview/internal/config"
+	"github.com/yourusername/weblogview/internal/filter"
+	"github.com/yourusername/weblogview/internal/histogram"
+	"github.com/yourusername/weblogview/internal/index"
+	"github.com/yourusername/weblogview/internal/logline"
+	"github.com/yourusername/weblogview/internal/multiline"
+	"github.com/yourusername/weblogview/internal/parser"
+	"github.com/yourusername/weblogview/internal/search"
 	"github.com/yourusername/weblogview/internal/settings"
+	"github.com/yourusername/weblogview/internal/sources"
+	"github.com/yourusername/weblogview/internal/templates"
 	"github.com/yourusername/weblogview/internal/watcher"
 )
 
@@ -24,6 +39,9 @@ const (
 
 	// Maximum message size allowed from peer
 	maxMessageSize = 8192
+
+	// Maximum number of appended lines sent in one batch
+	maxLiveBatch = 1000
 )
 
 var upgrader = websocket.Upgrader{
@@ -37,12 +55,61 @@ var upgrader = websocket.Upgrader{
 
 // Client represents a WebSocket client connection
 type Client struct {
-	hub        *Hub
-	conn       *websocket.Conn
-	send       chan []byte
-	watcher    *watcher.FileWatcher
-	k8sWatcher *watcher.K8sWatcher
-	config     *config.Config
+	hub         *Hub
+	conn        *websocket.Conn
+	send        chan []byte
+	watcher     *watcher.FileWatcher
+	k8sWatcher  *watcher.K8sWatcher
+	docker      *watcher.DockerWatcher
+	journal     *watcher.JournalWatcher
+	ssh         *watcher.SSHWatcher
+	stream      *sources.Stream // Ingested stream being followed
+	unsubscribe func()          // Removes the subscription to the stream
+	config      *config.Config
+
+	// Server-side filter state (see filter.go)
+	filterMu sync.Mutex
+	filter   *filter.Filter
+	parser   *parser.Parser // Metadata detection for the open source
+	source   string         // Settings key of the open source (see sourceKey)
+
+	// Multi-line grouping of the open source, nil if disabled (see multiline.go)
+	grouper       *multiline.Grouper
+	aggregator    *multiline.Aggregator
+	lastStatsSent time.Time
+
+	// Per-level line counts (see levels.go)
+	levelMu      sync.Mutex
+	levelCounts  map[string]int64
+	levelChanged bool
+
+	// Fields seen in the open source (see fields.go)
+	fieldMu  sync.Mutex
+	fieldSet *parser.FieldSet
+
+	// Message templates mined from the open source (see templates.go)
+	templateMu sync.Mutex
+	miner      *templates.Miner
+
+	// Recent lines of the open source for correlation (see correlate.go)
+	recentMu   sync.Mutex
+	recent     []logline.Line
+	recentPath string // Path of the open file, whose lines aren't buffered
+	recentOpen bool
+
+	// Time-bucketed line counts, nil if not subscribed (see histogram.go)
+	histogramMu     sync.Mutex
+	histogram       *histogram.Builder
+	histogramFrom   time.Time
+	histogramCancel context.CancelFunc
+
+	// Running searches by ID (see search.go)
+	searchMu sync.Mutex
+	searches map[string]*runningSearch
+
+	// Cancels the running filtered reload of a file (see filter.go)
+	reloadMu     sync.Mutex
+	reloadCancel context.CancelFunc
 }
 
 // Message represents a WebSocket message
@@ -55,10 +122,67 @@ type Message struct {
 	Namespace     string `json:"namespace,omitempty"`
 	PodName       string `json:"podName,omitempty"`
 	ContainerName string `json:"containerName,omitempty"`
+	// Docker source fields
+	ContainerID string `json:"containerId,omitempty"`
+	Since       string `json:"since,omitempty"`
+	// Journal source fields
+	Unit string `json:"unit,omitempty"`
+	// SSH source fields
+	Host string `json:"host,omitempty"`
+	// Ingested stream fields
+	Stream string `json:"stream,omitempty"`
+	// Filter fields
+	Include       []string      `json:"include,omitempty"`
+	Exclude       []string      `json:"exclude,omitempty"`
+	CaseSensitive bool          `json:"caseSensitive,omitempty"`
+	Stats         *filter.Stats `json:"stats,omitempty"` // Matched/dropped counts while a filter is active
+	// Search fields
+	SearchID   string          `json:"searchId,omitempty"`
+	Query      string          `json:"query,omitempty"`
+	Regex      bool            `json:"regex,omitempty"`
+	Language   bool            `json:"language,omitempty"`
+	Context    int             `json:"context,omitempty"`
+	MaxResults int             `json:"maxResults,omitempty"`
+	Matches    []search.Match  `json:"matches,omitempty"`
+	Summary    *search.Summary `json:"summary,omitempty"`
+	// Range fields
+	Offset     int64 `json:"offset,omitempty"`
+	Count      int   `json:"count,omitempty"`
+	NextOffset int64 `json:"nextOffset,omitempty"`
+	// Level fields
+	MinLevel    string           `json:"minLevel,omitempty"`    // Open and set-filter option: drop lines below this level
+	Levels      []string         `json:"levels,omitempty"`      // Detected level of each line of an unstructured source
+	LevelCounts map[string]int64 `json:"levelCounts,omitempty"` // Lines per level since the source was opened
+	// Time fields
+	Timestamps []int64 `json:"timestamps,omitempty"` // Detected time of each line of an unstructured source (Unix ms, 0 if unknown)
+	From       string  `json:"from,omitempty"`       // Time range start: RFC3339, date, time of day or -15m
+	To         string  `json:"to,omitempty"`
+	Truncated  bool    `json:"truncated,omitempty"`
+	// Multi-line fields
+	Multiline *bool `json:"multiline,omitempty"` // Open option: group stack traces into events (default from settings)
+	// Template fields
+	Templates     []templates.Template `json:"templates,omitempty"`     // Templates of the open source
+	TemplateIDs   []int                `json:"templateIds,omitempty"`   // Template of each line of an unstructured source
+	OnlyTemplates []int                `json:"onlyTemplates,omitempty"` // Set-filter option: keep only lines of these templates
+	HideTemplates []int                `json:"hideTemplates,omitempty"` // Set-filter option: drop lines of these templates
+	// Field fields
+	Fields  []parser.FieldInfo `json:"fields,omitempty"`  // Fields seen in the open source
+	Columns []string           `json:"columns,omitempty"` // Fields shown as columns for the open source
+	// Histogram fields
+	Bucket    string               `json:"bucket,omitempty"` // Bucket size such as 10s or 5m
+	By        string               `json:"by,omitempty"`     // Group by "level" (default), "none" or a field name
+	Histogram *histogram.Histogram `json:"histogram,omitempty"`
+	// Alert and anomaly fields
+	Alert     *alerts.Alert     `json:"alert,omitempty"`     // Fired by an alert rule
+	Anomaly   *anomaly.Anomaly  `json:"anomaly,omitempty"`   // Flagged on the open source
+	Anomalies []anomaly.Anomaly `json:"anomalies,omitempty"` // Flagged on the open source, most recent first
+	// Bookmark fields
+	Bookmarks []bookmarks.Position `json:"bookmarks,omitempty"` // Bookmarks of the open source resolved to its lines
 	// Common fields
-	Lines   []string `json:"lines,omitempty"`
-	Message string   `json:"message,omitempty"`
-	Error   string   `json:"error,omitempty"`
+	Lines   []string       `json:"lines,omitempty"`
+	Records []logline.Line `json:"records,omitempty"` // Structured lines, when the source provides metadata
+	Message string         `json:"message,omitempty"`
+	Error   string         `json:"error,omitempty"`
 }
 
 // HandleWebSocket handles WebSocket connection requests
@@ -92,6 +216,27 @@ func (c *Client) readPump() {
 		if c.k8sWatcher != nil {
 			c.k8sWatcher.Stop()
 		}
+		if c.docker != nil {
+			c.docker.Stop()
+		}
+		if c.journal != nil {
+			c.journal.Stop()
+		}
+		if c.ssh != nil {
+			c.ssh.Stop()
+		}
+		if c.unsubscribe != nil {
+			c.unsubscribe()
+		}
+		c.cancelSearches()
+		c.cancelReload()
+		c.stopGrouping()
+		c.stopHistogram()
+		// The closed tab no longer shows its source
+		c.filterMu.Lock()
+		c.source = ""
+		c.filterMu.Unlock()
+		c.releaseAlerts()
 		c.hub.unregister <- c
 		c.conn.Close()
 	}()
@@ -128,8 +273,10 @@ func (c *Client) readPump() {
 // writePump pumps messages from the hub to the WebSocket connection
 func (c *Client) writePump() {
 	ticker := time.NewTicker(pingPeriod)
+	levelTicker := time.NewTicker(levelCountsInterval)
 	defer func() {
 		ticker.Stop()
+		levelTicker.Stop()
 		c.conn.Close()
 	}()
 
@@ -152,19 +299,81 @@ func (c *Client) writePump() {
 			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
 			}
+
+		case <-levelTicker.C:
+			if message, ok := c.levelCountsMessage(); ok {
+				c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+				if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+					return
+				}
+			}
+			if message, ok := c.histogramMessage(); ok {
+				c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+				if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+					return
+				}
+			}
 		}
 	}
 }
 
 // handleMessage processes incoming messages from the client
 func (c *Client) handleMessage(msg *Message) {
+	if strings.HasPrefix(msg.Type, "open") {
+		c.cancelReload()
+		c.resetFilterStats()
+		if msg.MinLevel != "" {
+			if err := c.setMinLevel(msg.MinLevel); err != nil {
+				c.sendError("Invalid filter: " + err.Error())
+				return
+			}
+		}
+		if err := c.setSource(msg); err != nil {
+			c.sendError("Invalid multi-line settings: " + err.Error())
+			return
+		}
+	}
+
 	switch msg.Type {
 	case "open":
 		c.handleOpenFile(msg)
 	case "open-k8s":
 		c.handleOpenK8s(msg)
+	case "open-docker":
+		c.handleOpenDocker(msg)
+	case "open-journal":
+		c.handleOpenJournal(msg)
+	case "open-ssh":
+		c.handleOpenSSH(msg)
+	case "open-stream":
+		c.handleOpenStream(msg)
 	case "close":
 		c.handleCloseFile()
+		c.closeRecent()
+	case "set-filter":
+		c.handleSetFilter(msg)
+	case "search":
+		c.handleSearch(msg)
+	case "cancel-search":
+		c.handleCancelSearch(msg)
+	case "read-range":
+		c.handleReadRange(msg)
+	case "time-range":
+		c.handleTimeRange(msg)
+	case "get-fields":
+		c.handleGetFields()
+	case "set-columns":
+		c.handleSetColumns(msg)
+	case "get-templates":
+		c.handleGetTemplates(msg)
+	case "subscribe-histogram":
+		c.handleSubscribeHistogram(msg)
+	case "unsubscribe-histogram":
+		c.stopHistogram()
+	case "get-anomalies":
+		c.handleGetAnomalies(msg)
+	case "get-bookmarks":
+		c.handleGetBookmarks()
 	default:
 		c.sendError("Unknown message type: " + msg.Type)
 	}
@@ -205,8 +414,23 @@ func (c *Client) handleOpenFile(msg *Message) {
 		for line := range fw.Lines {
 			select {
 			case <-initialDone:
-				// After initial load, send lines as they come
-				c.sendNewLines([]string{line})
+				// After initial load, send the lines that have arrived
+				// together as one batch
+				batch := []string{line}
+			drain:
+				for len(batch) < maxLiveBatch {
+					select {
+					case next, ok := <-fw.Lines:
+						if !ok {
+							break drain
+						}
+						batch = append(batch, next)
+					default:
+						break drain
+					}
+				}
+				c.sendNewLines(batch)
+				index.Touch(msg.Path)
 			default:
 				// During initial load, collect lines
 				initialLines = append(initialLines, line)
@@ -224,6 +448,18 @@ func (c *Client) handleOpenFile(msg *Message) {
 	time.Sleep(100 * time.Millisecond)
 	close(initialDone)
 
+	// With an active filter, search the whole file for the initial window
+	if f := c.currentFilter(); !f.Empty() {
+		records := c.enrichLines(plainLines(c.groupLines(initialLines)))
+		c.mineTemplates(records, true)
+		c.collectFields(records, true)
+		c.countHistogram(records, true)
+		c.bufferLines(records, true)
+		// Mining may have dropped the template IDs from the filter
+		c.reloadFiltered(c.currentFilter())
+		return
+	}
+
 	// Send initial lines to client
 	if len(initialLines) > 0 {
 		c.sendInitialLines(initialLines)
@@ -232,6 +468,7 @@ func (c *Client) handleOpenFile(msg *Message) {
 
 // handleCloseFile handles file close requests
 func (c *Client) handleCloseFile() {
+	c.cancelReload()
 	if c.watcher != nil {
 		c.watcher.Stop()
 		c.watcher = nil
@@ -240,6 +477,23 @@ func (c *Client) handleCloseFile() {
 		c.k8sWatcher.Stop()
 		c.k8sWatcher = nil
 	}
+	if c.docker != nil {
+		c.docker.Stop()
+		c.docker = nil
+	}
+	if c.journal != nil {
+		c.journal.Stop()
+		c.journal = nil
+	}
+	if c.ssh != nil {
+		c.ssh.Stop()
+		c.ssh = nil
+	}
+	if c.unsubscribe != nil {
+		c.unsubscribe()
+		c.unsubscribe = nil
+		c.stream = nil
+	}
 }
 
 // handleOpenK8s handles Kubernetes pod log requests
@@ -296,24 +550,188 @@ func (c *Client) handleOpenK8s(msg *Message) {
 	}()
 }
 
+// handleOpenDocker handles Docker container log requests
+func (c *Client) handleOpenDocker(msg *Message) {
+	// Stop any existing watcher
+	c.handleCloseFile()
+
+	if msg.ContainerID == "" {
+		c.sendError("Container is required")
+		return
+	}
+
+	// Get tail lines from settings or message
+	tailLines := int64(msg.Tail)
+	if tailLines == 0 {
+		tailLines = int64(settings.GetInstance().GetTailLines())
+	}
+
+	dockerWatcher, err := watcher.NewDockerWatcher(watcher.DockerConfig{
+		ContainerID: msg.ContainerID,
+		TailLines:   tailLines,
+		Since:       msg.Since,
+	})
+	if err != nil {
+		c.sendError("Failed to open container: " + err.Error())
+		return
+	}
+
+	c.docker = dockerWatcher
+
+	// Start watching in background
+	go func() {
+		err := dockerWatcher.Watch(func(lines []string) {
+			c.sendNewLines(lines)
+		})
+		if err != nil {
+			c.sendError("Docker watch error: " + err.Error())
+		}
+	}()
+}
+
+// handleOpenJournal handles systemd journal requests
+func (c *Client) handleOpenJournal(msg *Message) {
+	// Stop any existing watcher
+	c.handleCloseFile()
+
+	// Get tail lines from settings or message
+	tailLines := int64(msg.Tail)
+	if tailLines == 0 {
+		tailLines = int64(settings.GetInstance().GetTailLines())
+	}
+
+	journalWatcher, err := watcher.NewJournalWatcher(watcher.JournalConfig{
+		Unit:      msg.Unit,
+		Path:      msg.Path,
+		TailLines: tailLines,
+	})
+	if err != nil {
+		c.sendError("Failed to open journal: " + err.Error())
+		return
+	}
+
+	c.journal = journalWatcher
+
+	// Start watching in background
+	go func() {
+		err := journalWatcher.Watch(func(records []logline.Line) {
+			c.sendNewRecords(records)
+		})
+		if err != nil {
+			c.sendError("Journal watch error: " + err.Error())
+		}
+	}()
+}
+
+// handleOpenSSH handles remote file requests over SSH
+func (c *Client) handleOpenSSH(msg *Message) {
+	// Stop any existing watcher
+	c.handleCloseFile()
+
+	if msg.Host == "" {
+		c.sendError("Host is required")
+		return
+	}
+	if msg.Path == "" {
+		c.sendError("Path is required")
+		return
+	}
+
+	// Get tail lines from settings or message
+	tailLines := int64(msg.Tail)
+	if tailLines == 0 {
+		tailLines = int64(settings.GetInstance().GetTailLines())
+	}
+
+	sshWatcher, err := watcher.NewSSHWatcher(watcher.SSHConfig{
+		Host:      msg.Host,
+		Path:      msg.Path,
+		TailLines: tailLines,
+	})
+	if err != nil {
+		c.sendError("Failed to open remote file: " + err.Error())
+		return
+	}
+
+	c.ssh = sshWatcher
+
+	// Save host to recent list
+	settings.GetInstance().AddRecentHost(msg.Host)
+
+	// Start watching in background
+	go func() {
+		err := sshWatcher.Watch(func(lines []string) {
+			c.sendInitialLines(lines)
+		}, func(lines []string) {
+			c.sendNewLines(lines)
+		})
+		if err != nil {
+			c.sendError("SSH watch error: " + err.Error())
+		}
+	}()
+}
+
+// handleOpenStream handles requests to follow a stream pushed by an ingest receiver
+func (c *Client) handleOpenStream(msg *Message) {
+	// Stop any existing watcher
+	c.handleCloseFile()
+
+	stream := c.hub.streams.Get(msg.Stream)
+	if stream == nil {
+		c.sendError("Stream not found: " + msg.Stream)
+		return
+	}
+
+	tailLines := msg.Tail
+	if tailLines == 0 {
+		tailLines = settings.GetInstance().GetTailLines()
+	}
+
+	// The snapshot and the subscription are taken under the stream's lock;
+	// lines appended meanwhile wait until "initial" has been sent
+	var mu sync.Mutex
+	var pending [][]logline.Line
+	ready := false
+	recent, unsubscribe := stream.Subscribe(tailLines, func(records []logline.Line) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !ready {
+			pending = append(pending, records)
+			return
+		}
+		c.sendNewRecords(records)
+	})
+	c.stream = stream
+	c.unsubscribe = unsubscribe
+
+	mu.Lock()
+	defer mu.Unlock()
+	c.sendInitialRecords(recent)
+	for _, records := range pending {
+		c.sendNewRecords(records)
+	}
+	pending = nil
+	ready = true
+}
+
 // sendInitialLines sends initial log lines to the client
 func (c *Client) sendInitialLines(lines []string) {
-	msg := Message{
-		Type:  "initial",
-		Lines: lines,
-	}
-	data, _ := json.Marshal(msg)
-	c.safeSend(data)
+	c.sendLines("initial", plainLines(lines), false)
+}
+
+// sendInitialRecords sends initial structured log lines to the client
+func (c *Client) sendInitialRecords(records []logline.Line) {
+	c.sendLines("initial", records, true)
 }
 
 // sendNewLines sends new log lines to the client
 func (c *Client) sendNewLines(lines []string) {
-	msg := Message{
-		Type:  "lines",
-		Lines: lines,
-	}
-	data, _ := json.Marshal(msg)
-	c.safeSend(data)
+	c.sendLines("lines", plainLines(lines), false)
+}
+
+// sendNewRecords sends new structured log lines to the client
+func (c *Client) sendNewRecords(records []logline.Line) {
+	c.sendLines("lines", records, true)
 }
 
 // sendError sends an error message to the client
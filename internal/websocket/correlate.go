@@ -0,0 +1,89 @@
+package websocket
+
+import (
+	"github.com/yourusername/weblogview/internal/logline"
+)
+
+// OpenSource is a source open in a client
+type OpenSource struct {
+	Key   string         // Settings key (see sourceKey)
+	Path  string         // Path of an open file, searched on disk
+	Lines []logline.Line // Recent lines of sources that can't be read again (K8s, Docker, journal, SSH)
+}
+
+// OpenSources returns the sources open in clients, each once. Ingested
+// streams are left out since the registry holds their lines.
+func (h *Hub) OpenSources() []OpenSource {
+	h.mu.RLock()
+	clients := make([]*Client, 0, len(h.clients))
+	for client := range h.clients {
+		clients = append(clients, client)
+	}
+	h.mu.RUnlock()
+
+	seen := map[string]bool{}
+	var open []OpenSource
+	for _, client := range clients {
+		source, ok := client.openSource()
+		if !ok || seen[source.Key] {
+			continue
+		}
+		seen[source.Key] = true
+		open = append(open, source)
+	}
+	return open
+}
+
+// openSource describes the client's open source
+func (c *Client) openSource() (OpenSource, bool) {
+	key := c.currentSource()
+	if key == "" || c.hub.streams.Get(key) != nil {
+		return OpenSource{}, false
+	}
+
+	c.recentMu.Lock()
+	defer c.recentMu.Unlock()
+
+	if !c.recentOpen {
+		return OpenSource{}, false
+	}
+	if c.recentPath != "" {
+		return OpenSource{Key: key, Path: c.recentPath}, true
+	}
+	return OpenSource{Key: key, Lines: append([]logline.Line(nil), c.recent...)}, true
+}
+
+// bufferLines keeps the recent lines of a source that can't be read again,
+// starting over when reset is set (a new initial window)
+func (c *Client) bufferLines(records []logline.Line, reset bool) {
+	c.recentMu.Lock()
+	defer c.recentMu.Unlock()
+
+	if reset {
+		c.recent = nil
+		c.recentPath = ""
+		c.recentOpen = true
+		if c.watcher != nil {
+			c.recentPath = c.watcher.Path()
+		}
+	}
+	if c.recentPath != "" || c.stream != nil {
+		return
+	}
+
+	// Trimmed in steps so appending stays cheap
+	c.recent = append(c.recent, records...)
+	if max := c.config.MaxLinesMemory; max > 0 && len(c.recent) > max+max/4 {
+		c.recent = append([]logline.Line(nil), c.recent[len(c.recent)-max:]...)
+	}
+}
+
+// closeRecent drops the recent lines when the client closes its source
+func (c *Client) closeRecent() {
+	c.recentMu.Lock()
+	defer c.recentMu.Unlock()
+
+	c.recent = nil
+	c.recentPath = ""
+	c.recentOpen = false
+}
@@ -0,0 +1,62 @@
+package websocket
+
+import (
+	"github.com/yourusername/weblogview/internal/logline"
+	"github.com/yourusername/weblogview/internal/parser"
+	"github.com/yourusername/weblogview/internal/settings"
+)
+
+// hasFields reports whether any line has metadata fields
+func hasFields(records []logline.Line) bool {
+	for _, line := range records {
+		if len(line.Fields) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// collectFields adds the fields of lines to the open source's field set,
+// starting over when reset is set (a new initial window)
+func (c *Client) collectFields(records []logline.Line, reset bool) {
+	c.fieldMu.Lock()
+	defer c.fieldMu.Unlock()
+
+	if reset || c.fieldSet == nil {
+		c.fieldSet = parser.NewFieldSet()
+	}
+	for _, line := range records {
+		c.fieldSet.Add(line)
+	}
+}
+
+// handleGetFields sends the fields seen in the open source and the ones
+// chosen as columns
+func (c *Client) handleGetFields() {
+	c.fieldMu.Lock()
+	fields := []parser.FieldInfo{}
+	if c.fieldSet != nil {
+		fields = c.fieldSet.List()
+	}
+	c.fieldMu.Unlock()
+
+	c.sendMessage(Message{
+		Type:    "fields",
+		Fields:  fields,
+		Columns: settings.GetInstance().GetFieldColumns(c.currentSource()),
+	})
+}
+
+// handleSetColumns saves the fields shown as columns for the open source
+func (c *Client) handleSetColumns(msg *Message) {
+	source := c.currentSource()
+	if source == "" {
+		c.sendError("No source is open")
+		return
+	}
+	if err := settings.GetInstance().SetFieldColumns(source, msg.Columns); err != nil {
+		c.sendError("Failed to save columns: " + err.Error())
+		return
+	}
+	c.handleGetFields()
+}
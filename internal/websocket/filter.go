@@ -0,0 +1,260 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/yourusername/weblogview/internal/filter"
+	"github.com/yourusername/weblogview/internal/logline"
+	"github.com/yourusername/weblogview/internal/settings"
+	"github.com/yourusername/weblogview/internal/watcher"
+)
+
+const (
+	// statsInterval is the minimum time between filter-stats messages sent
+	// while every incoming line is being dropped
+	statsInterval = time.Second
+
+	// levelCountBatch is the number of lines scanned by a filtered reload
+	// that are counted at once
+	levelCountBatch = 4096
+)
+
+// handleSetFilter replaces the client's filter and reloads the current
+// source so the initial window reflects the new patterns
+func (c *Client) handleSetFilter(msg *Message) {
+	f, err := filter.New(filter.Options{
+		Include:       msg.Include,
+		Exclude:       msg.Exclude,
+		CaseSensitive: msg.CaseSensitive,
+		Query:         msg.Query,
+		MinLevel:      msg.MinLevel,
+		OnlyTemplates: msg.OnlyTemplates,
+		HideTemplates: msg.HideTemplates,
+	})
+	if err != nil {
+		c.sendError("Invalid filter: " + err.Error())
+		return
+	}
+
+	c.filterMu.Lock()
+	c.filter = f
+	c.filterMu.Unlock()
+
+	c.reloadFiltered(f)
+}
+
+// reloadFiltered re-sends the initial window of the current source through
+// the filter. Files are rescanned from disk so matches outside the window
+// the browser has loaded are found too; the level counts start over with
+// the lines scanned. The rescan runs in the background and is canceled by
+// a newer filter or source.
+func (c *Client) reloadFiltered(f *filter.Filter) {
+	c.cancelReload()
+	tailLines := settings.GetInstance().GetTailLines()
+
+	switch {
+	case c.watcher != nil:
+		ctx, cancel := context.WithCancel(context.Background())
+		c.reloadMu.Lock()
+		c.reloadCancel = cancel
+		c.reloadMu.Unlock()
+
+		fw := c.watcher
+		go func() {
+			defer cancel()
+			c.rescanFiltered(ctx, fw, f)
+		}()
+
+	case c.stream != nil:
+		records := c.enrichLines(c.stream.Recent(0))
+		c.matchTemplates(records)
+		c.countLevels(records, true)
+		records = f.Apply(records)
+		if len(records) > tailLines {
+			records = records[len(records)-tailLines:]
+		}
+		c.sendMessage(c.linesMessage("initial", records, true, f))
+
+	default:
+		// Live-only sources: the filter applies to lines from now on
+		c.sendFilterStats(f)
+	}
+}
+
+// rescanFiltered scans a file for the initial window of a filtered reload
+func (c *Client) rescanFiltered(ctx context.Context, fw *watcher.FileWatcher, f *filter.Filter) {
+	p := c.currentParser()
+	var continues func(string, int) bool
+	if grouper, _ := c.currentGrouping(); grouper != nil {
+		continues = grouper.Continues
+	}
+	// Level counts cover every line scanned, as they do for lines that
+	// reach processLines before its filter
+	scanned := make([]logline.Line, 0, levelCountBatch)
+	reset := true
+	lines, err := fw.ReadTailEvents(ctx, continues, func(text string) bool {
+		line := p.Parse(text)
+		line.Template = c.matchTemplate(text)
+		if scanned = append(scanned, line); len(scanned) == levelCountBatch {
+			c.whileReloading(ctx, func() { c.countLevels(scanned, reset) })
+			scanned, reset = scanned[:0], false
+		}
+		return f.AcceptLine(line)
+	})
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		c.sendError("Failed to apply filter: " + err.Error())
+		return
+	}
+
+	records := c.enrichLines(plainLines(lines))
+	c.matchTemplates(records)
+	c.whileReloading(ctx, func() {
+		c.countLevels(scanned, reset)
+		c.sendMessage(c.linesMessage("initial", records, false, f))
+	})
+}
+
+// whileReloading runs fn unless the reload of ctx has been canceled, so a
+// stale rescan can't touch the counts or window of a newer one
+func (c *Client) whileReloading(ctx context.Context, fn func()) {
+	c.reloadMu.Lock()
+	defer c.reloadMu.Unlock()
+
+	if ctx.Err() == nil {
+		fn()
+	}
+}
+
+// cancelReload cancels the running filtered reload, if any
+func (c *Client) cancelReload() {
+	c.reloadMu.Lock()
+	defer c.reloadMu.Unlock()
+
+	if c.reloadCancel != nil {
+		c.reloadCancel()
+		c.reloadCancel = nil
+	}
+}
+
+// resetFilterStats starts the matched/dropped counts of the active filter
+// over, so a newly opened source doesn't carry the previous one's counts
+func (c *Client) resetFilterStats() {
+	c.filterMu.Lock()
+	defer c.filterMu.Unlock()
+
+	if c.filter.Empty() {
+		return
+	}
+	// A fresh filter rather than zeroed counters, which lines of the
+	// previous source may still be updating
+	if f, err := filter.New(c.filter.Options()); err == nil {
+		c.filter = f
+	}
+}
+
+// currentFilter returns the active filter, or nil if none is set
+func (c *Client) currentFilter() *filter.Filter {
+	c.filterMu.Lock()
+	defer c.filterMu.Unlock()
+	return c.filter
+}
+
+// sendLines groups multi-line events, then filters lines and sends them to
+// the client
+func (c *Client) sendLines(msgType string, records []logline.Line, structured bool) {
+	records, ok := c.groupRecords(msgType, records, structured)
+	if !ok {
+		return
+	}
+	c.processLines(msgType, records, structured)
+}
+
+// processLines filters lines and sends them to the client. Structured
+// sources and lines with extracted fields also send the records with their
+// metadata.
+func (c *Client) processLines(msgType string, records []logline.Line, structured bool) {
+	records = c.enrichLines(records)
+	c.mineTemplates(records, msgType == "initial")
+	c.countLevels(records, msgType == "initial")
+	c.collectFields(records, msgType == "initial")
+	c.countHistogram(records, msgType == "initial")
+	c.bufferLines(records, msgType == "initial")
+	if msgType == "lines" {
+		c.feedAlerts(records)
+	}
+
+	f := c.currentFilter()
+	records = f.Apply(records)
+
+	// Keep the client's counts moving even when everything is dropped
+	if len(records) == 0 && msgType == "lines" {
+		if !f.Empty() {
+			c.filterMu.Lock()
+			due := time.Since(c.lastStatsSent) >= statsInterval
+			if due {
+				c.lastStatsSent = time.Now()
+			}
+			c.filterMu.Unlock()
+			if due {
+				c.sendFilterStats(f)
+			}
+		}
+		return
+	}
+
+	c.sendMessage(c.linesMessage(msgType, records, structured, f))
+}
+
+// linesMessage builds a lines or initial message
+func (c *Client) linesMessage(msgType string, records []logline.Line, structured bool, f *filter.Filter) Message {
+	msg := Message{
+		Type:  msgType,
+		Lines: logline.Texts(records),
+	}
+	if structured || hasFields(records) {
+		msg.Records = records
+	} else {
+		msg.Levels = lineLevels(records)
+		msg.Timestamps = lineTimestamps(records)
+		msg.TemplateIDs = lineTemplates(records)
+	}
+	if !f.Empty() {
+		stats := f.Stats()
+		msg.Stats = &stats
+	}
+	return msg
+}
+
+// sendFilterStats sends the matched/dropped counts of a filter
+func (c *Client) sendFilterStats(f *filter.Filter) {
+	stats := f.Stats()
+	c.sendMessage(Message{
+		Type:  "filter-stats",
+		Stats: &stats,
+	})
+}
+
+// sendMessage marshals and sends a message to the client
+func (c *Client) sendMessage(msg Message) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("Error encoding %s message: %v", msg.Type, err)
+		return
+	}
+	c.safeSend(data)
+}
+
+// plainLines wraps raw text lines as log lines without metadata
+func plainLines(lines []string) []logline.Line {
+	records := make([]logline.Line, len(lines))
+	for i, line := range lines {
+		records[i] = logline.New(line)
+	}
+	return records
+}
@@ -0,0 +1,128 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/yourusername/weblogview/internal/histogram"
+	"github.com/yourusername/weblogview/internal/logline"
+	"github.com/yourusername/weblogview/internal/query"
+	"github.com/yourusername/weblogview/internal/search"
+)
+
+// handleSubscribeHistogram starts counting the open source's lines into
+// time buckets. Files are counted in full from their timestamps (from
+// msg.From if set) and ingested streams from their buffer; other sources
+// are counted from their initial window when subscribed before opening.
+// Live lines are added as they arrive and changes are pushed with the
+// level counts.
+func (c *Client) handleSubscribeHistogram(msg *Message) {
+	bucket, err := histogram.ParseBucket(msg.Bucket)
+	if err != nil {
+		c.sendError("Invalid histogram: " + err.Error())
+		return
+	}
+	var from time.Time
+	if msg.From != "" {
+		if from, err = query.ParseTime(msg.From, time.Now()); err != nil {
+			c.sendError("Invalid histogram: " + err.Error())
+			return
+		}
+	}
+
+	c.histogramMu.Lock()
+	c.histogramFrom = from
+	c.histogramMu.Unlock()
+
+	c.resetHistogram(histogram.New(bucket, msg.By))
+	if c.watcher == nil && c.stream != nil {
+		// The stream's buffer is the initial window
+		b := c.currentHistogram()
+		for _, line := range c.enrichLines(c.stream.Recent(0)) {
+			b.Add(line)
+		}
+	}
+}
+
+// resetHistogram replaces the client's histogram, canceling a running file
+// scan, and starts scanning the open file into the new one
+func (c *Client) resetHistogram(b *histogram.Builder) {
+	c.histogramMu.Lock()
+	defer c.histogramMu.Unlock()
+
+	if c.histogramCancel != nil {
+		c.histogramCancel()
+		c.histogramCancel = nil
+	}
+	c.histogram = b
+	if b == nil || c.watcher == nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.histogramCancel = cancel
+	path, from, p := c.watcher.Path(), c.histogramFrom, c.currentParser()
+	go func() {
+		if err := search.Histogram(ctx, path, from, time.Time{}, b, p); err != nil && ctx.Err() == nil {
+			c.sendError("Histogram failed: " + err.Error())
+		}
+	}()
+}
+
+// currentHistogram returns the client's histogram, or nil if not
+// subscribed
+func (c *Client) currentHistogram() *histogram.Builder {
+	c.histogramMu.Lock()
+	defer c.histogramMu.Unlock()
+	return c.histogram
+}
+
+// countHistogram adds lines to the client's histogram. reset starts over
+// for a newly opened source: files are rescanned from disk, so their
+// initial window isn't counted twice. Live lines without a timestamp
+// count at the time they arrive.
+func (c *Client) countHistogram(records []logline.Line, reset bool) {
+	b := c.currentHistogram()
+	if b == nil {
+		return
+	}
+	if reset {
+		b = histogram.New(b.Bucket(), b.By())
+		c.resetHistogram(b)
+		if c.watcher != nil {
+			return
+		}
+		for _, line := range records {
+			b.Add(line)
+		}
+		return
+	}
+
+	now := time.Now()
+	for _, line := range records {
+		t := line.Time()
+		if t.IsZero() {
+			t = now
+		}
+		b.AddAt(line, t)
+	}
+}
+
+// histogramMessage returns a histogram message if lines were counted since
+// the last one
+func (c *Client) histogramMessage() ([]byte, bool) {
+	b := c.currentHistogram()
+	if b == nil || !b.Changed() {
+		return nil, false
+	}
+	h := b.Histogram()
+	data, _ := json.Marshal(Message{Type: "histogram", Histogram: &h})
+	return data, true
+}
+
+// stopHistogram stops counting lines into time buckets, canceling a
+// running file scan
+func (c *Client) stopHistogram() {
+	c.resetHistogram(nil)
+}
@@ -3,6 +3,9 @@ package websocket
 import (
 	"log"
 	"sync"
+
+	"github.com/yourusername/weblogview/internal/alerts"
+	"github.com/yourusername/weblogview/internal/sources"
 )
 
 // Hub maintains the set of active clients and broadcasts messages
@@ -19,18 +22,30 @@ type Hub struct {
 	// Unregister requests from clients
 	unregister chan *Client
 
+	// Streams pushed to the server by ingest receivers
+	streams *sources.Registry
+
+	// Alert rules evaluated on the sources clients have open
+	alerts *alerts.Engine
+
 	// Mutex for thread-safe operations
 	mu sync.RWMutex
 }
 
-// NewHub creates a new Hub instance
-func NewHub() *Hub {
-	return &Hub{
+// NewHub creates a new Hub instance that broadcasts the engine's alerts
+// to all clients and its anomalies to the clients showing their source
+func NewHub(streams *sources.Registry, engine *alerts.Engine) *Hub {
+	h := &Hub{
 		clients:    make(map[*Client]bool),
 		broadcast:  make(chan []byte, 256),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
+		streams:    streams,
+		alerts:     engine,
 	}
+	engine.OnAlert(h.broadcastAlert)
+	engine.Anomalies().OnAnomaly(h.annotateAnomaly)
+	return h
 }
 
 // Run starts the hub's main loop
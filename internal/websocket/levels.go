@@ -0,0 +1,169 @@
+package websocket
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/yourusername/weblogview/internal/filter"
+	"github.com/yourusername/weblogview/internal/logline"
+	"github.com/yourusername/weblogview/internal/parser"
+)
+
+// levelCountsInterval is how often changed level counts are pushed
+const levelCountsInterval = 2 * time.Second
+
+// enrichLines returns copies of the lines with detected metadata filled in.
+// Lines are copied because stream subscribers share the same slice.
+func (c *Client) enrichLines(records []logline.Line) []logline.Line {
+	p := c.currentParser()
+	enriched := make([]logline.Line, len(records))
+	for i, line := range records {
+		p.Enrich(&line)
+		enriched[i] = line
+	}
+	return enriched
+}
+
+// lineLevels returns the level of each line, or nil if none has a level
+func lineLevels(records []logline.Line) []string {
+	levels := make([]string, len(records))
+	found := false
+	for i, line := range records {
+		levels[i] = line.Level
+		found = found || line.Level != ""
+	}
+	if !found {
+		return nil
+	}
+	return levels
+}
+
+// lineTimestamps returns the timestamp of each line (Unix milliseconds, 0
+// if unknown), or nil if no line has one
+func lineTimestamps(records []logline.Line) []int64 {
+	timestamps := make([]int64, len(records))
+	found := false
+	for i, line := range records {
+		timestamps[i] = line.Timestamp
+		found = found || line.Timestamp != 0
+	}
+	if !found {
+		return nil
+	}
+	return timestamps
+}
+
+// currentParser returns the parser of the open source
+func (c *Client) currentParser() *parser.Parser {
+	c.filterMu.Lock()
+	defer c.filterMu.Unlock()
+	return c.parser
+}
+
+// setSource sets up the parser of a newly opened source with its
+// timestamp layout and custom parser from settings, and its multi-line
+// grouping
+func (c *Client) setSource(msg *Message) error {
+	source := sourceKey(msg)
+	var path, container string
+	switch msg.Type {
+	case "open", "open-ssh":
+		path = msg.Path
+	case "open-k8s":
+		container = msg.ContainerName
+	}
+	p := parser.ForSource(source, path, container)
+
+	c.filterMu.Lock()
+	c.parser = p
+	c.source = source
+	c.filterMu.Unlock()
+	c.releaseAlerts()
+
+	return c.setGrouping(msg, p)
+}
+
+// currentSource returns the settings key of the open source
+func (c *Client) currentSource() string {
+	c.filterMu.Lock()
+	defer c.filterMu.Unlock()
+	return c.source
+}
+
+// sourceKey identifies the source of an open message in settings: the
+// path for files, otherwise kind:name
+func sourceKey(msg *Message) string {
+	switch msg.Type {
+	case "open":
+		return msg.Path
+	case "open-k8s":
+		return "k8s:" + msg.Namespace + "/" + msg.PodName
+	case "open-docker":
+		return "docker:" + msg.ContainerID
+	case "open-journal":
+		if msg.Path != "" {
+			return msg.Path
+		}
+		return "journal:" + msg.Unit
+	case "open-ssh":
+		return "ssh:" + msg.Host + ":" + msg.Path
+	case "open-stream":
+		return msg.Stream
+	}
+	return ""
+}
+
+// countLevels adds lines to the per-level counts, starting over when reset
+// is set (a new initial window)
+func (c *Client) countLevels(records []logline.Line, reset bool) {
+	c.levelMu.Lock()
+	defer c.levelMu.Unlock()
+
+	if reset || c.levelCounts == nil {
+		c.levelCounts = map[string]int64{}
+		c.levelChanged = true
+	}
+	for _, line := range records {
+		level := line.Level
+		if level == "" {
+			level = "unknown"
+		}
+		c.levelCounts[level]++
+		c.levelChanged = true
+	}
+}
+
+// levelCountsMessage returns a level-counts message if the counts changed
+// since the last one
+func (c *Client) levelCountsMessage() ([]byte, bool) {
+	c.levelMu.Lock()
+	defer c.levelMu.Unlock()
+
+	if !c.levelChanged {
+		return nil, false
+	}
+	c.levelChanged = false
+
+	counts := make(map[string]int64, len(c.levelCounts))
+	for level, n := range c.levelCounts {
+		counts[level] = n
+	}
+	data, _ := json.Marshal(Message{Type: "level-counts", LevelCounts: counts})
+	return data, true
+}
+
+// setMinLevel changes the minimum level of the client's filter, keeping
+// its other options
+func (c *Client) setMinLevel(minLevel string) error {
+	c.filterMu.Lock()
+	defer c.filterMu.Unlock()
+
+	opts := c.filter.Options()
+	opts.MinLevel = minLevel
+	f, err := filter.New(opts)
+	if err != nil {
+		return err
+	}
+	c.filter = f
+	return nil
+}
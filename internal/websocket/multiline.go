@@ -0,0 +1,94 @@
+package websocket
+
+import (
+	"time"
+
+	"github.com/yourusername/weblogview/internal/logline"
+	"github.com/yourusername/weblogview/internal/multiline"
+	"github.com/yourusername/weblogview/internal/parser"
+	"github.com/yourusername/weblogview/internal/settings"
+)
+
+// setGrouping sets up multi-line grouping for a newly opened source. Only
+// plain text sources are grouped; records of structured sources are whole
+// events already.
+func (c *Client) setGrouping(msg *Message, p *parser.Parser) error {
+	cfg := settings.GetInstance().GetMultiline()
+	enabled := cfg.Enabled
+	if msg.Multiline != nil {
+		enabled = *msg.Multiline
+	}
+	switch msg.Type {
+	case "open-journal", "open-stream":
+		enabled = false
+	}
+
+	var grouper *multiline.Grouper
+	var aggregator *multiline.Aggregator
+	if enabled {
+		var err error
+		grouper, err = multiline.NewGrouper(multiline.Options{
+			Patterns:     cfg.Patterns,
+			Timestamp:    cfg.Timestamp,
+			MaxLines:     cfg.MaxLines,
+			FlushTimeout: time.Duration(cfg.FlushTimeoutMs) * time.Millisecond,
+		}, p)
+		if err != nil {
+			return err
+		}
+		aggregator = multiline.NewAggregator(grouper, func(events []string) {
+			c.processLines("lines", plainLines(events), false)
+		})
+	}
+
+	c.filterMu.Lock()
+	previous := c.aggregator
+	c.grouper = grouper
+	c.aggregator = aggregator
+	c.filterMu.Unlock()
+
+	if previous != nil {
+		previous.Stop()
+	}
+	return nil
+}
+
+// currentGrouping returns the multi-line grouping of the open source, or
+// nils if lines aren't grouped
+func (c *Client) currentGrouping() (*multiline.Grouper, *multiline.Aggregator) {
+	c.filterMu.Lock()
+	defer c.filterMu.Unlock()
+	return c.grouper, c.aggregator
+}
+
+// groupLines joins a window of lines into events when grouping is enabled
+func (c *Client) groupLines(lines []string) []string {
+	if grouper, _ := c.currentGrouping(); grouper != nil {
+		return grouper.Group(lines)
+	}
+	return lines
+}
+
+// stopGrouping stops the live aggregator, dropping its pending event
+func (c *Client) stopGrouping() {
+	if _, aggregator := c.currentGrouping(); aggregator != nil {
+		aggregator.Stop()
+	}
+}
+
+// groupRecords routes plain lines through multi-line grouping. Initial
+// windows are grouped at once; live lines go to the aggregator, which
+// passes finished events on to processLines. Returns false if the lines
+// were taken by the aggregator.
+func (c *Client) groupRecords(msgType string, records []logline.Line, structured bool) ([]logline.Line, bool) {
+	grouper, aggregator := c.currentGrouping()
+	if grouper == nil || structured {
+		return records, true
+	}
+	if msgType == "initial" {
+		aggregator.Reset()
+		return plainLines(grouper.Group(logline.Texts(records))), true
+	}
+	aggregator.Add(logline.Texts(records))
+	return nil, false
+}
@@ -0,0 +1,213 @@
+package websocket
+
+import (
+	"context"
+	"time"
+
+	"github.com/yourusername/weblogview/internal/index"
+	"github.com/yourusername/weblogview/internal/parser"
+	"github.com/yourusername/weblogview/internal/query"
+	"github.com/yourusername/weblogview/internal/search"
+	"github.com/yourusername/weblogview/internal/watcher"
+)
+
+const (
+	// searchBatchSize is the number of matches sent per search-results message
+	searchBatchSize = 100
+
+	// searchFlushInterval is the longest time matches are held before sending
+	searchFlushInterval = 250 * time.Millisecond
+)
+
+// runningSearch is a search started by the client. Each run has its own
+// entry so a finished run can tell whether a restart has replaced it.
+type runningSearch struct {
+	cancel context.CancelFunc
+}
+
+// handleSearch starts a whole-file search and streams matches back in
+// search-results messages, finishing with search-done
+func (c *Client) handleSearch(msg *Message) {
+	path := msg.Path
+	if path == "" && c.watcher != nil {
+		path = c.watcher.Path()
+	}
+	if path == "" {
+		c.sendError("Search requires an open file or a path")
+		return
+	}
+
+	opts := search.Options{
+		Query:         msg.Query,
+		Regex:         msg.Regex,
+		Language:      msg.Language,
+		CaseSensitive: msg.CaseSensitive,
+		Context:       msg.Context,
+		MaxResults:    msg.MaxResults,
+		Parser:        parser.ForSource(path, path, ""),
+	}
+	if err := search.Validate(opts); err != nil {
+		c.sendMessage(Message{Type: "search-done", SearchID: msg.SearchID, Error: err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	run := &runningSearch{cancel: cancel}
+
+	c.searchMu.Lock()
+	if c.searches == nil {
+		c.searches = make(map[string]*runningSearch)
+	}
+	if previous, ok := c.searches[msg.SearchID]; ok {
+		previous.cancel()
+	}
+	c.searches[msg.SearchID] = run
+	c.searchMu.Unlock()
+
+	go func() {
+		defer func() {
+			c.searchMu.Lock()
+			// A search restarted with the same ID has replaced this one
+			if c.searches[msg.SearchID] == run {
+				delete(c.searches, msg.SearchID)
+			}
+			c.searchMu.Unlock()
+			cancel()
+		}()
+
+		batch := []search.Match{}
+		lastFlush := time.Now()
+		flush := func() {
+			if len(batch) == 0 {
+				return
+			}
+			c.sendMessage(Message{Type: "search-results", SearchID: msg.SearchID, Matches: batch})
+			batch = []search.Match{}
+			lastFlush = time.Now()
+		}
+
+		summary, err := index.Search(ctx, path, opts, func(m search.Match) error {
+			batch = append(batch, m)
+			if len(batch) >= searchBatchSize || time.Since(lastFlush) >= searchFlushInterval {
+				flush()
+			}
+			return nil
+		})
+		flush()
+
+		done := Message{Type: "search-done", SearchID: msg.SearchID, Summary: &summary}
+		if err != nil {
+			done.Error = err.Error()
+		}
+		c.sendMessage(done)
+	}()
+}
+
+// handleCancelSearch cancels a running search
+func (c *Client) handleCancelSearch(msg *Message) {
+	c.searchMu.Lock()
+	defer c.searchMu.Unlock()
+
+	if run, ok := c.searches[msg.SearchID]; ok {
+		run.cancel()
+	}
+}
+
+// cancelSearches cancels every running search of the client
+func (c *Client) cancelSearches() {
+	c.searchMu.Lock()
+	defer c.searchMu.Unlock()
+
+	for _, run := range c.searches {
+		run.cancel()
+	}
+}
+
+// handleReadRange sends the lines starting at a byte offset of a file or
+// the remote file open over SSH, e.g. to jump to a search match that is
+// outside the loaded window
+func (c *Client) handleReadRange(msg *Message) {
+	path := msg.Path
+	if path == "" && c.watcher != nil {
+		path = c.watcher.Path()
+	}
+	if path == "" && c.ssh == nil {
+		c.sendError("Reading a range requires an open file or a path")
+		return
+	}
+
+	count := msg.Count
+	if count <= 0 || count > c.config.ChunkSize {
+		count = c.config.ChunkSize
+	}
+
+	var lines []string
+	var next int64
+	var err error
+	if path == "" {
+		// Remote file open over SSH
+		lines, next, err = c.ssh.ReadRange(msg.Offset, count)
+	} else {
+		lines, next, err = watcher.ReadRange(path, msg.Offset, count)
+	}
+	if err != nil {
+		c.sendError("Failed to read range: " + err.Error())
+		return
+	}
+
+	c.sendMessage(Message{
+		Type:       "range",
+		Offset:     msg.Offset,
+		NextOffset: next,
+		Lines:      lines,
+	})
+}
+
+// handleTimeRange sends the lines of a file between two times, found by
+// binary searching the file's timestamps
+func (c *Client) handleTimeRange(msg *Message) {
+	path := msg.Path
+	if path == "" && c.watcher != nil {
+		path = c.watcher.Path()
+	}
+	if path == "" {
+		c.sendError("Reading a time range requires an open file or a path")
+		return
+	}
+
+	now := time.Now()
+	var from, to time.Time
+	var err error
+	if msg.From != "" {
+		if from, err = query.ParseTime(msg.From, now); err != nil {
+			c.sendError("Invalid time range: " + err.Error())
+			return
+		}
+	}
+	if msg.To != "" {
+		if to, err = query.ParseTime(msg.To, now); err != nil {
+			c.sendError("Invalid time range: " + err.Error())
+			return
+		}
+	}
+
+	count := msg.Count
+	if count <= 0 || count > c.config.ChunkSize {
+		count = c.config.ChunkSize
+	}
+
+	p := parser.ForSource(path, path, "")
+	result, err := search.ReadTimeRange(path, from, to, count, p)
+	if err != nil {
+		c.sendError("Failed to read time range: " + err.Error())
+		return
+	}
+
+	c.sendMessage(Message{
+		Type:       "range",
+		Offset:     result.Offset,
+		NextOffset: result.NextOffset,
+		Lines:      result.Lines,
+		Truncated:  result.Truncated,
+	})
+}
@@ -0,0 +1,96 @@
+package websocket
+
+import (
+	"github.com/yourusername/weblogview/internal/filter"
+	"github.com/yourusername/weblogview/internal/logline"
+	"github.com/yourusername/weblogview/internal/templates"
+)
+
+// mineTemplates assigns template IDs to lines, training the open source's
+// miner. reset starts over with a new miner (a new initial window).
+func (c *Client) mineTemplates(records []logline.Line, reset bool) {
+	c.templateMu.Lock()
+	if reset || c.miner == nil {
+		c.miner = templates.NewMiner()
+		// IDs of the previous miner's templates mean nothing to the new one
+		c.clearTemplateFilter()
+	}
+	miner := c.miner
+	c.templateMu.Unlock()
+
+	for i := range records {
+		records[i].Template = miner.Add(records[i].Text, records[i].Time())
+	}
+}
+
+// clearTemplateFilter drops the template IDs from the active filter
+func (c *Client) clearTemplateFilter() {
+	c.filterMu.Lock()
+	defer c.filterMu.Unlock()
+
+	opts := c.filter.Options()
+	if len(opts.OnlyTemplates) == 0 && len(opts.HideTemplates) == 0 {
+		return
+	}
+	opts.OnlyTemplates, opts.HideTemplates = nil, nil
+	if f, err := filter.New(opts); err == nil {
+		c.filter = f
+	}
+}
+
+// matchTemplates assigns template IDs to lines without training the miner,
+// for rescans of lines that were mined before
+func (c *Client) matchTemplates(records []logline.Line) {
+	for i := range records {
+		records[i].Template = c.matchTemplate(records[i].Text)
+	}
+}
+
+// matchTemplate returns the template ID of a line without training the
+// miner, or 0
+func (c *Client) matchTemplate(text string) int {
+	c.templateMu.Lock()
+	miner := c.miner
+	c.templateMu.Unlock()
+
+	if miner == nil {
+		return 0
+	}
+	return miner.Match(text)
+}
+
+// lineTemplates returns the template ID of each line, or nil if no line
+// has one
+func lineTemplates(records []logline.Line) []int {
+	ids := make([]int, len(records))
+	found := false
+	for i, line := range records {
+		ids[i] = line.Template
+		found = found || line.Template != 0
+	}
+	if !found {
+		return nil
+	}
+	return ids
+}
+
+// handleGetTemplates sends the templates of the open source, most frequent
+// first (at most msg.Count if set)
+func (c *Client) handleGetTemplates(msg *Message) {
+	c.templateMu.Lock()
+	miner := c.miner
+	c.templateMu.Unlock()
+
+	list := []templates.Template{}
+	if miner != nil {
+		list = miner.Templates()
+	}
+	if msg.Count > 0 && len(list) > msg.Count {
+		list = list[:msg.Count]
+	}
+
+	c.sendMessage(Message{
+		Type:      "templates",
+		Templates: list,
+	})
+}
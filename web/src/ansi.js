@@ -0,0 +1,19 @@
+import AnsiToHtml from 'ansi-to-html';
+
+// ANSI colors of the log viewers, shared with the HTML snapshot viewer
+export const ansiConverter = new AnsiToHtml({
+  fg: '#d4d4d4',
+  bg: '#1e1e1e',
+  newline: false,
+  escapeXML: true,
+  colors: {
+    0: '#000000',  // black
+    1: '#cd3131',  // red
+    2: '#0dbc79',  // green
+    3: '#e5e510',  // yellow
+    4: '#2472c8',  // blue
+    5: '#bc3fbc',  // magenta
+    6: '#11a8cd',  // cyan
+    7: '#e5e5e5',  // white
+  }
+});
@@ -0,0 +1,116 @@
+import { useState } from 'preact/hooks';
+
+export function ColumnPicker({ fields, columns, onChange, onOpen }) {
+  const [open, setOpen] = useState(false);
+
+  // Chosen columns stay listed even when no loaded line has them
+  const names = fields.map(field => field.name);
+  const missing = columns.filter(name => !names.includes(name));
+  const items = [
+    ...fields,
+    ...missing.map(name => ({ name, count: 0, values: [] })),
+  ];
+
+  const handleToggle = () => {
+    if (!open && onOpen) {
+      // Refresh the field list before showing it
+      onOpen();
+    }
+    setOpen(!open);
+  };
+
+  const toggleColumn = (name) => {
+    const next = columns.includes(name)
+      ? columns.filter(column => column !== name)
+      : [...columns, name];
+    onChange(next);
+  };
+
+  return (
+    <div style={styles.container}>
+      <button
+        type="button"
+        style={styles.button}
+        onClick={handleToggle}
+        title="Choose fields to show as columns"
+      >
+        Columns{columns.length > 0 ? ` (${columns.length})` : ''}
+      </button>
+
+      {open && (
+        <div style={styles.menu}>
+          {items.length === 0 ? (
+            <div style={styles.empty}>No fields found in this source</div>
+          ) : (
+            items.map(field => (
+              <label
+                key={field.name}
+                style={styles.item}
+                title={(field.values || []).join(', ')}
+              >
+                <input
+                  type="checkbox"
+                  checked={columns.includes(field.name)}
+                  onChange={() => toggleColumn(field.name)}
+                />
+                <span style={styles.name}>{field.name}</span>
+                <span style={styles.count}>{field.count.toLocaleString()}</span>
+              </label>
+            ))
+          )}
+        </div>
+      )}
+    </div>
+  );
+}
+
+const styles = {
+  container: {
+    position: 'relative',
+  },
+  button: {
+    background: 'none',
+    border: '1px solid #555',
+    borderRadius: '4px',
+    color: '#e0e0e0',
+    cursor: 'pointer',
+    padding: '6px 12px',
+    fontSize: '13px',
+    whiteSpace: 'nowrap',
+  },
+  menu: {
+    position: 'absolute',
+    right: 0,
+    bottom: '100%',
+    marginBottom: '6px',
+    minWidth: '240px',
+    maxHeight: '300px',
+    overflowY: 'auto',
+    backgroundColor: '#2d2d30',
+    border: '1px solid #3c3c3c',
+    borderRadius: '4px',
+    zIndex: 10,
+  },
+  item: {
+    display: 'flex',
+    alignItems: 'center',
+    gap: '8px',
+    padding: '6px 12px',
+    fontSize: '13px',
+    color: '#d4d4d4',
+    cursor: 'pointer',
+  },
+  name: {
+    flex: 1,
+    fontFamily: 'monospace',
+  },
+  count: {
+    color: '#888888',
+    fontSize: '12px',
+  },
+  empty: {
+    padding: '8px 12px',
+    fontSize: '13px',
+    color: '#888888',
+  },
+};
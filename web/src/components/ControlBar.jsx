@@ -1,3 +1,5 @@
+import { ColumnPicker } from './ColumnPicker';
+
 export function ControlBar({
   includeFilter,
   onIncludeFilterChange,
@@ -9,6 +11,10 @@ export function ControlBar({
   totalLines,
   onSettingsClick,
   onClearClick,
+  fields = [],
+  columns = [],
+  onColumnsChange,
+  onColumnsOpen,
 }) {
   return (
     <div style={styles.controlBar}>
@@ -46,6 +52,12 @@ export function ControlBar({
             </>
           )}
         </div>
+        <ColumnPicker
+          fields={fields}
+          columns={columns}
+          onChange={onColumnsChange}
+          onOpen={onColumnsOpen}
+        />
         <button 
           type="button"
           style={styles.clearButton}
@@ -1,7 +1,8 @@
 import { useState, useEffect } from 'preact/hooks';
 import { K8sConnector } from './K8sConnector';
+import { JournalConnector } from './JournalConnector';
 
-export function DropZone({ isDragging, onFileSelect, onK8sConnect }) {
+export function DropZone({ isDragging, onFileSelect, onK8sConnect, onJournalConnect }) {
   const [filePath, setFilePath] = useState('');
   const [recentFiles, setRecentFiles] = useState([]);
   const [showRecent, setShowRecent] = useState(false);
@@ -98,6 +99,25 @@ export function DropZone({ isDragging, onFileSelect, onK8sConnect }) {
             <K8sConnector onConnect={onK8sConnect} />
           </div>
         </div>
+
+        {/* OR Divider */}
+        <div style={styles.divider}>
+          <div style={styles.orText}>OR</div>
+        </div>
+
+        {/* Journal Source */}
+        <div style={styles.sourceCard}>
+          <div style={styles.dropZone}>
+            <div style={styles.icon}>📜</div>
+            <div style={styles.message}>
+              Follow systemd Journal
+            </div>
+            <JournalConnector onConnect={onJournalConnect} />
+            <div style={styles.hint}>
+              Pick a unit from the list or leave it empty for all units
+            </div>
+          </div>
+        </div>
       </div>
     </div>
   );
@@ -0,0 +1,138 @@
+import { useState, useEffect } from 'preact/hooks';
+
+export function JournalConnector({ onConnect }) {
+  const [unit, setUnit] = useState('');
+  const [units, setUnits] = useState([]);
+  const [showUnits, setShowUnits] = useState(false);
+  const [hoveredIndex, setHoveredIndex] = useState(null);
+  const [error, setError] = useState(null);
+
+  useEffect(() => {
+    // Load units with journal entries
+    fetch('/api/journal/units')
+      .then(async res => {
+        if (!res.ok) {
+          throw new Error(await res.text());
+        }
+        return res.json();
+      })
+      .then(list => {
+        setUnits(list || []);
+        setError(null);
+      })
+      .catch(err => {
+        console.error('Failed to load journal units:', err);
+        setError('Journal not available: ' + err.message);
+      });
+  }, []);
+
+  const handleSubmit = (e) => {
+    e.preventDefault();
+    // An empty unit follows the whole journal
+    onConnect({ unit: unit.trim() });
+  };
+
+  const handleUnitClick = (name) => {
+    setUnit(name);
+    setShowUnits(false);
+  };
+
+  const filteredUnits = units.filter(name =>
+    name.toLowerCase().includes(unit.trim().toLowerCase())
+  );
+
+  return (
+    <div style={styles.container}>
+      <form onSubmit={handleSubmit} style={styles.form}>
+        <input
+          type="text"
+          placeholder="Unit (empty for all), e.g. nginx.service"
+          value={unit}
+          onInput={(e) => {
+            setUnit(e.target.value);
+            setShowUnits(true);
+          }}
+          onFocus={() => setShowUnits(true)}
+          style={styles.input}
+        />
+        <button type="submit" style={styles.button}>
+          Open
+        </button>
+      </form>
+
+      {showUnits && filteredUnits.length > 0 && (
+        <div style={styles.unitList}>
+          {filteredUnits.map((name, index) => (
+            <div
+              key={name}
+              style={{
+                ...styles.unitItem,
+                ...(hoveredIndex === index ? { backgroundColor: '#3c3c3c' } : {})
+              }}
+              onClick={() => handleUnitClick(name)}
+              onMouseEnter={() => setHoveredIndex(index)}
+              onMouseLeave={() => setHoveredIndex(null)}
+            >
+              {name}
+            </div>
+          ))}
+        </div>
+      )}
+
+      {error && <div style={styles.error}>{error}</div>}
+    </div>
+  );
+}
+
+const styles = {
+  container: {
+    width: '100%',
+    maxWidth: '500px',
+  },
+  form: {
+    display: 'flex',
+    gap: '8px',
+    width: '100%',
+    margin: '20px 0 10px 0',
+  },
+  input: {
+    flex: 1,
+    padding: '8px 12px',
+    backgroundColor: '#3c3c3c',
+    border: '1px solid #555',
+    color: '#d4d4d4',
+    borderRadius: '4px',
+    fontSize: '13px',
+    outline: 'none',
+  },
+  button: {
+    padding: '8px 24px',
+    backgroundColor: '#0e639c',
+    border: 'none',
+    color: 'white',
+    borderRadius: '4px',
+    cursor: 'pointer',
+    fontSize: '13px',
+    fontWeight: '500',
+  },
+  unitList: {
+    maxHeight: '200px',
+    overflowY: 'auto',
+    backgroundColor: '#2d2d30',
+    border: '1px solid #3c3c3c',
+    borderRadius: '4px',
+  },
+  unitItem: {
+    padding: '8px 12px',
+    fontSize: '13px',
+    color: '#d4d4d4',
+    cursor: 'pointer',
+    fontFamily: 'monospace',
+    transition: 'background-color 0.1s',
+  },
+  error: {
+    marginTop: '10px',
+    fontSize: '13px',
+    color: '#f48771',
+  },
+};
@@ -1,25 +1,8 @@
 import { useEffect, useRef, useState } from 'preact/hooks';
 import { FixedSizeList as List } from 'react-window';
-import AnsiToHtml from 'ansi-to-html';
+import { ansiConverter } from '../ansi';
 
-const ansiConverter = new AnsiToHtml({
-  fg: '#d4d4d4',
-  bg: '#1e1e1e',
-  newline: false,
-  escapeXML: true,
-  colors: {
-    0: '#000000',  // black
-    1: '#cd3131',  // red
-    2: '#0dbc79',  // green
-    3: '#e5e510',  // yellow
-    4: '#2472c8',  // blue
-    5: '#bc3fbc',  // magenta
-    6: '#11a8cd',  // cyan
-    7: '#e5e5e5',  // white
-  }
-});
-
-export function LogViewer({ lines, autoScroll, title, renderAnsi = false, highlightedLineIndex = null, onLineClick = null, onLineDoubleClick = null }) {
+export function LogViewer({ lines, autoScroll, title, renderAnsi = false, highlightedLineIndex = null, onLineClick = null, onLineDoubleClick = null, lineFields = null, columns = [], lineMarks = null, lineNumbers = null }) {
   const listRef = useRef(null);
   const containerRef = useRef(null);
   const [height, setHeight] = useState(400);
@@ -42,7 +25,8 @@ export function LogViewer({ lines, autoScroll, title, renderAnsi = false, highli
       if (containerRef.current) {
         const rect = containerRef.current.getBoundingClientRect();
         const titleHeight = title ? 30 : 0;
-        setHeight(Math.max(100, rect.height - titleHeight));
+        const headerHeight = columns.length > 0 ? 21 : 0;
+        setHeight(Math.max(100, rect.height - titleHeight - headerHeight));
       }
     };
 
@@ -56,7 +40,7 @@ export function LogViewer({ lines, autoScroll, title, renderAnsi = false, highli
     return () => {
       resizeObserver.disconnect();
     };
-  }, [title]);
+  }, [title, columns.length > 0]);
 
   const Row = ({ index, style }) => {
     const lineContent = lines[index];
@@ -75,12 +59,16 @@ export function LogViewer({ lines, autoScroll, title, renderAnsi = false, highli
     
     const displayContent = renderAnsi ? ansiConverter.toHtml(actualContent) : actualContent;
     const isHighlighted = highlightedLineIndex === index;
+    const fields = (lineFields && lineFields[index]) || {};
+    // Marked lines (such as bookmarks) get a colored left border
+    const mark = lineMarks && lineMarks[index];
     
     return (
       <div 
         style={{ 
           ...style, 
           ...rowStyle,
+          ...(lineMarks ? { borderLeft: `3px solid ${mark || 'transparent'}` } : {}),
           backgroundColor: isHighlighted ? '#3a3d41' : 'transparent'
         }}
         onDblClick={() => onLineDoubleClick && onLineDoubleClick(index, lineContent)}
@@ -93,7 +81,7 @@ export function LogViewer({ lines, autoScroll, title, renderAnsi = false, highli
           }}
           onClick={() => onLineClick && onLineClick(index)}
         >
-          {index + 1}
+          {lineNumbers ? lineNumbers[index] : index + 1}
         </span>
         {prefix && (
           <span style={{ 
@@ -104,6 +92,11 @@ export function LogViewer({ lines, autoScroll, title, renderAnsi = false, highli
             [{prefix}]{' '}
           </span>
         )}
+        {columns.map(name => (
+          <span key={name} style={columnCellStyle} title={fields[name]}>
+            {fields[name] || ''}
+          </span>
+        ))}
         {renderAnsi ? (
           <span 
             style={lineContentStyle} 
@@ -123,6 +116,17 @@ export function LogViewer({ lines, autoScroll, title, renderAnsi = false, highli
           {title}
         </div>
       )}
+      {columns.length > 0 && (
+        <div style={{ ...columnHeaderStyle, ...(lineMarks ? { borderLeft: '3px solid transparent' } : {}) }}>
+          <span style={lineNumberStyle} />
+          {columns.map(name => (
+            <span key={name} style={columnCellStyle} title={name}>
+              {name}
+            </span>
+          ))}
+          <span style={lineContentStyle}>message</span>
+        </div>
+      )}
       <div style={{ flex: 1, overflow: 'hidden' }}>
         <List
           ref={listRef}
@@ -170,6 +174,24 @@ const prefixStyle = {
   userSelect: 'none',
 };
 
+const columnHeaderStyle = {
+  ...rowStyle,
+  color: '#cccccc',
+  fontWeight: '500',
+  backgroundColor: '#252526',
+  overflow: 'hidden',
+};
+
+const columnCellStyle = {
+  color: '#9cdcfe',
+  width: '140px',
+  minWidth: '140px',
+  marginRight: '12px',
+  overflow: 'hidden',
+  textOverflow: 'ellipsis',
+  whiteSpace: 'pre',
+};
+
 const lineContentStyle = {
   color: '#d4d4d4',
   whiteSpace: 'pre',
@@ -27,8 +27,22 @@ const getSourceColor = (sourceName, sourceIndex) => {
   return SOURCE_COLORS[sourceIndex % SOURCE_COLORS.length];
 };
 
+// Fields of each line of a lines/initial message (null for lines without)
+const messageFields = (data) => {
+  if (data.records) {
+    return data.records.map(record => record.fields || null);
+  }
+  return noFields((data.lines || []).length);
+};
+
+// Empty fields for lines that have none, like merged lines
+const noFields = (count) => new Array(count).fill(null);
+
 export const LogViewerTab = forwardRef(({ tabId, onTitleChange }, ref) => {
   const [lines, setLines] = useState([]);
+  const [lineFields, setLineFields] = useState([]); // Fields of each line, parallel to lines
+  const [fields, setFields] = useState([]); // Fields seen in the source
+  const [columns, setColumns] = useState([]); // Fields shown as columns
   const [logSources, setLogSources] = useState([]); // Array of {id, name, color}
   const [mergedTabRefs, setMergedTabRefs] = useState([]); // Refs to merged tabs
   const [currentSourceId, setCurrentSourceId] = useState(null); // Primary source
@@ -73,6 +87,7 @@ export const LogViewerTab = forwardRef(({ tabId, onTitleChange }, ref) => {
         const sourcePrefixedLines = sourceData.lines.map(line => `[${sourceName}]|||${sourceColor}|||${line}`);
         
         setLines([...currentPrefixedLines, ...sourcePrefixedLines]);
+        setLineFields(prev => [...prev, ...noFields(sourceData.lines.length)]);
         
         // Store colors in ref for quick lookup
         sourceColorMapRef.current[fileName] = currentColor;
@@ -93,6 +108,7 @@ export const LogViewerTab = forwardRef(({ tabId, onTitleChange }, ref) => {
         
         // Just append new lines
         setLines(prev => [...prev, ...prefixedLines]);
+        setLineFields(prev => [...prev, ...noFields(prefixedLines.length)]);
         
         // Add source if it's new
         if (!logSources.find(s => s.id === sourceTabId)) {
@@ -113,6 +129,7 @@ export const LogViewerTab = forwardRef(({ tabId, onTitleChange }, ref) => {
       // Add new lines with prefix and color
       const prefixedLines = newLines.map(line => `[${sourceName}]|||${color}|||${line}`);
       setLines(prev => [...prev, ...prefixedLines]);
+      setLineFields(prev => [...prev, ...noFields(prefixedLines.length)]);
     },
     subscribeToMessages: (callback) => {
       // Allow another tab to subscribe to our messages
@@ -194,6 +211,7 @@ export const LogViewerTab = forwardRef(({ tabId, onTitleChange }, ref) => {
       case 'lines':
         const newLines = shouldPrefix ? data.lines.map(line => `${prefix}${line}`) : data.lines;
         setLines(prev => [...prev, ...newLines]);
+        setLineFields(prev => [...prev, ...messageFields(data)]);
         
         // Notify subscribers (merged tabs) with UNPREFIXED lines
         // Let the subscriber add its own prefix
@@ -204,9 +222,17 @@ export const LogViewerTab = forwardRef(({ tabId, onTitleChange }, ref) => {
       case 'initial':
         const initialLines = shouldPrefix ? (data.lines || []).map(line => `${prefix}${line}`) : (data.lines || []);
         setLines(initialLines);
+        setLineFields(messageFields(data));
+        // Load the saved columns of the source
+        sendMessage({ type: 'get-fields' });
         break;
       case 'clear':
         setLines([]);
+        setLineFields([]);
+        break;
+      case 'fields':
+        setFields(data.fields || []);
+        setColumns(data.columns || []);
         break;
       case 'error':
         console.error('WebSocket error:', data.message || data.error);
@@ -286,6 +312,23 @@ export const LogViewerTab = forwardRef(({ tabId, onTitleChange }, ref) => {
     }
   };
 
+  const handleJournalConnect = (journalConfig) => {
+    if (connected) {
+      const message = {
+        type: 'open-journal',
+        unit: journalConfig.unit,
+        // tail is omitted - backend will use settings value
+      };
+      sendMessage(message);
+
+      const displayName = journalConfig.unit || 'journal';
+      setFileName(displayName);
+      onTitleChange(displayName);
+    } else {
+      alert('WebSocket not connected. Please wait...');
+    }
+  };
+
   const handleDragOver = (e) => {
     e.preventDefault();
     setIsDragging(true);
@@ -352,8 +395,9 @@ export const LogViewerTab = forwardRef(({ tabId, onTitleChange }, ref) => {
       }
     }
 
-    return { lines: filtered, originalIndices };
-  }, [lines, includeFilter, excludeFilter]);
+    const fields = originalIndices.map(index => lineFields[index]);
+    return { lines: filtered, originalIndices, fields };
+  }, [lines, lineFields, includeFilter, excludeFilter]);
 
   const handleLineClick = (filteredIndex) => {
     const originalIndex = filteredLines.originalIndices[filteredIndex];
@@ -371,6 +415,12 @@ export const LogViewerTab = forwardRef(({ tabId, onTitleChange }, ref) => {
     setModalLineNumber(null);
   };
 
+  const handleColumnsChange = (nextColumns) => {
+    // The reply ("fields") confirms the saved columns
+    setColumns(nextColumns);
+    sendMessage({ type: 'set-columns', columns: nextColumns });
+  };
+
   const hasLog = lines.length > 0;
   const hasConnection = fileName !== ''; // Check if connected to a log source
   const hasFilters = includeFilter || excludeFilter;
@@ -422,12 +472,15 @@ export const LogViewerTab = forwardRef(({ tabId, onTitleChange }, ref) => {
               renderAnsi={renderAnsiTopPane}
               highlightedLineIndex={highlightedLineIndex}
               onLineDoubleClick={handleLineDoubleClick}
+              lineFields={lineFields}
+              columns={columns}
             />
           ) : (
             <DropZone 
               isDragging={isDragging} 
               onFileSelect={handleFileOpen}
               onK8sConnect={handleK8sConnect}
+              onJournalConnect={handleJournalConnect}
             />
           )
         }
@@ -442,8 +495,13 @@ export const LogViewerTab = forwardRef(({ tabId, onTitleChange }, ref) => {
             filteredLineCount={filteredLines.lines.length}
             totalLines={lines.length}
             onSettingsClick={() => setSettingsOpen(true)}
+            fields={fields}
+            columns={columns}
+            onColumnsChange={handleColumnsChange}
+            onColumnsOpen={() => sendMessage({ type: 'get-fields' })}
             onClearClick={() => {
               setLines([]);
+              setLineFields([]);
               setHighlightedLineIndex(null);
               // Help garbage collection by clearing the ref
               if (Object.keys(sourceColorMapRef.current).length > 0) {
@@ -461,6 +519,8 @@ export const LogViewerTab = forwardRef(({ tabId, onTitleChange }, ref) => {
               renderAnsi={renderAnsiBottomPane}
               onLineClick={handleLineClick}
               onLineDoubleClick={handleLineDoubleClick}
+              lineFields={filteredLines.fields}
+              columns={columns}
             />
           ) : (
             <div style={{ height: '100%', backgroundColor: '#1e1e1e' }} />
@@ -0,0 +1,306 @@
+import { useMemo, useState } from 'preact/hooks';
+import { LogViewer } from './LogViewer';
+
+// ANSI escape sequences, stripped before searching
+const ansiPattern = /\x1b\[[0-9;]*[A-Za-z]/g;
+
+// formatTime renders a line's Unix milliseconds like the snapshot header
+function formatTime(ms) {
+  return new Date(ms).toISOString().replace('T', ' ').replace('Z', '');
+}
+
+// matcher returns a function testing text against the search, null when
+// there is no search, or false for an invalid regex
+function matcher(query, regex, matchCase) {
+  if (!query) {
+    return null;
+  }
+  if (regex) {
+    try {
+      const re = new RegExp(query, matchCase ? '' : 'i');
+      return text => re.test(text);
+    } catch (e) {
+      return false;
+    }
+  }
+  const needle = matchCase ? query : query.toLowerCase();
+  return text => (matchCase ? text : text.toLowerCase()).includes(needle);
+}
+
+// SnapshotViewer shows an HTML snapshot exported by the server: the lines
+// a tab was showing with the source's bookmarks, and a search over them
+export function SnapshotViewer({ snapshot }) {
+  const [query, setQuery] = useState('');
+  const [regex, setRegex] = useState(false);
+  const [matchCase, setMatchCase] = useState(false);
+  const [onlyMatches, setOnlyMatches] = useState(false);
+  const [current, setCurrent] = useState(-1);
+  const [selected, setSelected] = useState(null);
+
+  const lines = snapshot.lines || [];
+  const bookmarks = snapshot.bookmarks || [];
+  const texts = useMemo(() => lines.map(line => line.text.replace(ansiPattern, '')), [lines]);
+
+  const match = matcher(query, regex, matchCase);
+  const hits = useMemo(() => {
+    if (!match) {
+      return [];
+    }
+    const found = [];
+    texts.forEach((text, i) => {
+      if (match(text)) {
+        found.push(i);
+      }
+    });
+    return found;
+  }, [texts, query, regex, matchCase]);
+
+  // The lines shown, as indexes into the snapshot's lines
+  const shown = useMemo(() => {
+    if (match && onlyMatches) {
+      return hits;
+    }
+    return lines.map((_, i) => i);
+  }, [lines, hits, onlyMatches, !!match]);
+
+  const hasTimes = lines.some(line => line.timestamp);
+  const columns = hasTimes ? ['time'] : [];
+  const lineFields = hasTimes
+    ? shown.map(i => ({ time: lines[i].timestamp ? formatTime(lines[i].timestamp) : '' }))
+    : null;
+
+  const highlighted = current >= 0 && current < hits.length ? hits[current] : selected;
+  const highlightedIndex = highlighted === null ? null : shown.indexOf(highlighted);
+
+  const search = (value, options = {}) => {
+    setQuery(value);
+    setCurrent(-1);
+    if ('regex' in options) setRegex(options.regex);
+    if ('matchCase' in options) setMatchCase(options.matchCase);
+  };
+
+  const step = (delta) => {
+    if (hits.length === 0) {
+      return;
+    }
+    setSelected(null);
+    setCurrent((current + delta + hits.length) % hits.length);
+  };
+
+  const jumpToBookmark = (id) => {
+    const i = lines.findIndex(line => line.bookmark && line.bookmark.id === id);
+    if (i >= 0) {
+      setOnlyMatches(false);
+      setCurrent(-1);
+      setSelected(i);
+    }
+  };
+
+  let count = '';
+  if (match === false) {
+    count = 'Invalid regex';
+  } else if (match) {
+    count = current >= 0
+      ? `${current + 1} of ${hits.length} matches`
+      : `${hits.length} matches`;
+  }
+
+  return (
+    <div style={styles.container}>
+      <div style={styles.header}>
+        <div style={styles.title}>{snapshot.source}</div>
+        <div style={styles.meta}>
+          {lines.length.toLocaleString()} lines · snapshot taken {formatTime(Date.parse(snapshot.created)).slice(0, 19)} UTC
+          {(snapshot.filter || []).map(condition => (
+            <span key={condition} style={styles.chip}>{condition}</span>
+          ))}
+        </div>
+        <div style={styles.search}>
+          <input
+            type="text"
+            placeholder="Search (Enter: next, Shift+Enter: previous)"
+            value={query}
+            onInput={(e) => search(e.target.value)}
+            onKeyDown={(e) => {
+              if (e.key === 'Enter') {
+                step(e.shiftKey ? -1 : 1);
+              }
+            }}
+            style={styles.input}
+            autoFocus
+          />
+          <label style={styles.checkbox}>
+            <input type="checkbox" checked={regex} onChange={(e) => search(query, { regex: e.target.checked })} />
+            Regex
+          </label>
+          <label style={styles.checkbox}>
+            <input type="checkbox" checked={matchCase} onChange={(e) => search(query, { matchCase: e.target.checked })} />
+            Case
+          </label>
+          <label style={styles.checkbox}>
+            <input type="checkbox" checked={onlyMatches} onChange={(e) => setOnlyMatches(e.target.checked)} />
+            Only matches
+          </label>
+          <button type="button" style={styles.button} onClick={() => step(-1)}>↑</button>
+          <button type="button" style={styles.button} onClick={() => step(1)}>↓</button>
+          <span style={styles.meta}>{count}</span>
+        </div>
+      </div>
+
+      {snapshot.truncated && (
+        <div style={styles.notice}>
+          More lines passed the filter; only the last {lines.length.toLocaleString()} are included.
+        </div>
+      )}
+
+      <div style={styles.body}>
+        <div style={styles.lines}>
+          <LogViewer
+            lines={shown.map(i => lines[i].text)}
+            renderAnsi={true}
+            autoScroll={false}
+            highlightedLineIndex={highlightedIndex >= 0 ? highlightedIndex : null}
+            onLineClick={(index) => { setCurrent(-1); setSelected(shown[index]); }}
+            lineFields={lineFields}
+            columns={columns}
+            lineMarks={shown.map(i => lines[i].bookmark ? lines[i].bookmark.color : null)}
+            lineNumbers={shown.map(i => i + 1)}
+          />
+        </div>
+
+        {bookmarks.length > 0 && (
+          <div style={styles.sidebar}>
+            <div style={styles.sidebarTitle}>Bookmarks</div>
+            {bookmarks.map(bookmark => (
+              <div
+                key={bookmark.id}
+                style={{
+                  ...styles.bookmark,
+                  borderLeftColor: bookmark.color,
+                  opacity: bookmark.shown ? 1 : 0.5,
+                  cursor: bookmark.shown ? 'pointer' : 'default',
+                }}
+                title={bookmark.shown ? '' : 'Not in this snapshot'}
+                onClick={() => bookmark.shown && jumpToBookmark(bookmark.id)}
+              >
+                <div style={styles.note}>{bookmark.note || '(no note)'}</div>
+                <div style={styles.bookmarkText}>{(bookmark.text || '').replace(ansiPattern, '')}</div>
+              </div>
+            ))}
+          </div>
+        )}
+      </div>
+    </div>
+  );
+}
+
+const styles = {
+  container: {
+    height: '100%',
+    display: 'flex',
+    flexDirection: 'column',
+  },
+  header: {
+    backgroundColor: '#252526',
+    borderBottom: '1px solid #3c3c3c',
+    padding: '8px 12px',
+  },
+  title: {
+    fontSize: '14px',
+    fontWeight: '600',
+    color: '#ffffff',
+    wordBreak: 'break-all',
+    marginBottom: '4px',
+  },
+  meta: {
+    color: '#9d9d9d',
+    fontSize: '12px',
+  },
+  chip: {
+    display: 'inline-block',
+    margin: '2px 0 0 6px',
+    padding: '0 6px',
+    borderRadius: '3px',
+    backgroundColor: '#3c3c3c',
+    color: '#d4d4d4',
+  },
+  search: {
+    display: 'flex',
+    flexWrap: 'wrap',
+    alignItems: 'center',
+    gap: '8px',
+    marginTop: '6px',
+  },
+  input: {
+    width: '320px',
+    maxWidth: '100%',
+    padding: '4px 8px',
+    backgroundColor: '#3c3c3c',
+    border: '1px solid #555',
+    borderRadius: '4px',
+    color: '#e0e0e0',
+    fontSize: '13px',
+  },
+  checkbox: {
+    display: 'flex',
+    alignItems: 'center',
+    gap: '4px',
+    fontSize: '13px',
+    color: '#9d9d9d',
+    cursor: 'pointer',
+  },
+  button: {
+    background: 'none',
+    border: '1px solid #555',
+    borderRadius: '4px',
+    color: '#e0e0e0',
+    cursor: 'pointer',
+    padding: '2px 8px',
+    fontSize: '13px',
+  },
+  notice: {
+    padding: '6px 12px',
+    color: '#cca700',
+    fontSize: '13px',
+  },
+  body: {
+    flex: 1,
+    display: 'flex',
+    minHeight: 0,
+  },
+  lines: {
+    flex: 1,
+    minWidth: 0,
+  },
+  sidebar: {
+    width: '260px',
+    flex: 'none',
+    borderLeft: '1px solid #3c3c3c',
+    padding: '8px',
+    overflowY: 'auto',
+  },
+  sidebarTitle: {
+    marginBottom: '6px',
+    fontSize: '12px',
+    color: '#9d9d9d',
+    textTransform: 'uppercase',
+  },
+  bookmark: {
+    marginBottom: '6px',
+    padding: '4px 6px',
+    borderLeft: '3px solid',
+    backgroundColor: '#252526',
+    fontSize: '13px',
+  },
+  note: {
+    color: '#ffffff',
+  },
+  bookmarkText: {
+    color: '#9d9d9d',
+    fontSize: '11px',
+    fontFamily: 'monospace',
+    overflow: 'hidden',
+    textOverflow: 'ellipsis',
+    whiteSpace: 'nowrap',
+  },
+};
@@ -0,0 +1,8 @@
+import { render } from 'preact';
+import { SnapshotViewer } from './components/SnapshotViewer';
+
+// Entry of the HTML snapshot viewer: the server inlines this bundle into an
+// exported snapshot along with its lines as JSON
+const snapshot = JSON.parse(document.getElementById('snapshot-data').textContent);
+
+render(<SnapshotViewer snapshot={snapshot} />, document.getElementById('app'));
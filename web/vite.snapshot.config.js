@@ -0,0 +1,22 @@
+import { defineConfig } from 'vite';
+import preact from '@preact/preset-vite';
+
+// The HTML snapshot viewer, built as one classic script the server inlines
+// into exported snapshots. Runs after the main build, which empties the
+// static directory.
+export default defineConfig({
+  plugins: [preact()],
+  define: {
+    'process.env.NODE_ENV': JSON.stringify('production'),
+  },
+  build: {
+    outDir: '../internal/server/static/snapshot',
+    emptyOutDir: true,
+    lib: {
+      entry: 'src/snapshot.jsx',
+      name: 'snapshot',
+      formats: ['iife'],
+      fileName: () => 'snapshot.js',
+    },
+  },
+});